@@ -10,6 +10,22 @@ docker run --rm --env-file=.env 570480763436.dkr.ecr.us-east-1.amazonaws.com/com
 
 docker-compose up -d --build --remove-orphans
 
+# Wait for the API to reach Postgres and Redis before cleaning up the old images.
+ready=0
+for i in $(seq 1 30); do
+  if curl -sf http://localhost:8001/readyz > /dev/null; then
+    ready=1
+    break
+  fi
+  sleep 2
+done
+if [ "$ready" -ne 1 ]; then
+  echo "api is not ready"
+  curl -s http://localhost:8001/readyz
+  docker logout
+  exit 1
+fi
+
 docker system prune -af
 
 docker logout
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/comiccruncher/comiccruncher/comic"
 	"github.com/comiccruncher/comiccruncher/internal/log"
+	"github.com/comiccruncher/comiccruncher/internal/metrics"
 	"github.com/comiccruncher/comiccruncher/internal/stringutil"
 	"github.com/aimeelaplant/externalissuesource"
 	"github.com/avast/retry-go"
@@ -227,7 +228,9 @@ func (i *CharacterIssueImporter) listenOnSigInt(syncLogs []*comic.CharacterSyncL
 	}
 }
 
-// Gets all the links to the issues we do not have in the database.
+// Gets all the links to the issues we do not have in the database. This also records why the issues we already
+// have don't count as appearances, so importing a character again backfills the reasons for characters imported
+// before they were recorded.
 func (i *CharacterIssueImporter) nonExistingURLs(vi CharacterVendorInfo, c comic.Character) ([]ExternalVendorURL, error) {
 	// Find all the issues that we could have in the database.
 	localIssues, err := i.issueSvc.IssuesByVendor(vi.vendorIDStrings(), comic.VendorTypeCb, 0, 0)
@@ -238,19 +241,29 @@ func (i *CharacterIssueImporter) nonExistingURLs(vi CharacterVendorInfo, c comic
 	localIssueVendorIDs := make(map[string]bool)
 	// character issues we don't have and need to create
 	characterIssues := make([]*comic.CharacterIssue, 0)
+	// issues that don't count as appearances and why
+	excludedIssues := make([]*comic.CharacterExcludedIssue, 0)
 	for _, localIssue := range localIssues {
 		// add the vendor id to the map
 		localIssueVendorIDs[localIssue.VendorID] = true
 		// create a character issue if it counts as an appearance
-		if isAppearance(localIssue) {
-			characterIssues = append(characterIssues, comic.NewCharacterIssue(c.ID, localIssue.ID, vi.AppearanceType(localIssue)))
+		if reason := exclusionReason(localIssue); reason == "" {
+			ci := comic.NewCharacterIssue(c.ID, localIssue.ID, vi.AppearanceType(localIssue))
+			imp := importance(c, localIssue)
+			ci.Importance = &imp
+			characterIssues = append(characterIssues, ci)
+		} else {
+			excludedIssues = append(excludedIssues, comic.NewCharacterExcludedIssue(c.ID, localIssue.ID, reason))
 		}
 	}
 	// Insert ignore what we possibly don't have.
-	i.logger.Info("inserting what we possibly don't have", zap.Int("character issues", len(characterIssues)))
+	i.logger.Info("inserting what we possibly don't have", zap.Int("character issues", len(characterIssues)), zap.Int("excluded issues", len(excludedIssues)))
 	if err := i.characterSvc.CreateIssues(characterIssues); err != nil {
 		return nil, err
 	}
+	if err := i.characterSvc.ExcludeIssues(excludedIssues); err != nil {
+		return nil, err
+	}
 	linksToFetch := make([]ExternalVendorURL, 0)
 	for k, v := range vi.VendorIDs {
 		// if the vendor id isn't in the map of local issues, then we want to put the link in the `links` slice.
@@ -302,14 +315,19 @@ func (i *CharacterIssueImporter) importIssues(character comic.Character, doReset
 	for _, l := range linksToFetch {
 		linkCh <- l
 	}
+	// Issues that don't count as appearances get inserted all at once after the work is collected. If the process
+	// quits before then, they're still excluded the next time since the issues themselves were saved.
+	excludedIssues := make([]*comic.CharacterExcludedIssue, 0)
 	// Collect the results of the work.
 	for idx := 0; idx < len(linksToFetch); idx++ {
 		ish := <-issueCh
 		// Skip the issue if we get a blank one or the year is is less than one.
 		if ish.VendorID == "" || ish.SaleDate.Year() <= 1 {
 			i.logger.Warn("received blank issue. skipping.", zap.String("link", linksToFetch[idx].String()))
+			metrics.BlankIssuesSkipped.Inc()
 			continue
 		}
+		metrics.IssuesFetched.Inc()
 		i.logger.Info("received issue", zap.String("issue.VendorId", ish.VendorID))
 		// Ideally creating an issue and character issue shouldn't be in a for loop.
 		// Maybe put chunks in a pool and do bulk creation.
@@ -318,12 +336,18 @@ func (i *CharacterIssueImporter) importIssues(character comic.Character, doReset
 		if err := i.issueSvc.Create(ish); err != nil {
 			return 0, err
 		}
-		if isAppearance(ish) {
-			if _, err := i.characterSvc.CreateIssueP(character.ID, ish.ID, vi.AppearanceType(ish), nil); err != nil {
+		if reason := exclusionReason(ish); reason == "" {
+			imp := importance(character, ish)
+			if _, err := i.characterSvc.CreateIssueP(character.ID, ish.ID, vi.AppearanceType(ish), &imp); err != nil {
 				return 0, err
 			}
+		} else {
+			excludedIssues = append(excludedIssues, comic.NewCharacterExcludedIssue(character.ID, ish.ID, reason))
 		}
 	}
+	if err := i.characterSvc.ExcludeIssues(excludedIssues); err != nil {
+		return 0, err
+	}
 	i.logger.Info("issues to attempt to sync!", zap.Int("total", len(linksToFetch)), zap.String("character", character.Slug.Value()))
 	if doReset {
 		// delete all the issues first.
@@ -399,7 +423,7 @@ func (i *CharacterIssueImporter) ImportAll(slugs []comic.CharacterSlug, doReset
 			}
 		}
 	}
-	// Now refresh all the views.
+	// Now refresh the trending and co-appearance views. The ranks are computed from the counts already.
 	if err := i.refresher.RefreshAll(); err != nil {
 		return err
 	}
@@ -429,6 +453,11 @@ func (i *CharacterIssueImporter) updateSyncLog(cLog *comic.CharacterSyncLog, new
 	if err != nil {
 		i.logger.Error("error updating sync log", zap.Error(err))
 	}
+	if newStatus == comic.Success {
+		metrics.SyncLogs.WithLabelValues("success").Inc()
+	} else if newStatus == comic.Fail {
+		metrics.SyncLogs.WithLabelValues("fail").Inc()
+	}
 }
 
 // requestIssues requests issue information from an external source link (the caller sends string to the `links`) and then converts the
@@ -474,20 +503,40 @@ func (i *CharacterIssueImporter) requestIssues(workerID int, links <-chan Extern
 	}
 }
 
-// isAppearance checks that the issue should count as an issue appearance for the character.
-func isAppearance(issue *comic.Issue) bool {
-	if !issue.IsVariant && // it's not a variant
-		!issue.IsReprint && // it's not a reprint.
-		countsAsAppearance[issue.Format] && // the format counts as an appearance
-		// the issue actually has a sale date.
-		issue.SaleDate.Year() > 1 &&
-		// Checks that the external issue's publisher matches up with allowed publishers
-		// There can be multiple publishers such as Timely Comics that are actually Marvel
-		// or crossovers with different publishers.
-		stringutil.AnyFunc(issue.VendorPublisher, allowedPublishers, strings.Contains) {
-		return true
-	}
-	return false
+// exclusionReason gets why the issue shouldn't count as an issue appearance for the character.
+// Returns an empty reason if the issue counts as an appearance.
+func exclusionReason(issue *comic.Issue) comic.ExclusionReason {
+	switch {
+	case issue.IsVariant:
+		return comic.ExcludedVariant
+	case issue.IsReprint:
+		return comic.ExcludedReprint
+	case !countsAsAppearance[issue.Format]:
+		return comic.ExcludedFormat
+	// the issue actually has a sale date.
+	case issue.SaleDate.Year() <= 1:
+		return comic.ExcludedNoSaleDate
+	// Checks that the external issue's publisher matches up with allowed publishers
+	// There can be multiple publishers such as Timely Comics that are actually Marvel
+	// or crossovers with different publishers.
+	case !stringutil.AnyFunc(issue.VendorPublisher, allowedPublishers, strings.Contains):
+		return comic.ExcludedPublisher
+	}
+	return ""
+}
+
+// importance guesses the character's importance in the issue since the external source doesn't say.
+// The character is a major character if the series is named after them and a minor one otherwise.
+// The name has to be a whole word in the series name, so Storm isn't a major character in Brainstorm.
+// Cameos only come from curators.
+func importance(c comic.Character, issue *comic.Issue) comic.Importance {
+	series := strings.ToLower(issue.VendorSeriesName)
+	for _, name := range []string{c.Name, c.OtherName} {
+		if name = strings.ToLower(strings.TrimSpace(name)); stringutil.ContainsWord(series, name) {
+			return comic.Major
+		}
+	}
+	return comic.Minor
 }
 
 // NewCharacterIssueImporter creates a new character issue importer.
@@ -498,7 +547,7 @@ func NewCharacterIssueImporter(
 	cr := comic.NewPGCharacterRepository(db)
 	ctr := comic.NewRedisCharacterThumbRepository(redis)
 	pr := comic.NewPGPopularRepository(db, ctr)
-	ss := comic.NewCharacterStatsSyncer(redis, cr, pr)
+	ss := comic.NewCharacterStatsSyncer(redis, cr, pr, comic.NewPGCareerRepository(db))
 	aw := comic.NewRedisAppearancesPerYearRepository(redis)
 	p := comic.NewPGPopularRepository(db, ctr)
 	externalSource := externalissuesource.NewCbExternalSource(externalissuesource.NewHttpClient(), &externalissuesource.CbExternalSourceConfig{})
@@ -510,7 +559,7 @@ func NewCharacterIssueImporter(
 		appearanceSyncer:  as,
 		logger:            log.CEREBRO(),
 		extractor:         NewCharacterCBExtractor(externalSource),
-		refresher:         p,
+		refresher:         comic.NewStatsCacheRefresher(comic.NewRankSnapshotRefresher(p, comic.NewPGRankSnapshotRepository(db)), redis),
 		statsSyncer:       ss,
 	}
 }
@@ -2,14 +2,27 @@ package cmd
 
 import (
 	"github.com/comiccruncher/comiccruncher/internal/log"
+	"github.com/comiccruncher/comiccruncher/internal/metrics"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
+	"os"
 )
 
 // RootCmd is the the root command for cerebro.
 var RootCmd = &cobra.Command{
 	Use:   "cerebro",
 	Short: "The application for importing resources from external sources.",
+	// Imports don't run long enough to get scraped, so the metrics get pushed to the sink when a command is done.
+	// The sink is either a Pushgateway URL or a file for node_exporter's textfile collector.
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		sink := os.Getenv("CC_METRICS_SINK")
+		if sink == "" {
+			return
+		}
+		if err := metrics.Push(sink, "cerebro"); err != nil {
+			log.CEREBRO().Error("error pushing metrics", zap.String("sink", sink), zap.Error(err))
+		}
+	},
 }
 
 // Exec executes the root command.
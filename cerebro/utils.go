@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/aimeelaplant/externalissuesource"
 	"github.com/avast/retry-go"
+	"github.com/comiccruncher/comiccruncher/internal/metrics"
 	"log"
 	"net/http"
 	"strings"
@@ -30,6 +31,7 @@ func retryURL(f func() (string, error)) error {
 		if err != nil {
 			if isConnectionError(err) {
 				log.Println(fmt.Sprintf("got connection error for %s. retrying.", url))
+				metrics.Retries.Inc()
 				return err
 			}
 			errCh <- err
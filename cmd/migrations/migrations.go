@@ -9,26 +9,21 @@ import (
 	"github.com/go-pg/pg/orm"
 	"go.uber.org/zap"
 	"os"
+	"strings"
 )
 
+// Sets the importance of the character issues that don't have one like the importer guesses it: the character is a
+// major character if their name or other name is a whole word in the series name and a minor one otherwise.
+const importanceBackfillSQL = `
+	UPDATE character_issues ci
+	SET importance = CASE WHEN
+		lower(i.vendor_series_name) ~ ('(^|[^[:alnum:]])' || regexp_replace(lower(trim(c.name)), '([^[:alnum:][:space:]])', '\\\1', 'g') || '($|[^[:alnum:]])')
+		OR (trim(coalesce(c.other_name, '')) != '' AND lower(i.vendor_series_name) ~ ('(^|[^[:alnum:]])' || regexp_replace(lower(trim(c.other_name)), '([^[:alnum:][:space:]])', '\\\1', 'g') || '($|[^[:alnum:]])'))
+		THEN ?0 ELSE ?1 END
+	FROM issues i, characters c
+	WHERE i.id = ci.issue_id AND c.id = ci.character_id AND ci.importance IS NULL`
+
 var (
-	materializedViews = map[string]map[comic.AppearanceType]uint{
-		"mv_ranked_characters": {
-			comic.Main | comic.Alternate: 0,
-		},
-		"mv_ranked_characters_main": {
-			comic.Main: 0,
-		},
-		"mv_ranked_characters_alternate": {
-			comic.Alternate: 0,
-		},
-		"mv_ranked_characters_marvel_main": {
-			comic.Main: 1,
-		},
-		"mv_ranked_characters_dc_main": {
-			comic.Main: 2,
-		},
-	}
 	tables = []interface{}{
 		&comic.Publisher{},
 		&comic.Character{},
@@ -36,6 +31,10 @@ var (
 		&comic.CharacterSyncLog{},
 		&comic.Issue{},
 		&comic.CharacterIssue{},
+		&comic.CharacterExcludedIssue{},
+		&comic.CharacterYearCount{},
+		&comic.ViewRefresh{},
+		&comic.RankSnapshot{},
 	}
 	updatedAtTriggers = []string{
 		"publishers",
@@ -49,12 +48,6 @@ var (
 		IfNotExists:   true,
 		FKConstraints: true,
 	}
-	execs = []string{
-		trendingSQL("mv_trending_characters_marvel", 1),
-		trendingSQL("mv_trending_characters_dc", 2),
-		fmt.Sprintf(`CREATE UNIQUE INDEX IF NOT EXISTS %[1]s_id_idx ON %[1]s(id);`, "mv_trending_characters_marvel"),
-		fmt.Sprintf(`CREATE UNIQUE INDEX IF NOT EXISTS %[1]s_id_idx ON %[1]s(id);`, "mv_trending_characters_dc"),
-	}
 )
 
 // Logs the error as fatal and exits.
@@ -102,6 +95,14 @@ func updatedAtTrigger(tableName string) string {
 		$$`, tableName)
 }
 
+// Drops the materialized view and forgets when it was refreshed.
+func dropView(tx *pg.Tx, view comic.MaterializedView) error {
+	if err := logResultIfError(tx.Exec("DROP MATERIALIZED VIEW IF EXISTS " + view.Value())); err != nil {
+		return err
+	}
+	return logResultIfError(tx.Exec("DELETE FROM view_refreshes WHERE view = ?", view))
+}
+
 func mustInstance() *pg.DB {
 	env := os.Getenv("CC_ENVIRONMENT")
 	if env == "test" {
@@ -146,6 +147,8 @@ func main() {
 			CREATE INDEX IF NOT EXISTS characters_other_name_idx_gin ON characters USING GIN(other_name gin_trgm_ops) WHERE is_disabled = false AND (other_name IS NOT NULL AND other_name != '');
 			CREATE INDEX IF NOT EXISTS issues_sale_date_idx ON issues(sale_date);
 			CREATE INDEX IF NOT EXISTS issues_publication_date_idx ON issues(publication_date);
+			CREATE UNIQUE INDEX IF NOT EXISTS rank_snapshots_character_id_category_snapshot_date_idx ON rank_snapshots(character_id, category, snapshot_date);
+			CREATE INDEX IF NOT EXISTS rank_snapshots_snapshot_date_idx ON rank_snapshots(snapshot_date);
 		`)); err != nil {
 			return err
 		}
@@ -170,20 +173,53 @@ func main() {
 				return err
 			}
 		}
+		// character issues from before the importance was guessed on import get the same guess, so they don't
+		// weigh less than the new ones.
+		backfilled, err := tx.Exec(importanceBackfillSQL, comic.Major, comic.Minor)
+		if logIfError(err) != nil {
+			return err
+		}
+		// the ranks get computed from the appearances per year, so they need counting once.
+		// the weighted counts need counting again after the importance gets backfilled.
+		yearCounts, err := tx.Model(&comic.CharacterYearCount{}).Count()
+		if logIfError(err) != nil {
+			return err
+		}
+		if yearCounts == 0 || backfilled.RowsAffected() > 0 {
+			if err := logResultIfError(tx.Exec(comic.YearCountsSQL("TRUE"))); err != nil {
+				return err
+			}
+		}
+		for _, view := range comic.LegacyViews {
+			if err := dropView(tx, view); err != nil {
+				return err
+			}
+		}
 		// views
-		for view, t := range materializedViews {
-			for ty, pubID := range t {
-				if err := logResultIfError(tx.Exec(rankedCharactersSQL(view, ty, pubID))); err != nil {
-					return err
-				}
-				if err := logResultIfError(tx.Exec(fmt.Sprintf(`CREATE UNIQUE INDEX IF NOT EXISTS %[1]s_id_idx ON %[1]s(id);`, view))); err != nil {
+		if err := logResultIfError(tx.Exec(comic.CoAppearanceViewSQL(comic.CoAppearanceView))); err != nil {
+			return err
+		}
+		if err := logResultIfError(tx.Exec(comic.CoAppearanceViewIndexSQL(comic.CoAppearanceView))); err != nil {
+			return err
+		}
+		// publisher views. new publishers get theirs created when the views are refreshed, too.
+		var publishers []*comic.Publisher
+		if err := logIfError(tx.Model(&publishers).Select()); err != nil {
+			return err
+		}
+		refresher := comic.NewPopularRefresher(tx)
+		for _, p := range publishers {
+			// the trending views used to be for the last year only and didn't have the window in the name.
+			legacyTrending := strings.TrimSuffix(comic.PublisherTrendingView(p.Slug, comic.TwelveMonths).Value(), "_12m")
+			if err := logResultIfError(tx.Exec("DROP MATERIALIZED VIEW IF EXISTS " + legacyTrending)); err != nil {
+				return err
+			}
+			for _, view := range comic.LegacyPublisherViews(p.Slug) {
+				if err := dropView(tx, view); err != nil {
 					return err
 				}
 			}
-		}
-		// trending views / other execs
-		for _, exec := range execs {
-			if err := logResultIfError(tx.Exec(exec)); err != nil {
+			if err := logIfError(refresher.CreatePublisherViews(p)); err != nil {
 				return err
 			}
 		}
@@ -196,124 +232,3 @@ func main() {
 
 	log.MIGRATIONS().Info("done")
 }
-
-func rankedCharactersSQL(name string, t comic.AppearanceType, publisherID uint) string {
-	sql := fmt.Sprintf(`
-		CREATE MATERIALIZED VIEW IF NOT EXISTS %s AS
-		  SELECT
-		  dense_rank() OVER (ORDER BY count(ci.id) DESC) AS issue_count_rank,
-		  count(ci.id) as issue_count,
-		  dense_rank() OVER (
-		   ORDER BY
-			 (
-				count(ci.id)
-				/
-				(
-				  CASE
-					WHEN
-					 (date_part('year', current_date)) -  min(date_part('year', i.sale_date)) = 0
-					 THEN 1 -- avoid division by 0
-					ELSE (date_part('year', current_date)) -  min(date_part('year', i.sale_date))
-				  END
-				)
-			 ) DESC) AS average_per_year_rank,
-		  round(count(ci.id)
-		  /
-		  (
-			 CASE
-			   WHEN
-				 (date_part('year', current_date)) -  min(date_part('year', i.sale_date)) = 0
-					   THEN 1 -- avoid division by 0
-			   ELSE (date_part('year', current_date)) -  min(date_part('year', i.sale_date))
-				 END
-			 )::DECIMAL, 2) as average_per_year,
-		  c.id,
-		  c.publisher_id,
-		  c.name,
-		  c.other_name,
-		  c.description,
-		  c.image,
-		  c.slug,
-		  c.vendor_image,
-		  c.vendor_url,
-		  c.vendor_description,
-          p.id as publisher__id,
-          p.slug as publisher__slug,
-          p.name as publisher__name
-		  FROM characters c
-			JOIN character_issues ci ON ci.character_id = c.id
-			JOIN issues i ON i.id = ci.issue_id
-            JOIN publishers p ON p.id = c.publisher_id
-          WHERE 1=1
-		`, name)
-	if t == comic.Main {
-		sql += " AND ci.appearance_type & 1::bit(8) > 0::bit(8)"
-	}
-	if t == comic.Alternate {
-		sql += " AND ci.appearance_type & 2::bit(8) > 0::bit(8)"
-	}
-	if publisherID != 0 {
-		sql += fmt.Sprintf(" AND c.publisher_id = %d", publisherID)
-	}
-	sql += ` AND c.is_disabled = false
-				GROUP BY c.id, p.id
-				ORDER BY issue_count_rank`
-	return sql
-}
-
-func trendingSQL(name string, publisherID uint) string {
-	sql := fmt.Sprintf(`
-		CREATE MATERIALIZED VIEW IF NOT EXISTS %s AS
-		SELECT
-			   dense_rank() OVER (ORDER BY count(ci.id) DESC) AS issue_count_rank,
-			   count(ci.id) as issue_count,
-			   dense_rank() OVER (
-					  ORDER BY
-							 (
-									count(ci.id)
-									/
-									(
-										   CASE
-										   WHEN
-												  (date_part('year', current_date)) -  min(date_part('year', i.sale_date)) = 0
-												  THEN 1 -- avoid division by 0
-										   ELSE (date_part('year', current_date)) -  min(date_part('year', i.sale_date))
-										   END
-									)
-							 ) DESC) AS average_per_year_rank,
-			   round(count(ci.id)
-							/
-					 (
-						 CASE
-								WHEN
-							 (date_part('year', current_date)) -  min(date_part('year', i.sale_date)) = 0
-								   THEN 1 -- avoid division by 0
-								ELSE (date_part('year', current_date)) -  min(date_part('year', i.sale_date))
-							 END
-						 )::DECIMAL, 2) as average_per_year,
-			   c.id,
-			   c.publisher_id,
-			   c.name,
-			   c.other_name,
-			   c.description,
-			   c.image,
-			   c.slug,
-			   c.vendor_image,
-			   c.vendor_url,
-			   c.vendor_description,
-			   p.id as publisher__id,
-			   p.slug as publisher__slug,
-			   p.name as publisher__name
-		FROM characters c
-					JOIN character_issues ci ON ci.character_id = c.id
-					JOIN issues i ON i.id = ci.issue_id
-					JOIN publishers p ON p.id = c.publisher_id
-		WHERE c.publisher_id = %d
-		AND c.is_disabled = FALSE
-		AND i.sale_date > date_trunc('month', CURRENT_DATE) - INTERVAL '1 year'
-		AND ci.appearance_type & B'00000001' > 0::BIT(8)
-		GROUP BY c.slug, c.id, c.name, c.other_name, p.id
-		ORDER BY issue_count DESC
-		LIMIT 50;`, name, publisherID)
-	return sql
-}
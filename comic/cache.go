@@ -12,7 +12,43 @@ type RedisClient interface {
 	Set(key string, value interface{}, expiration time.Duration) *redis.StatusCmd
 	HMSet(key string, fields map[string]interface{}) *redis.StatusCmd
 	HGetAll(key string) *redis.StringStringMapCmd
+	HDel(key string, fields ...string) *redis.IntCmd
 	Del(keys ...string) *redis.IntCmd
+	Eval(script string, keys []string, args ...interface{}) *redis.Cmd
+	Ping() *redis.StatusCmd
+}
+
+// The key for the cached stats about all the characters and issues.
+const redisStatsKey = "stats:all"
+
+// How long the stats stay cached if nothing refreshes the views.
+const statsTTL = 24 * time.Hour
+
+// The key for the cached time the materialized views were last refreshed.
+const redisLastRefreshedKey = "views:last_refreshed"
+
+// How long the time the views were last refreshed stays cached if a refresh didn't remove it.
+const lastRefreshedTTL = time.Hour
+
+// The max number of a character's series to keep in Redis.
+const seriesLimit = 100
+
+// The number of recent years, including this one, that a character's appearances per month go back.
+const monthlyYears = 10
+
+// redisMonthlyAppearancesKey returns the key for a character's appearances per month.
+func redisMonthlyAppearancesKey(s CharacterSlug) string {
+	return s.Value() + ":appearances:monthly"
+}
+
+// redisSeriesKey returns the key for the series a character appears in the most.
+func redisSeriesKey(s CharacterSlug) string {
+	return s.Value() + ":series"
+}
+
+// redisFormatMixKey returns the key for the issues in each format that counted as a character's appearances.
+func redisFormatMixKey(s CharacterSlug) string {
+	return s.Value() + ":format_mix"
 }
 
 // redisThumbnailKey returns the key for character profile thumbnails.
@@ -10,6 +10,23 @@ type IssueCriteria struct {
 	Offset     int
 }
 
+// CharacterIssueCriteria for querying a character's issues.
+type CharacterIssueCriteria struct {
+	CharacterIDs []CharacterID
+	// If AppearanceType is 0, it will return all types of appearances.
+	AppearanceType AppearanceType
+	Formats        []Format
+	// Years filters by the year of the issue's sale date.
+	Years []int
+	// If IsVariant is null, it will return both.
+	IsVariant *bool
+	// If IsReprint is null, it will return both.
+	IsReprint *bool
+	SortBy    IssueSortCriteria
+	Limit     int
+	Offset    int
+}
+
 // CharacterSourceCriteria for querying character sources.
 type CharacterSourceCriteria struct {
 	CharacterIDs []CharacterID
@@ -46,19 +63,137 @@ const (
 	MostIssues PopularSortCriteria = "issue_count_rank"
 	// AverageIssuesPerYear sorts by the highest average issues per year for each character.
 	AverageIssuesPerYear = "average_per_year_rank"
+	// MostRelevant sorts by the appearances weighted by how recent they are. An appearance counts half as much
+	// for every half-life it's been since, so it's somewhere between the all-time counts and the trending characters.
+	MostRelevant PopularSortCriteria = "relevance_rank"
+)
+
+// DefaultHalfLife is the number of years it takes an appearance to count half as much when sorting by relevance.
+const DefaultHalfLife = 10
+
+// IssueSortCriteria is criteria for sorting a character's issues.
+type IssueSortCriteria string
+
+const (
+	// SaleDateAsc sorts by the oldest sale date first.
+	SaleDateAsc IssueSortCriteria = "issue.sale_date ASC"
+	// SaleDateDesc sorts by the newest sale date first.
+	SaleDateDesc IssueSortCriteria = "issue.sale_date DESC"
 )
 
 // PopularCriteria is for querying ranked and popular characters.
 type PopularCriteria struct {
 	AppearanceType AppearanceType
 	SortBy         PopularSortCriteria
-	Limit          int
-	Offset         int
+	// After gets the characters ranked after the cursor. The offset is ignored when set.
+	After *RankCursor
+	// Before gets the characters ranked before the cursor. The offset is ignored when set.
+	Before *RankCursor
+	// Years ranks the characters by their appearances in the years only. If nil, it ranks them for all time.
+	Years *YearRange
+	// Weighted ranks the characters by all their appearances weighted by their importance in the issues.
+	// The appearance type is ignored when set.
+	Weighted bool
+	// HalfLife is the number of years it takes an appearance to count half as much when sorting by relevance.
+	// If 0, it's the `DefaultHalfLife`.
+	HalfLife int
+	Limit    int
+	Offset   int
+}
+
+// YearRange is an inclusive range of years, like `1961` to `1985`.
+type YearRange struct {
+	From int
+	// If To is 0, the range goes up to the current year.
+	To int
+}
+
+// Era is a named age of comics.
+type Era string
+
+const (
+	// GoldenAge is the golden age of comics.
+	GoldenAge Era = "golden"
+	// SilverAge is the silver age of comics.
+	SilverAge Era = "silver"
+	// BronzeAge is the bronze age of comics.
+	BronzeAge Era = "bronze"
+	// ModernAge is the modern age of comics, up to the current year.
+	ModernAge Era = "modern"
+)
+
+// Eras are the named eras in order.
+var Eras = []Era{GoldenAge, SilverAge, BronzeAge, ModernAge}
+
+// eraYears are the years for each era. The eras don't overlap so a year only counts once.
+var eraYears = map[Era]YearRange{
+	GoldenAge: {From: 1938, To: 1955},
+	SilverAge: {From: 1956, To: 1969},
+	BronzeAge: {From: 1970, To: 1984},
+	ModernAge: {From: 1985},
+}
+
+// Years gets the range of years for the era. Returns nil if the era isn't known.
+func (e Era) Years() *YearRange {
+	years, ok := eraYears[e]
+	if !ok {
+		return nil
+	}
+	return &years
+}
+
+// RankCursor is the position of a ranked character for keyset pagination.
+// Characters can share a rank, so the ID breaks the tie.
+type RankCursor struct {
+	Rank uint
+	ID   CharacterID
 }
 
 // TrendingCriteria is for querying characters who are trending.
 type TrendingCriteria struct {
-	PublisherID PublisherID
-	Limit       int
-	Offset      int
+	// If Window is 0, it's the 12 month window.
+	Window TrendingWindow
+	Limit  int
+	Offset int
+}
+
+// TrendingWindow is the number of months that trending characters get ranked for.
+type TrendingWindow int
+
+const (
+	// ThreeMonths ranks the characters' appearances in the last 3 months.
+	ThreeMonths TrendingWindow = 3
+	// SixMonths ranks the characters' appearances in the last 6 months.
+	SixMonths TrendingWindow = 6
+	// TwelveMonths ranks the characters' appearances in the last 12 months.
+	TwelveMonths TrendingWindow = 12
+)
+
+// TrendingWindows are the windows that have trending views.
+var TrendingWindows = []TrendingWindow{ThreeMonths, SixMonths, TwelveMonths}
+
+// CoAppearanceSortCriteria is for sorting the characters a character appears with.
+type CoAppearanceSortCriteria string
+
+const (
+	// MostSharedIssues sorts by the most issues the characters appear in together.
+	MostSharedIssues CoAppearanceSortCriteria = "issue_count"
+	// HighestAffinity sorts by the highest affinity, so characters who mostly appear together come first.
+	HighestAffinity CoAppearanceSortCriteria = "affinity"
+)
+
+// CoAppearanceCriteria is for querying the characters a character appears with.
+type CoAppearanceCriteria struct {
+	// If SortBy is empty, it sorts by the most shared issues.
+	SortBy CoAppearanceSortCriteria
+	Limit  int
+	Offset int
+}
+
+// CoAppearanceGraphCriteria is for querying the graph of characters who appear together.
+type CoAppearanceGraphCriteria struct {
+	// MinIssueCount leaves out the pairs of characters who appear together in fewer issues.
+	MinIssueCount int
+	// Limit is the max number of pairs, the ones with the most shared issues first.
+	Limit int
 }
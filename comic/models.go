@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"os"
+	"sort"
 	"strconv"
 	"time"
 )
@@ -44,8 +46,7 @@ const (
 	Alternate AppearanceType = 1 << 1
 )
 
-// Consts for a later feature.
-// The available importance types.
+// The available importance types, from the least important to the most.
 const (
 	// Cameo - they just make a cameo appearance
 	Cameo Importance = iota + 1
@@ -82,6 +83,24 @@ var categoryToString = map[AppearanceType]string{
 	Main | Alternate: "all",
 }
 
+// A map for the string values of importance types.
+var importanceToString = map[Importance]string{
+	Cameo: "cameo",
+	Minor: "minor",
+	Major: "major",
+}
+
+// How much an appearance counts toward the weighted rankings for the character's importance in the issue.
+// A cameo counts for a fraction of a lead role.
+var importanceWeights = map[Importance]float64{
+	Cameo: 0.1,
+	Minor: 0.5,
+	Major: 1,
+}
+
+// Appearances without an importance count the same as minor ones.
+const unknownImportanceWeight = 0.5
+
 var cdnURL = os.Getenv("CC_CDN_URL")
 
 // PublisherID is the PK identifier for the publisher.
@@ -108,6 +127,29 @@ type CharacterSourceID uint
 // CharacterSyncLogID is the PK identifier for character sync logs.
 type CharacterSyncLogID uint
 
+// RankSnapshotID is the PK identifier for rank snapshots.
+type RankSnapshotID uint
+
+// CharacterExcludedIssueID is the PK identifier for a character's excluded issues.
+type CharacterExcludedIssueID uint
+
+// ExclusionReason is why a character's issue doesn't count as an appearance.
+type ExclusionReason string
+
+// The reasons an issue doesn't count as an appearance, in the order they're checked.
+const (
+	// ExcludedVariant is for variant covers of an issue.
+	ExcludedVariant ExclusionReason = "variant"
+	// ExcludedReprint is for issues that reprint older stories.
+	ExcludedReprint ExclusionReason = "reprint"
+	// ExcludedFormat is for formats like trade paperbacks and hardcovers that collect other issues.
+	ExcludedFormat ExclusionReason = "format"
+	// ExcludedNoSaleDate is for issues without a sale date.
+	ExcludedNoSaleDate ExclusionReason = "no_sale_date"
+	// ExcludedPublisher is for issues from publishers that aren't allowed.
+	ExcludedPublisher ExclusionReason = "publisher"
+)
+
 // CharacterSyncLogType is the type of sync that occurred for the character.
 type CharacterSyncLogType int
 
@@ -133,7 +175,8 @@ type AvgPerYearRank uint
 // Both Main and Alternate would be 101 so: `Main | Alternate`
 type AppearanceType uint8
 
-// Importance -- for a later feature -- ranks a character issue by the character's importance in the issue.
+// Importance ranks a character issue by the character's importance in the issue.
+// The weighted rankings count an appearance by its importance.
 type Importance int
 
 // AppearancesByYears represents the key, category, and appearances categorized per year for a character.
@@ -142,6 +185,50 @@ type AppearancesByYears struct {
 	Aggregates    []YearlyAggregate `json:"aggregates"`
 }
 
+// SeriesAppearances is a character's appearances in a series.
+type SeriesAppearances struct {
+	Name       string `json:"name"`
+	IssueCount int    `json:"issue_count"`
+	Main       int    `json:"main"`
+	Alternate  int    `json:"alternate"`
+	FirstYear  int    `json:"first_year"`
+	LastYear   int    `json:"last_year"`
+	// Share is the series' issues over all the character's issues, from 0 to 1.
+	Share float64 `json:"share"`
+}
+
+// CharacterSeries is the series a character appears in the most.
+type CharacterSeries struct {
+	CharacterSlug CharacterSlug `json:"slug"`
+	// IssueCount is the number of issues the character appears in across all their series.
+	IssueCount int `json:"issue_count"`
+	// SeriesCount is the number of series the character appears in, including the ones left out of Series.
+	SeriesCount int                 `json:"series_count"`
+	Series      []SeriesAppearances `json:"series"`
+}
+
+// NewCharacterSeries creates the character's series with the most issues first and keeps the top `limit`
+// series. The shares are out of the issues in all the series.
+func NewCharacterSeries(slug CharacterSlug, series []SeriesAppearances, limit int) CharacterSeries {
+	cs := CharacterSeries{CharacterSlug: slug, SeriesCount: len(series), Series: []SeriesAppearances{}}
+	for _, s := range series {
+		cs.IssueCount += s.IssueCount
+	}
+	sort.SliceStable(series, func(i, j int) bool {
+		return series[i].IssueCount > series[j].IssueCount
+	})
+	if len(series) > limit {
+		series = series[:limit]
+	}
+	for _, s := range series {
+		if cs.IssueCount > 0 {
+			s.Share = math.Round(float64(s.IssueCount)/float64(cs.IssueCount)*10000) / 10000
+		}
+		cs.Series = append(cs.Series, s)
+	}
+	return cs
+}
+
 // YearlyAggregate is the aggregated year and count of an appearance for that year.
 type YearlyAggregate struct {
 	Main      int `json:"main"`
@@ -149,6 +236,21 @@ type YearlyAggregate struct {
 	Year      int `json:"year"`
 }
 
+// MonthlyAggregate is the aggregated month and count of an appearance for that month.
+// A Month of 0 is for the issues in the year whose month is uncertain, so they don't get guessed into a month.
+type MonthlyAggregate struct {
+	Main      int `json:"main"`
+	Alternate int `json:"alternate"`
+	Year      int `json:"year"`
+	Month     int `json:"month"`
+}
+
+// AppearancesByMonths represents a character's appearances per month for their recent years.
+type AppearancesByMonths struct {
+	CharacterSlug CharacterSlug      `json:"slug"`
+	Aggregates    []MonthlyAggregate `json:"aggregates"`
+}
+
 // Publisher is a publisher is an entity that publishes comics and characters.
 type Publisher struct {
 	tableName struct{}      `pg:",discard_unknown_columns"`
@@ -161,20 +263,20 @@ type Publisher struct {
 
 // Issue is an issue with details about its publication and on sale dates.
 type Issue struct {
-	tableName          struct{} `pg:",discard_unknown_columns"`
-	ID                 IssueID
-	PublicationDate    time.Time `sql:",notnull"`
-	SaleDate           time.Time `sql:",notnull"` // @TODO: add an index.
-	IsVariant          bool      `sql:",notnull"`
-	MonthUncertain     bool      `sql:",notnull"`
-	Format             Format    `sql:",notnull"`
-	VendorPublisher    string    `sql:",notnull"`
-	VendorSeriesName   string    `sql:",notnull"`
-	VendorSeriesNumber string    `sql:",notnull"`
+	tableName          struct{}  `pg:",discard_unknown_columns"`
+	ID                 IssueID   `json:"-"`
+	PublicationDate    time.Time `sql:",notnull" json:"publication_date"`
+	SaleDate           time.Time `sql:",notnull" json:"sale_date"` // @TODO: add an index.
+	IsVariant          bool      `sql:",notnull" json:"is_variant"`
+	MonthUncertain     bool      `sql:",notnull" json:"month_uncertain"`
+	Format             Format    `sql:",notnull" json:"format"`
+	VendorPublisher    string    `sql:",notnull" json:"vendor_publisher"`
+	VendorSeriesName   string    `sql:",notnull" json:"vendor_series_name"`
+	VendorSeriesNumber string    `sql:",notnull" json:"vendor_series_number"`
 	// IsReprint means the issue is a full reprint with no original story. (So something like Classic X-Men 7 would not count).
-	IsReprint  bool       `sql:"default:false,notnull"`
-	VendorType VendorType `sql:",notnull,unique:uix_vendor_type_vendor_id,type:smallint"`
-	VendorID   string     `sql:",notnull,unique:uix_vendor_type_vendor_id"`
+	IsReprint  bool       `sql:"default:false,notnull" json:"is_reprint"`
+	VendorType VendorType `sql:",notnull,unique:uix_vendor_type_vendor_id,type:smallint" json:"-"`
+	VendorID   string     `sql:",notnull,unique:uix_vendor_type_vendor_id" json:"vendor_id"`
 	CreatedAt  time.Time  `sql:",notnull,default:NOW()" json:"-"`
 	UpdatedAt  time.Time  `sql:",notnull,default:NOW()" json:"-"`
 }
@@ -205,16 +307,16 @@ type Character struct {
 type CharacterSource struct {
 	tableName       struct{}          `pg:",discard_unknown_columns"`
 	ID              CharacterSourceID `json:"id"`
-	Character       *Character        // Pointer. Could be nil. Not eager-loaded.
+	Character       *Character        `json:"-"` // Pointer. Could be nil. Not eager-loaded.
 	CharacterID     CharacterID       `pg:",fk:character_id" sql:",notnull,unique:uix_character_id_vendor_url,on_delete:CASCADE" json:"character_id"`
 	VendorType      VendorType        `sql:",notnull,type:smallint" json:"type"`
-	VendorURL       string            `sql:",notnull,unique:uix_character_id_vendor_url"`
-	VendorName      string            `sql:",notnull"`
-	VendorOtherName string
-	IsDisabled      bool      `sql:",notnull"`
-	IsMain          bool      `sql:",notnull"`
-	CreatedAt       time.Time `sql:",default:NOW(),notnull" json:"-"`
-	UpdatedAt       time.Time `sql:",notnull,default:NOW()" json:"-"`
+	VendorURL       string            `sql:",notnull,unique:uix_character_id_vendor_url" json:"vendor_url"`
+	VendorName      string            `sql:",notnull" json:"vendor_name"`
+	VendorOtherName string            `json:"vendor_other_name"`
+	IsDisabled      bool              `sql:",notnull" json:"is_disabled"`
+	IsMain          bool              `sql:",notnull" json:"is_main"`
+	CreatedAt       time.Time         `sql:",default:NOW(),notnull" json:"-"`
+	UpdatedAt       time.Time         `sql:",notnull,default:NOW()" json:"-"`
 }
 
 // CharacterSyncLog contains information pertaining to syncs for the character.
@@ -231,18 +333,75 @@ type CharacterSyncLog struct {
 	UpdatedAt   time.Time   `sql:",notnull,default:NOW()" json:"-"`
 }
 
+// ViewRefresh records when a materialized view was last refreshed.
+type ViewRefresh struct {
+	tableName   struct{}         `pg:",discard_unknown_columns"`
+	View        MaterializedView `sql:",pk"`
+	RefreshedAt time.Time        `sql:",notnull"`
+}
+
+// RankSnapshot records a character's rank for a category on the day the ranks were snapshotted. The main ranks are
+// within the character's publisher like their main stats. The other ranks are within every publisher's characters.
+type RankSnapshot struct {
+	tableName      struct{}               `pg:",discard_unknown_columns"`
+	ID             RankSnapshotID         `json:"-"`
+	Character      *Character             `json:"-"` // Not eager-loaded, could be nil.
+	CharacterID    CharacterID            `pg:",fk:character_id" sql:",notnull,on_delete:CASCADE" json:"-"`
+	Category       CharacterStatsCategory `sql:",notnull" json:"category"`
+	IssueCountRank uint                   `sql:",notnull" json:"issue_count_rank"`
+	IssueCount     uint                   `sql:",notnull" json:"issue_count"`
+	AverageRank    uint                   `sql:",notnull" json:"average_issues_per_year_rank"`
+	Average        float64                `sql:",notnull" json:"average_issues_per_year"`
+	SnapshotDate   time.Time              `sql:",notnull,type:date" json:"date"`
+}
+
+// CharacterExcludedIssue is an issue a character is in that doesn't count as an appearance, so the issues
+// that got left out can be audited.
+type CharacterExcludedIssue struct {
+	tableName   struct{}                 `pg:",discard_unknown_columns"`
+	ID          CharacterExcludedIssueID `json:"-"`
+	Character   *Character               `json:"-"` // Not eager-loaded, could be nil.
+	CharacterID CharacterID              `pg:",fk:character_id" sql:",notnull,unique:uix_character_excluded_issue,on_delete:CASCADE" json:"-"`
+	Issue       *Issue                   `json:"issue"` // Not eager-loaded, could be nil.
+	IssueID     IssueID                  `pg:",fk:issue_id" sql:",notnull,unique:uix_character_excluded_issue,on_delete:CASCADE" json:"-"`
+	Reason      ExclusionReason          `sql:",notnull" json:"reason"`
+	CreatedAt   time.Time                `sql:",notnull,default:NOW()" json:"-"`
+}
+
+// RankHistory is a character's rank snapshots for a category, oldest first.
+type RankHistory struct {
+	Slug      CharacterSlug          `json:"slug"`
+	Category  CharacterStatsCategory `json:"category"`
+	Snapshots []*RankSnapshot        `json:"snapshots"`
+}
+
 // CharacterIssue references an issue for a character.
 type CharacterIssue struct {
-	tableName      struct{} `pg:",discard_unknown_columns"`
-	ID             CharacterIssueID
-	Character      *Character     // Not eager-loaded. Could be nil.
-	CharacterID    CharacterID    `pg:",fk:character_id" sql:",notnull,unique:uix_character_id_issue_id,on_delete:CASCADE"`
-	Issue          *Issue         // Not eager-loaded. Could be nil.
-	IssueID        IssueID        `pg:",fk:issue_id" sql:",notnull,unique:uix_character_id_issue_id,on_delete:CASCADE"`
-	AppearanceType AppearanceType `sql:",notnull,type:bit(8),default:B'00000001'"`
-	Importance     *Importance    `sql:",type:smallint"`
-	CreatedAt      time.Time      `sql:",notnull,default:NOW()" json:"-"`
-	UpdatedAt      time.Time      `sql:",notnull,default:NOW()" json:"-"`
+	tableName      struct{}         `pg:",discard_unknown_columns"`
+	ID             CharacterIssueID `json:"-"`
+	Character      *Character       `json:"-"` // Not eager-loaded. Could be nil.
+	CharacterID    CharacterID      `pg:",fk:character_id" sql:",notnull,unique:uix_character_id_issue_id,on_delete:CASCADE" json:"-"`
+	Issue          *Issue           `json:"issue"` // Not eager-loaded. Could be nil.
+	IssueID        IssueID          `pg:",fk:issue_id" sql:",notnull,unique:uix_character_id_issue_id,on_delete:CASCADE" json:"issue_id"`
+	AppearanceType AppearanceType   `sql:",notnull,type:bit(8),default:B'00000001'" json:"appearance_type"`
+	Importance     *Importance      `sql:",type:smallint" json:"importance"`
+	CreatedAt      time.Time        `sql:",notnull,default:NOW()" json:"-"`
+	UpdatedAt      time.Time        `sql:",notnull,default:NOW()" json:"-"`
+}
+
+// CharacterYearCount is the number of a character's appearances in a year. The counts get updated in the same
+// transaction that changes the character's issues, so the ranks can be built from them instead of from every issue.
+type CharacterYearCount struct {
+	tableName   struct{}    `pg:",discard_unknown_columns"`
+	Character   *Character  `json:"-"` // Not eager-loaded, could be nil.
+	CharacterID CharacterID `pg:",fk:character_id" sql:",pk,on_delete:CASCADE" json:"-"`
+	Year        int         `sql:",pk" json:"year"`
+	// Total is every appearance in the year. An appearance can be both main and alternate.
+	Total     uint `sql:",notnull" json:"total"`
+	Main      uint `sql:",notnull" json:"main"`
+	Alternate uint `sql:",notnull" json:"alternate"`
+	// Weighted is the sum of the appearances weighted by the character's importance in the issues.
+	Weighted float64 `sql:",notnull" json:"weighted"`
 }
 
 // ThumbnailSizes represents the sizes of thumbnails.
@@ -261,11 +420,60 @@ type CharacterThumbnails struct {
 
 // Stats represents general stats about the db.
 type Stats struct {
-	TotalCharacters  int `json:"total_characters"`
-	TotalAppearances int `json:"total_appearances"`
-	MinYear          int `json:"min_year"`
-	MaxYear          int `json:"max_year"`
-	TotalIssues      int `json:"total_issues"`
+	TotalCharacters      int `json:"total_characters"`
+	TotalAppearances     int `json:"total_appearances"`
+	MainAppearances      int `json:"main_appearances"`
+	AlternateAppearances int `json:"alternate_appearances"`
+	MinYear              int `json:"min_year"`
+	MaxYear              int `json:"max_year"`
+	TotalIssues          int `json:"total_issues"`
+	// VariantIssues and ReprintIssues are the issues that don't count as appearances.
+	VariantIssues int              `json:"variant_issues"`
+	ReprintIssues int              `json:"reprint_issues"`
+	Publishers    []PublisherStats `json:"publishers"`
+	Formats       []FormatStats    `json:"formats"`
+	// FormatMix is the issues in each format that counted as appearances and the ones that didn't.
+	FormatMix       []FormatBreakdown `json:"format_mix"`
+	Decades         []DecadeStats     `json:"decades"`
+	LastRefreshedAt *time.Time        `json:"last_refreshed_at"`
+	LastSyncedAt    *time.Time        `json:"last_synced_at"`
+}
+
+// PublisherStats is the totals for a publisher's characters.
+type PublisherStats struct {
+	Slug             PublisherSlug `json:"slug"`
+	Name             string        `json:"name"`
+	TotalCharacters  int           `json:"total_characters"`
+	TotalAppearances int           `json:"total_appearances"`
+}
+
+// FormatStats is the number of issues for a format.
+type FormatStats struct {
+	Format      Format `json:"format"`
+	TotalIssues int    `json:"total_issues"`
+}
+
+// FormatBreakdown is the number of issues in a format that counted as appearances and the number that didn't
+// for each reason.
+type FormatBreakdown struct {
+	Format   Format                  `json:"format"`
+	Counted  int                     `json:"counted"`
+	Excluded map[ExclusionReason]int `json:"excluded"`
+}
+
+// FormatCount is the number of issues in a format that counted as appearances when the reason is empty or
+// the number that didn't for the reason.
+type FormatCount struct {
+	Format Format
+	Reason ExclusionReason
+	Count  int
+}
+
+// DecadeStats is the number of appearances for a decade, like `1960` for the 60s.
+type DecadeStats struct {
+	Decade               int `json:"decade"`
+	MainAppearances      int `json:"main_appearances"`
+	AlternateAppearances int `json:"alternate_appearances"`
 }
 
 // RankedCharacter represents a character who has its rank and issue count accounted for
@@ -284,6 +492,57 @@ type RankedCharacter struct {
 	VendorDescription string               `json:"vendor_description"`
 	Thumbnails        *CharacterThumbnails `json:"thumbnails"`
 	Stats             CharacterStats       `json:"stats"`
+	// PreviousRank is a trending character's rank in the previous window. Nil if they didn't appear in it.
+	PreviousRank *uint `json:"-"`
+	// Trend is only set for trending characters.
+	Trend *RankTrend `json:"trend,omitempty"`
+}
+
+// TrendDirection is the way a trending character's rank moved.
+type TrendDirection string
+
+const (
+	// TrendUp is for a character ranked higher than in the previous window.
+	TrendUp TrendDirection = "up"
+	// TrendDown is for a character ranked lower than in the previous window.
+	TrendDown TrendDirection = "down"
+	// TrendSame is for a character ranked the same as in the previous window.
+	TrendSame TrendDirection = "same"
+	// TrendNew is for a character who didn't appear in the previous window.
+	TrendNew TrendDirection = "new"
+)
+
+// RankTrend is how a trending character's rank moved compared to the previous window of the same length.
+type RankTrend struct {
+	PreviousRank *uint `json:"previous_rank"`
+	// Change is the number of places the character moved up. It's negative when they moved down.
+	Change    int            `json:"change"`
+	Direction TrendDirection `json:"direction"`
+}
+
+// NewRankTrend creates the trend for the rank compared to the previous rank.
+func NewRankTrend(rank uint, previousRank *uint) *RankTrend {
+	if previousRank == nil {
+		return &RankTrend{Direction: TrendNew}
+	}
+	t := &RankTrend{PreviousRank: previousRank, Change: int(*previousRank) - int(rank), Direction: TrendSame}
+	if t.Change > 0 {
+		t.Direction = TrendUp
+	} else if t.Change < 0 {
+		t.Direction = TrendDown
+	}
+	return t
+}
+
+// Cursor gets the position of the ranked character for paginating by the sort.
+func (c *RankedCharacter) Cursor(sort PopularSortCriteria) RankCursor {
+	if sort == AverageIssuesPerYear {
+		return RankCursor{Rank: c.Stats.AverageRank, ID: c.ID}
+	}
+	if sort == MostRelevant {
+		return RankCursor{Rank: c.Stats.RelevanceRank, ID: c.ID}
+	}
+	return RankCursor{Rank: c.Stats.IssueCountRank, ID: c.ID}
 }
 
 // LastSync represents the last sync for a character.
@@ -300,7 +559,115 @@ type ExpandedCharacter struct {
 	Thumbnails  *CharacterThumbnails `json:"thumbnails"`
 	LastSyncs   []*LastSync          `json:"last_syncs"`
 	Stats       []CharacterStats     `json:"stats"`
+	Career      *CareerStats         `json:"career"`
 	Appearances AppearancesByYears   `json:"appearances"`
+	FormatMix   []FormatBreakdown    `json:"format_mix"`
+}
+
+// CareerIssue is the issue for the first or the latest appearance in a character's career.
+type CareerIssue struct {
+	IssueID            IssueID   `json:"-"`
+	SaleDate           time.Time `json:"sale_date"`
+	VendorSeriesName   string    `json:"vendor_series_name"`
+	VendorSeriesNumber string    `json:"vendor_series_number"`
+}
+
+// CareerStats are the facts about a character's career from all their appearances.
+type CareerStats struct {
+	FirstAppearance  CareerIssue `json:"first_appearance"`
+	LatestAppearance CareerIssue `json:"latest_appearance"`
+	// PeakYear is the year with the most appearances. The earliest year wins a tie.
+	PeakYear      int `json:"peak_year"`
+	PeakYearCount int `json:"peak_year_count"`
+	// LongestStreak is the longest run of consecutive years with at least one appearance, starting in LongestStreakStart.
+	LongestStreak      int `json:"longest_streak"`
+	LongestStreakStart int `json:"longest_streak_start"`
+	// ActiveYears is the number of years with at least one appearance.
+	ActiveYears int `json:"active_years"`
+	// YearsSinceLatestAppearance is from the year of the latest appearance to the current year.
+	YearsSinceLatestAppearance int `json:"years_since_latest_appearance"`
+}
+
+// NewCareerStats creates the career stats from the first and latest appearances and the number of appearances
+// for each year. Years without any appearances don't need to be in the map.
+func NewCareerStats(first, latest CareerIssue, years map[int]int, now time.Time) *CareerStats {
+	cs := &CareerStats{
+		FirstAppearance:            first,
+		LatestAppearance:           latest,
+		YearsSinceLatestAppearance: now.Year() - latest.SaleDate.Year(),
+	}
+	active := make([]int, 0, len(years))
+	for year, count := range years {
+		if count > 0 {
+			active = append(active, year)
+		}
+	}
+	sort.Ints(active)
+	cs.ActiveYears = len(active)
+	streak, streakStart := 0, 0
+	for i, year := range active {
+		if count := years[year]; count > cs.PeakYearCount {
+			cs.PeakYear, cs.PeakYearCount = year, count
+		}
+		if i == 0 || active[i-1] != year-1 {
+			streak, streakStart = 0, year
+		}
+		streak++
+		if streak > cs.LongestStreak {
+			cs.LongestStreak, cs.LongestStreakStart = streak, streakStart
+		}
+	}
+	return cs
+}
+
+// Comparison represents characters with their appearances lined up on the same span of years.
+type Comparison struct {
+	Years      []int                `json:"years"`
+	Characters []*ExpandedCharacter `json:"characters"`
+}
+
+// CoAppearance is a character who appears in the same issues as another character.
+type CoAppearance struct {
+	ID          CharacterID          `json:"-"`
+	Publisher   Publisher            `json:"publisher"`
+	PublisherID PublisherID          `json:"-"`
+	Name        string               `json:"name"`
+	OtherName   string               `json:"other_name"`
+	Image       string               `json:"image"`
+	Slug        CharacterSlug        `json:"slug"`
+	VendorImage string               `json:"vendor_image"`
+	Thumbnails  *CharacterThumbnails `json:"thumbnails"`
+	// IssueCount is the number of issues both characters appear in.
+	IssueCount uint `json:"issue_count"`
+	// Affinity is the number of shared issues over the number of issues either character appears in,
+	// from 0 to 1. Two characters who only ever appear together have an affinity of 1.
+	Affinity float64 `json:"affinity"`
+}
+
+// CoAppearanceGraph is the characters who appear together as nodes and the number of issues they share as
+// weighted edges.
+type CoAppearanceGraph struct {
+	Nodes []*CoAppearanceNode `json:"nodes"`
+	Edges []*CoAppearanceEdge `json:"edges"`
+}
+
+// CoAppearanceNode is a character in the co-appearance graph.
+type CoAppearanceNode struct {
+	ID        CharacterID   `json:"id"`
+	Slug      CharacterSlug `json:"slug"`
+	Name      string        `json:"name"`
+	Publisher PublisherSlug `json:"publisher"`
+	// IssueCount is the number of issues the character appears in.
+	IssueCount uint `json:"issue_count"`
+}
+
+// CoAppearanceEdge is a pair of characters who appear together in the co-appearance graph.
+// Each pair only has one edge with the lower character ID as the source.
+type CoAppearanceEdge struct {
+	Source     CharacterID `json:"source"`
+	Target     CharacterID `json:"target"`
+	IssueCount uint        `json:"issue_count"`
+	Affinity   float64     `json:"affinity"`
 }
 
 // CharacterStatsCategory is the category types for character stats.
@@ -311,6 +678,10 @@ var (
 	AllTimeStats CharacterStatsCategory = "all_time"
 	// MainStats represents stats for MAIN appearances only and rankings per publisher.
 	MainStats CharacterStatsCategory = "main"
+	// AlternateStats represents stats for ALTERNATE appearances only.
+	AlternateStats CharacterStatsCategory = "alternate"
+	// WeightedStats represents stats for ALL appearances weighted by the character's importance in the issues.
+	WeightedStats CharacterStatsCategory = "weighted"
 )
 
 // CharacterStats represents ranking and issue statistic information.
@@ -320,6 +691,17 @@ type CharacterStats struct {
 	IssueCount     uint                   `json:"issue_count"`
 	Average        float64                `json:"average_issues_per_year"`
 	AverageRank    uint                   `json:"average_issues_per_year_rank"`
+	// IssueCountPercentile is the percent of the other ranked characters with fewer issues, so 99 is the top 1%.
+	// Unlike the rank, it can be compared between publishers with catalogs of different sizes.
+	IssueCountPercentile float64 `json:"issue_count_percentile"`
+	// AveragePercentile is the percent of the other ranked characters with a lower average issues per year.
+	AveragePercentile float64 `json:"average_issues_per_year_percentile"`
+	// NormalizedPopularity is how many standard deviations the character's issue count is from the mean of the ranked
+	// characters. The counts are logged first since a few characters have most of the issues.
+	NormalizedPopularity float64 `json:"normalized_popularity"`
+	// Relevance is the appearances weighted by how recent they are. Only set when ranked by relevance.
+	Relevance     float64 `json:"relevance,omitempty"`
+	RelevanceRank uint    `json:"relevance_rank,omitempty"`
 }
 
 // NewCharacterStats creates a new character stats struct.
@@ -426,12 +808,108 @@ func (u AppearanceType) String() string {
 	}
 }
 
+// String gets the string value of the importance.
+func (i Importance) String() string {
+	if s, ok := importanceToString[i]; ok {
+		return s
+	}
+	return "unknown"
+}
+
+// Weight gets how much an appearance counts toward the weighted rankings.
+func (i Importance) Weight() float64 {
+	if w, ok := importanceWeights[i]; ok {
+		return w
+	}
+	return unknownImportanceWeight
+}
+
+// MarshalJSON returns the JSON string representation.
+func (i Importance) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i.String())
+}
+
+// UnmarshalJSON parses the importance from its string representation.
+func (i *Importance) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	imp, err := NewImportance(s)
+	if err != nil {
+		return err
+	}
+	*i = imp
+	return nil
+}
+
 // AddAppearance adds an appearance to the appearances for the character.
 func (c *AppearancesByYears) AddAppearance(appearance YearlyAggregate) *AppearancesByYears {
 	c.Aggregates = append(c.Aggregates, appearance)
 	return c
 }
 
+// Fill fills in the years from `from` to `to` that have no appearances with zero counts and drops
+// any years outside of the span. The aggregates stay sorted by year.
+func (c *AppearancesByYears) Fill(from, to int) *AppearancesByYears {
+	byYear := make(map[int]YearlyAggregate, len(c.Aggregates))
+	for _, a := range c.Aggregates {
+		byYear[a.Year] = a
+	}
+	aggs := make([]YearlyAggregate, 0)
+	for y := from; y <= to; y++ {
+		if a, ok := byYear[y]; ok {
+			aggs = append(aggs, a)
+		} else {
+			aggs = append(aggs, YearlyAggregate{Year: y})
+		}
+	}
+	c.Aggregates = aggs
+	return c
+}
+
+// Fill fills in the months up to `to` that have no appearances with zero counts, starting from the first month
+// with an appearance in the recent years before `to`, and drops any months outside of them. The aggregates must
+// be sorted by year and month. The uncertain months stay first in their year and only when there are any.
+func (c *AppearancesByMonths) Fill(to time.Time) *AppearancesByMonths {
+	type yearMonth struct{ year, month int }
+	byMonth := make(map[yearMonth]MonthlyAggregate, len(c.Aggregates))
+	recent := make([]MonthlyAggregate, 0)
+	for _, a := range c.Aggregates {
+		if a.Year > to.Year()-monthlyYears {
+			byMonth[yearMonth{a.Year, a.Month}] = a
+			recent = append(recent, a)
+		}
+	}
+	if len(recent) == 0 {
+		c.Aggregates = recent
+		return c
+	}
+	first := recent[0]
+	aggs := make([]MonthlyAggregate, 0)
+	for y := first.Year; y <= to.Year(); y++ {
+		if a, ok := byMonth[yearMonth{y, 0}]; ok {
+			aggs = append(aggs, a)
+		}
+		from, last := 1, 12
+		if y == first.Year && first.Month > 0 {
+			from = first.Month
+		}
+		if y == to.Year() {
+			last = int(to.Month())
+		}
+		for m := from; m <= last; m++ {
+			if a, ok := byMonth[yearMonth{y, m}]; ok {
+				aggs = append(aggs, a)
+			} else {
+				aggs = append(aggs, MonthlyAggregate{Year: y, Month: m})
+			}
+		}
+	}
+	c.Aggregates = aggs
+	return c
+}
+
 // Total returns the total number of appearances per year.
 func (c *AppearancesByYears) Total() int {
 	total := 0
@@ -577,6 +1055,65 @@ func NewCharacterIssue(characterID CharacterID, id IssueID, appearanceType Appea
 	}
 }
 
+// NewImportance gets the importance from its string value, like `cameo`.
+func NewImportance(s string) (Importance, error) {
+	for i, str := range importanceToString {
+		if str == s {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown importance: %s", s)
+}
+
+// NewFormatMix folds the counts into a breakdown for each format, with the formats with the most issues first.
+func NewFormatMix(counts []FormatCount) []FormatBreakdown {
+	mix := make([]FormatBreakdown, 0)
+	idx := make(map[Format]int)
+	totals := make(map[Format]int)
+	for _, c := range counts {
+		i, ok := idx[c.Format]
+		if !ok {
+			i = len(mix)
+			idx[c.Format] = i
+			mix = append(mix, FormatBreakdown{Format: c.Format, Excluded: make(map[ExclusionReason]int)})
+		}
+		if c.Reason == "" {
+			mix[i].Counted += c.Count
+		} else {
+			mix[i].Excluded[c.Reason] += c.Count
+		}
+		totals[c.Format] += c.Count
+	}
+	sort.SliceStable(mix, func(i, j int) bool {
+		if totals[mix[i].Format] != totals[mix[j].Format] {
+			return totals[mix[i].Format] > totals[mix[j].Format]
+		}
+		return mix[i].Format < mix[j].Format
+	})
+	return mix
+}
+
+// NewCharacterExcludedIssue creates a new excluded issue for the character.
+func NewCharacterExcludedIssue(characterID CharacterID, id IssueID, reason ExclusionReason) *CharacterExcludedIssue {
+	return &CharacterExcludedIssue{
+		CharacterID: characterID,
+		IssueID:     id,
+		Reason:      reason,
+	}
+}
+
+// NewAppearancesByMonths creates a new struct with the parameters.
+func NewAppearancesByMonths(slug CharacterSlug, aggs []MonthlyAggregate) AppearancesByMonths {
+	apm := AppearancesByMonths{
+		CharacterSlug: slug,
+		Aggregates:    aggs,
+	}
+	if aggs == nil {
+		apm.Aggregates = []MonthlyAggregate{}
+	}
+	return apm
+}
+
 // NewAppearancesByYears creates a new struct with the parameters.
 func NewAppearancesByYears(slug CharacterSlug, aggs []YearlyAggregate) AppearancesByYears {
 	apy := AppearancesByYears{
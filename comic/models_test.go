@@ -1,9 +1,11 @@
 package comic_test
 
 import (
+	"encoding/json"
 	"github.com/comiccruncher/comiccruncher/comic"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestNewCharacterSlugs(t *testing.T) {
@@ -75,7 +77,7 @@ func TestExpandedCharacterMarshalJSON(t *testing.T) {
 	}
 	b, err := ec.MarshalJSON()
 	assert.Nil(t, err)
-	s := `{"publisher":{"name":"","slug":""},"name":"emma frost","other_name":"","description":"","image":"","slug":"emma-frost","vendor_image":"","vendor_url":"https://example.com","vendor_description":"","thumbnails":null,"stats":[{"category":"all_time","issue_count_rank":1,"issue_count":1,"average_issues_per_year":1,"average_issues_per_year_rank":1}],"last_syncs":null,"appearances":{"slug":"emma-frost","aggregates":[{"main":10,"alternate":10,"year":1900}]}}`
+	s := `{"publisher":{"name":"","slug":""},"name":"emma frost","other_name":"","description":"","image":"","slug":"emma-frost","vendor_image":"","vendor_url":"https://example.com","vendor_description":"","thumbnails":null,"stats":[{"category":"all_time","issue_count_rank":1,"issue_count":1,"average_issues_per_year":1,"average_issues_per_year_rank":1,"issue_count_percentile":0,"average_issues_per_year_percentile":0,"normalized_popularity":0}],"last_syncs":null,"appearances":{"slug":"emma-frost","aggregates":[{"main":10,"alternate":10,"year":1900}]}}`
 	assert.Equal(t, s, string(b))
 }
 
@@ -98,6 +100,245 @@ func TestRankedCharacterMarshalJSON(t *testing.T) {
 	}
 	b, err := rc.MarshalJSON()
 	assert.Nil(t, err)
-	expected := `{"publisher":{"name":"","slug":""},"name":"emma frost","other_name":"","description":"test","image":"https://d2jsu6fyd1g4ln.cloudfront.net/test","slug":"emma-frost","vendor_image":"https://d2jsu6fyd1g4ln.cloudfront.net/test1","vendor_url":"","vendor_description":"","thumbnails":null,"stats":{"category":"all_time","issue_count_rank":1,"issue_count":1,"average_issues_per_year":1,"average_issues_per_year_rank":1}}`
+	expected := `{"publisher":{"name":"","slug":""},"name":"emma frost","other_name":"","description":"test","image":"https://d2jsu6fyd1g4ln.cloudfront.net/test","slug":"emma-frost","vendor_image":"https://d2jsu6fyd1g4ln.cloudfront.net/test1","vendor_url":"","vendor_description":"","thumbnails":null,"stats":{"category":"all_time","issue_count_rank":1,"issue_count":1,"average_issues_per_year":1,"average_issues_per_year_rank":1,"issue_count_percentile":0,"average_issues_per_year_percentile":0,"normalized_popularity":0}}`
 	assert.Equal(t, expected, string(b))
 }
+
+func TestRankedCharacterCursor(t *testing.T) {
+	c := &comic.RankedCharacter{ID: 5, Stats: comic.CharacterStats{IssueCountRank: 2, AverageRank: 7, RelevanceRank: 3}}
+	assert.Equal(t, comic.RankCursor{Rank: 2, ID: 5}, c.Cursor(comic.MostIssues))
+	assert.Equal(t, comic.RankCursor{Rank: 7, ID: 5}, c.Cursor(comic.AverageIssuesPerYear))
+	assert.Equal(t, comic.RankCursor{Rank: 3, ID: 5}, c.Cursor(comic.MostRelevant))
+}
+
+func TestAppearancesByYearsFill(t *testing.T) {
+	apps := comic.AppearancesByYears{
+		CharacterSlug: "emma-frost",
+		Aggregates: []comic.YearlyAggregate{
+			{Year: 1978, Main: 1},
+			{Year: 1980, Main: 2, Alternate: 3},
+		},
+	}
+	apps.Fill(1979, 1982)
+	assert.Equal(t, []comic.YearlyAggregate{
+		{Year: 1979},
+		{Year: 1980, Main: 2, Alternate: 3},
+		{Year: 1981},
+		{Year: 1982},
+	}, apps.Aggregates)
+}
+
+func TestAppearancesByMonthsFill(t *testing.T) {
+	apps := comic.AppearancesByMonths{
+		CharacterSlug: "emma-frost",
+		Aggregates: []comic.MonthlyAggregate{
+			{Year: 2008, Month: 12, Main: 5},
+			{Year: 2017, Month: 11, Main: 1},
+			{Year: 2018, Month: 0, Alternate: 2},
+			{Year: 2018, Month: 2, Main: 3},
+			{Year: 2018, Month: 5, Main: 4},
+		},
+	}
+	apps.Fill(time.Date(2018, time.March, 15, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, []comic.MonthlyAggregate{
+		{Year: 2017, Month: 11, Main: 1},
+		{Year: 2017, Month: 12},
+		{Year: 2018, Month: 0, Alternate: 2},
+		{Year: 2018, Month: 1},
+		{Year: 2018, Month: 2, Main: 3},
+		{Year: 2018, Month: 3},
+	}, apps.Aggregates)
+
+	// the months from before the recent years get dropped.
+	apps = comic.NewAppearancesByMonths("emma-frost", []comic.MonthlyAggregate{{Year: 2017, Month: 11, Main: 1}, {Year: 2018, Month: 0, Alternate: 2}})
+	apps.Fill(time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, comic.MonthlyAggregate{Year: 2018, Month: 0, Alternate: 2}, apps.Aggregates[0])
+	apps = comic.NewAppearancesByMonths("emma-frost", []comic.MonthlyAggregate{{Year: 2018, Month: 1, Main: 1}})
+	apps.Fill(time.Date(2028, time.January, 1, 0, 0, 0, 0, time.UTC))
+	assert.Empty(t, apps.Aggregates)
+}
+
+func TestEraYears(t *testing.T) {
+	assert.Equal(t, &comic.YearRange{From: 1956, To: 1969}, comic.SilverAge.Years())
+	assert.Equal(t, &comic.YearRange{From: 1985}, comic.ModernAge.Years())
+	assert.Nil(t, comic.Era("dark").Years())
+}
+
+func TestRankedYearsSQL(t *testing.T) {
+	sql := comic.RankedYearsSQL(comic.Main, comic.YearRange{From: 1961, To: 1985})
+	assert.Contains(t, sql, "yc.year >= 1961 AND yc.year <= 1985")
+	assert.Contains(t, sql, "(1985) -  min(yc.year)")
+	assert.Contains(t, sql, "WHERE yc.main > 0")
+	assert.Contains(t, sql, "sum(yc.main)::BIGINT as issue_count")
+	sql = comic.RankedYearsSQL(comic.Main|comic.Alternate, comic.YearRange{From: 1985})
+	assert.Contains(t, sql, "yc.year >= 1985")
+	assert.NotContains(t, sql, "yc.year <=")
+	assert.Contains(t, sql, "WHERE yc.total > 0")
+	assert.Contains(t, sql, "date_part('year', current_date)")
+	assert.NotContains(t, sql, "character_issues")
+}
+
+func TestNewRankTrend(t *testing.T) {
+	previous := uint(5)
+	assert.Equal(t, &comic.RankTrend{PreviousRank: &previous, Change: 3, Direction: comic.TrendUp}, comic.NewRankTrend(2, &previous))
+	assert.Equal(t, &comic.RankTrend{PreviousRank: &previous, Change: -4, Direction: comic.TrendDown}, comic.NewRankTrend(9, &previous))
+	assert.Equal(t, &comic.RankTrend{PreviousRank: &previous, Direction: comic.TrendSame}, comic.NewRankTrend(5, &previous))
+	assert.Equal(t, &comic.RankTrend{Direction: comic.TrendNew}, comic.NewRankTrend(1, nil))
+}
+
+func TestNewCareerStats(t *testing.T) {
+	first := comic.CareerIssue{SaleDate: time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)}
+	latest := comic.CareerIssue{SaleDate: time.Date(2016, time.June, 1, 0, 0, 0, 0, time.UTC)}
+	years := map[int]int{1980: 2, 1981: 5, 1982: 1, 1990: 5, 1991: 3, 1992: 3, 1993: 1, 2016: 4, 2000: 0}
+	cs := comic.NewCareerStats(first, latest, years, time.Date(2018, time.March, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, first, cs.FirstAppearance)
+	assert.Equal(t, latest, cs.LatestAppearance)
+	assert.Equal(t, 1981, cs.PeakYear)
+	assert.Equal(t, 5, cs.PeakYearCount)
+	assert.Equal(t, 4, cs.LongestStreak)
+	assert.Equal(t, 1990, cs.LongestStreakStart)
+	assert.Equal(t, 8, cs.ActiveYears)
+	assert.Equal(t, 2, cs.YearsSinceLatestAppearance)
+
+	empty := comic.NewCareerStats(first, latest, map[int]int{}, latest.SaleDate)
+	assert.Equal(t, 0, empty.ActiveYears)
+	assert.Equal(t, 0, empty.LongestStreak)
+	assert.Equal(t, 0, empty.YearsSinceLatestAppearance)
+}
+
+func TestNewCharacterSeries(t *testing.T) {
+	cs := comic.NewCharacterSeries("emma-frost", []comic.SeriesAppearances{
+		{Name: "X-Men", IssueCount: 10},
+		{Name: "Uncanny X-Men", IssueCount: 30},
+		{Name: "Generation X", IssueCount: 20},
+	}, 2)
+	assert.Equal(t, 60, cs.IssueCount)
+	assert.Equal(t, 3, cs.SeriesCount)
+	assert.Len(t, cs.Series, 2)
+	assert.Equal(t, "Uncanny X-Men", cs.Series[0].Name)
+	assert.Equal(t, 0.5, cs.Series[0].Share)
+	assert.Equal(t, "Generation X", cs.Series[1].Name)
+	assert.Equal(t, 0.3333, cs.Series[1].Share)
+
+	empty := comic.NewCharacterSeries("emma-frost", nil, 2)
+	assert.Equal(t, 0, empty.IssueCount)
+	assert.NotNil(t, empty.Series)
+}
+
+func TestNewFormatMix(t *testing.T) {
+	mix := comic.NewFormatMix([]comic.FormatCount{
+		{Format: comic.FormatTPB, Reason: comic.ExcludedFormat, Count: 4},
+		{Format: comic.FormatStandard, Count: 10},
+		{Format: comic.FormatStandard, Reason: comic.ExcludedVariant, Count: 3},
+		{Format: comic.FormatStandard, Reason: comic.ExcludedReprint, Count: 1},
+		{Format: comic.FormatHC, Reason: comic.ExcludedFormat, Count: 4},
+	})
+	assert.Len(t, mix, 3)
+	assert.Equal(t, comic.FormatStandard, mix[0].Format)
+	assert.Equal(t, 10, mix[0].Counted)
+	assert.Equal(t, 3, mix[0].Excluded[comic.ExcludedVariant])
+	assert.Equal(t, 1, mix[0].Excluded[comic.ExcludedReprint])
+	// Ties are sorted by the format.
+	assert.Equal(t, comic.FormatHC, mix[1].Format)
+	assert.Equal(t, comic.FormatTPB, mix[2].Format)
+	assert.Equal(t, 0, mix[2].Counted)
+
+	assert.NotNil(t, comic.NewFormatMix(nil))
+}
+
+func TestImportance(t *testing.T) {
+	assert.Equal(t, "cameo", comic.Cameo.String())
+	assert.Equal(t, "major", comic.Major.String())
+	assert.Equal(t, "unknown", comic.Importance(0).String())
+	assert.True(t, comic.Cameo.Weight() < comic.Minor.Weight())
+	assert.True(t, comic.Minor.Weight() < comic.Major.Weight())
+	assert.Equal(t, comic.Minor.Weight(), comic.Importance(0).Weight())
+
+	i, err := comic.NewImportance("minor")
+	assert.Nil(t, err)
+	assert.Equal(t, comic.Minor, i)
+	_, err = comic.NewImportance("lead")
+	assert.Error(t, err)
+
+	ci := comic.CharacterIssue{Importance: &i}
+	b, err := json.Marshal(ci)
+	assert.Nil(t, err)
+	assert.Contains(t, string(b), `"importance":"minor"`)
+	var decoded comic.CharacterIssue
+	assert.Nil(t, json.Unmarshal([]byte(`{"importance":"major"}`), &decoded))
+	assert.Equal(t, comic.Major, *decoded.Importance)
+	assert.Error(t, json.Unmarshal([]byte(`{"importance":3}`), &decoded))
+}
+
+func TestWeightedSQL(t *testing.T) {
+	sql := comic.WeightedSQL()
+	assert.NotContains(t, sql, "MATERIALIZED VIEW")
+	assert.Contains(t, sql, "round(sum(yc.weighted))::BIGINT as issue_count")
+	assert.Contains(t, comic.RankedSQL(comic.Main|comic.Alternate, 0), "sum(yc.total)::BIGINT as issue_count")
+	assert.Contains(t, comic.WeightedYearsSQL(comic.YearRange{From: 1961, To: 1985}), "yc.year <= 1985")
+}
+
+func TestRelevanceSQL(t *testing.T) {
+	sql := comic.RelevanceSQL(comic.Main, 1, nil, false, 5)
+	assert.Contains(t, sql, "dense_rank() OVER (ORDER BY sum(yc.main * power(0.5, (date_part('year', current_date) - yc.year) / 5::DECIMAL)) DESC) AS relevance_rank")
+	assert.Contains(t, sql, "AS relevance,")
+	assert.Contains(t, sql, "AND c.publisher_id = 1")
+	sql = comic.RelevanceSQL(comic.Main|comic.Alternate, 0, &comic.YearRange{From: 1985}, true, 0)
+	assert.Contains(t, sql, "sum(yc.weighted * power(0.5, (date_part('year', current_date) - yc.year) / 10::DECIMAL))")
+	assert.Contains(t, sql, "yc.year >= 1985")
+	assert.NotContains(t, comic.RankedSQL(comic.Main|comic.Alternate, 0), "relevance")
+}
+
+func TestRankedSQLNormalized(t *testing.T) {
+	sql := comic.RankedSQL(comic.Main, 2)
+	assert.Contains(t, sql, "percent_rank() OVER (ORDER BY sum(yc.main))")
+	assert.Contains(t, sql, "AS average_per_year_percentile")
+	assert.Contains(t, sql, "stddev_pop(ln(1 + sum(yc.main))) OVER ()")
+	assert.Contains(t, sql, "c.publisher_id = 2")
+	assert.Contains(t, comic.WeightedSQL(), "avg(ln(1 + sum(yc.weighted))) OVER ()")
+}
+
+func TestYearCountsSQL(t *testing.T) {
+	sql := comic.YearCountsSQL("character_id IN (?0)")
+	assert.Contains(t, sql, "DELETE FROM character_year_counts WHERE character_id IN (?0);")
+	assert.Contains(t, sql, "sum(CASE ci.importance WHEN 1 THEN 0.1 WHEN 2 THEN 0.5 WHEN 3 THEN 1 ELSE 0.5 END) AS weighted")
+	assert.Contains(t, sql, "WHERE character_id IN (?0) AND i.sale_date IS NOT NULL")
+}
+
+func TestYearCountsDeltaSQL(t *testing.T) {
+	sql := comic.YearCountsDeltaSQL("ci.id = ?0", 1)
+	assert.Contains(t, sql, "1 * count(ci.id) AS total")
+	assert.Contains(t, sql, "WHERE ci.id = ?0 AND i.sale_date IS NOT NULL")
+	assert.Contains(t, sql, "ON CONFLICT (character_id, year) DO UPDATE SET")
+	assert.Contains(t, sql, "weighted = yc.weighted + EXCLUDED.weighted")
+	assert.NotContains(t, sql, "DELETE")
+	sql = comic.YearCountsDeltaSQL("ci.issue_id = ?0", -1)
+	assert.Contains(t, sql, "-1 * sum(CASE ci.importance")
+	assert.Contains(t, sql, "WHERE total <= 0 AND character_id IN (SELECT ci.character_id FROM character_issues ci WHERE ci.issue_id = ?0)")
+}
+
+func TestTrendingViewSQL(t *testing.T) {
+	sql := comic.TrendingViewSQL("mv_trending_characters_marvel_3m", 1, comic.ThreeMonths)
+	assert.Contains(t, sql, "CREATE MATERIALIZED VIEW IF NOT EXISTS mv_trending_characters_marvel_3m")
+	assert.Contains(t, sql, "i.sale_date > date_trunc('month', CURRENT_DATE) - INTERVAL '3 months'")
+	assert.Contains(t, sql, "i.sale_date > date_trunc('month', CURRENT_DATE) - INTERVAL '6 months'")
+	assert.Contains(t, sql, "i.sale_date <= date_trunc('month', CURRENT_DATE) - INTERVAL '3 months'")
+	assert.Contains(t, sql, "WHERE c.publisher_id = 1")
+}
+
+func TestCoAppearanceViewSQL(t *testing.T) {
+	sql := comic.CoAppearanceViewSQL(comic.CoAppearanceView)
+	assert.Contains(t, sql, "CREATE MATERIALIZED VIEW IF NOT EXISTS mv_co_appearances")
+	assert.Contains(t, sql, "b.character_id != a.character_id")
+	assert.Contains(t, sql, "ta.issue_count + tb.issue_count - count(a.id)")
+	assert.Contains(t, comic.CoAppearanceViewIndexSQL(comic.CoAppearanceView), "ON mv_co_appearances(character_id, partner_id)")
+}
+
+func TestPublisherViews(t *testing.T) {
+	assert.Equal(t, []comic.MaterializedView{"mv_ranked_characters_marvel_main", "mv_ranks_marvel_main"}, comic.LegacyPublisherViews("marvel"))
+	assert.Equal(t, comic.MaterializedView("mv_trending_characters_dc_12m"), comic.PublisherTrendingView("dc", comic.TwelveMonths))
+	assert.Equal(t, comic.MaterializedView("mv_trending_characters_dark_horse_3m"), comic.PublisherTrendingView("dark-horse", comic.ThreeMonths))
+	assert.NotEqual(t, comic.PublisherTrendingView("dark-horse", comic.ThreeMonths), comic.PublisherTrendingView("dark_horse", comic.ThreeMonths))
+	assert.NotEqual(t, comic.PublisherTrendingView("dark-horse", comic.ThreeMonths), comic.PublisherTrendingView("Dark-Horse", comic.ThreeMonths))
+	assert.Regexp(t, "^mv_trending_characters_dark_horse_[0-9a-f]{8}_3m$", comic.PublisherTrendingView("dark_horse", comic.ThreeMonths))
+}
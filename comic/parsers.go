@@ -6,6 +6,7 @@ import (
 	"github.com/comiccruncher/comiccruncher/internal/stringutil"
 	"strconv"
 	"strings"
+	"time"
 )
 
 func parseRedisThumbnails(s string, th *CharacterThumbnails) error {
@@ -36,11 +37,147 @@ func parseRedisThumbnails(s string, th *CharacterThumbnails) error {
 	return nil
 }
 
+// The format for the dates of the career stats in Redis.
+const careerDateFormat = "2006-01-02"
+
+// Parses the career stats from the character's `:stats` hash. Returns nil if the career stats haven't been synced.
+func parseRedisCareer(res map[string]string, now time.Time) (*CareerStats, error) {
+	if res["career_first_appearance_date"] == "" {
+		return nil, nil
+	}
+	first, err := time.Parse(careerDateFormat, res["career_first_appearance_date"])
+	if err != nil {
+		return nil, err
+	}
+	latest, err := time.Parse(careerDateFormat, res["career_latest_appearance_date"])
+	if err != nil {
+		return nil, err
+	}
+	ints := make(map[string]int, 5)
+	for _, k := range []string{"peak_year", "peak_year_count", "longest_streak", "longest_streak_start", "active_years"} {
+		if ints[k], err = strconv.Atoi(res["career_"+k]); err != nil {
+			return nil, err
+		}
+	}
+	return &CareerStats{
+		FirstAppearance: CareerIssue{
+			SaleDate:           first,
+			VendorSeriesName:   res["career_first_appearance_series_name"],
+			VendorSeriesNumber: res["career_first_appearance_series_number"],
+		},
+		LatestAppearance: CareerIssue{
+			SaleDate:           latest,
+			VendorSeriesName:   res["career_latest_appearance_series_name"],
+			VendorSeriesNumber: res["career_latest_appearance_series_number"],
+		},
+		PeakYear:                   ints["peak_year"],
+		PeakYearCount:              ints["peak_year_count"],
+		LongestStreak:              ints["longest_streak"],
+		LongestStreakStart:         ints["longest_streak_start"],
+		ActiveYears:                ints["active_years"],
+		YearsSinceLatestAppearance: now.Year() - latest.Year(),
+	}, nil
+}
+
+// Parses the weighted stats from the character's `:stats` hash. Returns nil if the weighted stats haven't been synced.
+func parseRedisWeightedStats(res map[string]string) (*CharacterStats, error) {
+	if res["weighted_issue_count"] == "" {
+		return nil, nil
+	}
+	count, err := parseUint(res["weighted_issue_count"])
+	if err != nil {
+		return nil, err
+	}
+	rank, err := parseUint(res["weighted_issue_count_rank"])
+	if err != nil {
+		return nil, err
+	}
+	avg, err := strconv.ParseFloat(res["weighted_average_per_year"], 64)
+	if err != nil {
+		return nil, err
+	}
+	avgRank, err := parseUint(res["weighted_average_per_year_rank"])
+	if err != nil {
+		return nil, err
+	}
+	stats := NewCharacterStats(WeightedStats, rank, count, avgRank, avg)
+	if err := parseRedisNormalizedStats(res, "weighted", &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// Parses the percentiles and normalized popularity for the category's `prefix` from the character's `:stats` hash.
+// They're left as 0 if they haven't been synced yet.
+func parseRedisNormalizedStats(res map[string]string, prefix string, stats *CharacterStats) error {
+	fields := map[string]*float64{
+		prefix + "_issue_count_percentile":      &stats.IssueCountPercentile,
+		prefix + "_average_per_year_percentile": &stats.AveragePercentile,
+		prefix + "_normalized_popularity":       &stats.NormalizedPopularity,
+	}
+	for key, f := range fields {
+		if res[key] == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(res[key], 64)
+		if err != nil {
+			return err
+		}
+		*f = v
+	}
+	return nil
+}
+
 func parseUint(s string) (uint, error) {
 	u, err := strconv.ParseUint(s, 10, 64)
 	return uint(u), err
 }
 
+// MonthlyAggregateSerializer serializes the monthly aggregates into a string.
+type MonthlyAggregateSerializer interface {
+	Serialize(aggregates []MonthlyAggregate) string
+}
+
+// RedisMonthlyAggregateSerializer serializes the monthly aggregates into a string for Redis storage.
+type RedisMonthlyAggregateSerializer struct {
+}
+
+// Serialize serializes the structs into a string for Redis storage.
+func (s *RedisMonthlyAggregateSerializer) Serialize(aggregates []MonthlyAggregate) string {
+	vals := make([]string, len(aggregates))
+	// sets the value in the form of `year:month:main_count:alt_count;year:month:main_count:alt_count`
+	// like the yearly aggregates. the month is 0 for the issues with an uncertain month.
+	for idx, appearance := range aggregates {
+		vals[idx] = fmt.Sprintf("%d:%d:%d:%d", appearance.Year, appearance.Month, appearance.Main, appearance.Alternate)
+	}
+	return strings.Join(vals, ";")
+}
+
+// MonthlyAggregateDeserializer deserializes a string into the monthly aggregates.
+type MonthlyAggregateDeserializer interface {
+	Deserialize(val string) []MonthlyAggregate
+}
+
+// RedisMonthlyAggregateDeserializer deserializes a Redis string into the monthly aggregates.
+type RedisMonthlyAggregateDeserializer struct {
+}
+
+// Deserialize deserializes the string into the monthly aggregates structs.
+func (p *RedisMonthlyAggregateDeserializer) Deserialize(val string) []MonthlyAggregate {
+	var monthlyAggregates []MonthlyAggregate
+	// the values are in the form of `2017:0:1:0;2017:1:2:1`.
+	for _, v := range strings.Split(val, ";") {
+		counts := strings.Split(v, ":")
+		monthlyAggregates = append(monthlyAggregates, MonthlyAggregate{
+			Year:      stringutil.MustAtoi(counts[0]),
+			Month:     stringutil.MustAtoi(counts[1]),
+			Main:      stringutil.MustAtoi(counts[2]),
+			Alternate: stringutil.MustAtoi(counts[3]),
+		})
+	}
+	return monthlyAggregates
+}
+
 // YearlyAggregateSerializer serializes a struct into a string.
 type YearlyAggregateSerializer interface {
 	Serialize(aggregates []YearlyAggregate) string
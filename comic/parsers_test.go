@@ -20,3 +20,14 @@ func TestRedisYearlyAggregateDeserializerDeserialize(t *testing.T) {
 	assert.NotNil(t, vals)
 	assert.ElementsMatch(t, vals, expected)
 }
+
+func TestRedisMonthlyAggregateSerializerRoundTrip(t *testing.T) {
+	aggs := []comic.MonthlyAggregate{
+		{Year: 2017, Month: 0, Main: 1},
+		{Year: 2017, Month: 1, Main: 2, Alternate: 1},
+		{Year: 2017, Month: 2},
+	}
+	s := (&comic.RedisMonthlyAggregateSerializer{}).Serialize(aggs)
+	assert.Equal(t, "2017:0:1:0;2017:1:2:1;2017:2:0:0", s)
+	assert.Equal(t, aggs, (&comic.RedisMonthlyAggregateDeserializer{}).Deserialize(s))
+}
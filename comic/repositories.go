@@ -1,38 +1,64 @@
 package comic
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/comiccruncher/comiccruncher/internal/log"
+	"github.com/comiccruncher/comiccruncher/internal/metrics"
 	"github.com/go-pg/pg"
 	"github.com/go-pg/pg/orm"
 	"github.com/go-redis/redis"
 	"github.com/gosimple/slug"
 	"go.uber.org/zap"
 	"math"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 )
 
+// The ranks are computed from the characters' appearances per year when they're queried (see `RankedSQL`), so only
+// the views that have to aggregate every character issue are materialized.
 var (
-	// AllView is the materialized view for all characters with both main and alternate appearances.
-	AllView MaterializedView = "mv_ranked_characters"
-	// MainView is the materialized view for all characters with main appearances.
-	MainView MaterializedView = "mv_ranked_characters_main"
-	// AltView is the materialized view for all characters with alternate appearances.
-	AltView MaterializedView = "mv_ranked_characters_alternate"
-	// DcMainView is the materialized view for DC characters with main appearances.
-	DcMainView MaterializedView = "mv_ranked_characters_dc_main"
-	// MarvelMainView is the materialized view for Marvel characters with main appearances.
-	MarvelMainView MaterializedView = "mv_ranked_characters_marvel_main"
-	// MarvelTrendingView is the materialized view for trending Marvel characters for main appearances only.
-	MarvelTrendingView MaterializedView = "mv_trending_characters_marvel"
-	// DCTrendingView is the materialized view for trending DC characters for main appearances only.
-	DCTrendingView MaterializedView = "mv_trending_characters_dc"
+	// CoAppearanceView is the materialized view for the pairs of characters who appear in the same issues.
+	CoAppearanceView MaterializedView = "mv_co_appearances"
+	// Publishers get their own trending views. See `PublisherTrendingView`.
 	// Sooo many. In hindsight I should have used something like MongoDB. ¯\_(ツ)_/¯
 )
 
+// LegacyViews are the ranked views from before the ranks were computed when they're queried.
+var LegacyViews = []MaterializedView{
+	"mv_ranked_characters",
+	"mv_ranked_characters_main",
+	"mv_ranked_characters_alternate",
+	"mv_ranked_characters_weighted",
+	"mv_ranks",
+	"mv_ranks_main",
+	"mv_ranks_alternate",
+	"mv_ranks_weighted",
+}
+
+// The extra columns for the percentiles and normalized popularity the ranks have, but the trending views don't.
+var normalizedColumns = []string{
+	"issue_count_percentile as stats__issue_count_percentile",
+	"average_per_year_percentile as stats__average_percentile",
+	"normalized_popularity as stats__normalized_popularity",
+}
+
+// The extra columns for the stats of the characters ranked by relevance.
+var relevanceColumns = []string{"relevance_rank as stats__relevance_rank", "relevance as stats__relevance"}
+
+// The categories of ranks that get snapshotted. See `rankSnapshotSQLs` for which ranks they are.
+var rankSnapshotCategories = []CharacterStatsCategory{AllTimeStats, MainStats, AlternateStats, WeightedStats}
+
+const (
+	// Every daily rank snapshot is kept for this long. Older ones get thinned out to one per month.
+	rankSnapshotDailyRetention = 90 * 24 * time.Hour
+	// Any rank snapshot is kept for this long.
+	rankSnapshotRetention = 2 * 365 * 24 * time.Hour
+)
+
 // MaterializedView is the name of a table with a materialized view to cache expensive query results.
 type MaterializedView string
 
@@ -99,6 +125,7 @@ type CharacterSyncLogRepository interface {
 	Update(s *CharacterSyncLog) error
 	FindByID(id CharacterSyncLogID) (*CharacterSyncLog, error)
 	LastSyncs(id CharacterID) ([]*LastSync, error)
+	LastSuccess() (*CharacterSyncLog, error)
 }
 
 // CharacterIssueRepository is the repository interface for character issues.
@@ -106,7 +133,10 @@ type CharacterIssueRepository interface {
 	CreateAll(cis []*CharacterIssue) error
 	Create(ci *CharacterIssue) error
 	FindOneBy(characterID CharacterID, issueID IssueID) (*CharacterIssue, error)
+	FindAll(cr CharacterIssueCriteria) ([]*CharacterIssue, error)
 	InsertFast(issues []*CharacterIssue) error
+	UpdateImportance(ci *CharacterIssue) error
+	CreateExcluded(issues []*CharacterExcludedIssue) error
 	RemoveAllByCharacterID(id CharacterID) (int, error)
 }
 
@@ -115,9 +145,19 @@ type AppearancesByYearsRepository interface {
 	List(slugs CharacterSlug) (AppearancesByYears, error)
 }
 
+// AppearancesByMonthsRepository is the repository interface for getting a character's appearances per month.
+type AppearancesByMonthsRepository interface {
+	List(slug CharacterSlug) (AppearancesByMonths, error)
+}
+
 // AppearancesByYearsMapRepository is the repository for listing a character's appearances by years in a map.
 type AppearancesByYearsMapRepository interface {
-	ListMap(slugs ...CharacterSlug) (map[CharacterSlug][]AppearancesByYears, error)
+	ListMap(slugs ...CharacterSlug) (map[CharacterSlug]AppearancesByYears, error)
+}
+
+// SeriesRepository is the repository interface for the series a character appears in the most.
+type SeriesRepository interface {
+	Series(slug CharacterSlug) (CharacterSeries, error)
 }
 
 // StatsRepository is the repository interface for general stats about the db.
@@ -128,19 +168,45 @@ type StatsRepository interface {
 // PopularRepository is the repository interface for popular character rankings.
 type PopularRepository interface {
 	All(cr PopularCriteria) ([]*RankedCharacter, error)
-	DC(cr PopularCriteria) ([]*RankedCharacter, error)
-	Marvel(cr PopularCriteria) ([]*RankedCharacter, error)
-	FindOneByDC(id CharacterID) (*RankedCharacter, error)
-	FindOneByMarvel(id CharacterID) (*RankedCharacter, error)
+	Publisher(slug PublisherSlug, cr PopularCriteria) ([]*RankedCharacter, error)
+	FindOneByPublisher(slug PublisherSlug, id CharacterID) (*RankedCharacter, error)
 	FindOneByAll(id CharacterID) (*RankedCharacter, error)
-	MarvelTrending(limit, offset int) ([]*RankedCharacter, error)
-	DCTrending(limit, offset int) ([]*RankedCharacter, error)
+	FindOneByWeighted(id CharacterID) (*RankedCharacter, error)
+	Trending(slug PublisherSlug, cr TrendingCriteria) ([]*RankedCharacter, error)
+	Total(cr PopularCriteria) (int, error)
+	PublisherTotal(slug PublisherSlug) (int, error)
+	LastRefreshed() (time.Time, error)
+}
+
+// CareerRepository is the repository interface for the facts about a character's career.
+type CareerRepository interface {
+	Career(id CharacterID) (*CareerStats, error)
+}
+
+// FormatMixRepository is the repository interface for the issues in each format that counted as appearances
+// and the ones that didn't.
+type FormatMixRepository interface {
+	FormatMix(slug CharacterSlug) ([]FormatBreakdown, error)
+}
+
+// CoAppearanceRepository is the repository interface for the characters who appear in the same issues.
+type CoAppearanceRepository interface {
+	Partners(id CharacterID, cr CoAppearanceCriteria) ([]*CoAppearance, error)
+	Graph(cr CoAppearanceGraphCriteria) (*CoAppearanceGraph, error)
+}
+
+// RankSnapshotRepository is the repository interface for the history of the characters' ranks.
+type RankSnapshotRepository interface {
+	Snapshot(date time.Time) error
+	History(id CharacterID, cat CharacterStatsCategory) ([]*RankSnapshot, error)
+	Prune(dailyBefore, before time.Time) (int, error)
 }
 
 // PopularRefresher concurrently refreshes the materialized views.
 type PopularRefresher interface {
 	Refresh(view MaterializedView) error
 	RefreshAll() error
+	CreatePublisherViews(p *Publisher) error
 }
 
 // CharacterThumbRepository is the repository for getting character thumbnails.
@@ -158,11 +224,13 @@ type RedisCharacterThumbRepository struct {
 func (ctr *RedisCharacterThumbRepository) Thumbnails(slug CharacterSlug) (*CharacterThumbnails, error) {
 	result, err := ctr.r.Get(redisThumbnailKey(slug)).Result()
 	if err == redis.Nil {
+		metrics.ObserveCache(metrics.CacheThumbnails, 0, 1)
 		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
+	metrics.ObserveCache(metrics.CacheThumbnails, 1, 0)
 	thumbs := &CharacterThumbnails{}
 	err = parseRedisThumbnails(result, thumbs)
 	return thumbs, err
@@ -180,16 +248,19 @@ func (ctr *RedisCharacterThumbRepository) AllThumbnails(slugs ...CharacterSlug)
 		return nil, err
 	}
 	allThumbs := make(map[CharacterSlug]*CharacterThumbnails, slcLen)
+	hits := 0
 	for i, s := range slugs {
 		thumb := &CharacterThumbnails{Slug: s}
 		allThumbs[s] = thumb
 		if all != nil {
 			val := all[i]
 			if val != nil {
+				hits++
 				parseRedisThumbnails(val.(string), thumb)
 			}
 		}
 	}
+	metrics.ObserveCache(metrics.CacheThumbnails, hits, slcLen-hits)
 
 	return allThumbs, nil
 }
@@ -200,6 +271,44 @@ type PGPopularRepository struct {
 	ctr CharacterThumbRepository
 }
 
+// PGAppearancesByMonthsRepository is the postgres implementation for the appearances per month repository.
+type PGAppearancesByMonthsRepository struct {
+	db ORM
+}
+
+// RedisAppearancesByMonthsRepository is the Redis implementation for the appearances per month repository.
+type RedisAppearancesByMonthsRepository struct {
+	redisClient  RedisClient
+	deserializer MonthlyAggregateDeserializer
+	serializer   MonthlyAggregateSerializer
+}
+
+// PGSeriesRepository is the postgres implementation for the series repository.
+type PGSeriesRepository struct {
+	db ORM
+}
+
+// PGCareerRepository is the postgres implementation for the career repository.
+type PGCareerRepository struct {
+	db ORM
+}
+
+// PGFormatMixRepository is the postgres implementation for the format mix repository.
+type PGFormatMixRepository struct {
+	db ORM
+}
+
+// PGCoAppearanceRepository is the postgres implementation for the co-appearance repository.
+type PGCoAppearanceRepository struct {
+	db  ORM
+	ctr CharacterThumbRepository
+}
+
+// PGRankSnapshotRepository is the postgres implementation for the rank snapshot repository.
+type PGRankSnapshotRepository struct {
+	db ORM
+}
+
 // PGAppearancesByYearsRepository is the postgres implementation for the appearances per year repository.
 type PGAppearancesByYearsRepository struct {
 	db ORM
@@ -240,6 +349,43 @@ type PGStatsRepository struct {
 	db ORM
 }
 
+// RedisStatsRepository caches the stats from another stats repository in Redis until the views get refreshed.
+type RedisStatsRepository struct {
+	r    RedisClient
+	repo StatsRepository
+}
+
+// RedisPopularRepository is the popular character repository that caches when the views were last refreshed
+// in Redis, since it gets checked on every request for the rankings.
+type RedisPopularRepository struct {
+	PopularRepository
+	r RedisClient
+}
+
+// StatsCacheRefresher refreshes the materialized views and then removes the cached stats since they're out of date.
+type StatsCacheRefresher struct {
+	PopularRefresher
+	r RedisClient
+}
+
+// RankSnapshotRefresher refreshes the materialized views and then snapshots the characters' ranks,
+// since the ranks only ever show the current counts.
+type RankSnapshotRefresher struct {
+	PopularRefresher
+	snapshots RankSnapshotRepository
+	now       func() time.Time
+}
+
+// RedisSeriesRepository is the Redis implementation for the series repository.
+type RedisSeriesRepository struct {
+	r RedisClient
+}
+
+// RedisFormatMixRepository is the Redis implementation for the format mix repository.
+type RedisFormatMixRepository struct {
+	r RedisClient
+}
+
 // RedisAppearancesByYearsRepository is the Redis implementation for appearances per year repository.
 type RedisAppearancesByYearsRepository struct {
 	redisClient  RedisClient
@@ -417,51 +563,91 @@ func (r *PGCharacterRepository) UpdateAll(characters []*Character) error {
 	return nil
 }
 
-// CreateAll creates the issues in the slice.
+// CreateAll creates the issues in the slice and adds them to the characters' appearances per year.
 func (r *PGCharacterIssueRepository) CreateAll(issues []*CharacterIssue) error {
 	// pg-go gives error if you pass an empty slice.
 	// interface should handle empty slice accordingly.
 	if len(issues) > 0 {
-		if _, err := r.db.Model(&issues).OnConflict("DO NOTHING").Insert(); err != nil {
+		return r.db.RunInTransaction(func(tx *pg.Tx) error {
+			if _, err := tx.Model(&issues).OnConflict("DO NOTHING").Insert(); err != nil {
+				return err
+			}
+			// the issues that already existed don't get an ID back, so only the ones just inserted get counted.
+			var ids []CharacterIssueID
+			for _, ci := range issues {
+				if ci.ID != 0 {
+					ids = append(ids, ci.ID)
+				}
+			}
+			if len(ids) == 0 {
+				return nil
+			}
+			_, err := tx.Exec(YearCountsDeltaSQL("ci.id IN (?0)", 1), pg.In(ids))
 			return err
-		}
+		})
 	}
 	return nil
 }
 
 // InsertFast creates all the issues in the db ...
 // but NOTE it does not generate the autoincremented ID's into the models of the slice. :(
+// Issues that already exist only get their importance set if they don't have one yet, so a curator's importance
+// doesn't get overwritten. In the same transaction, the issues that get inserted are added to the characters'
+// appearances per year, and the ones that get an importance are taken away before and added back after.
 // TODO: Find out why ORM can't do this?!?!
 func (r *PGCharacterIssueRepository) InsertFast(issues []*CharacterIssue) error {
 	if len(issues) > 0 {
 		query := `INSERT INTO character_issues (character_id, issue_id, appearance_type, importance, created_at, updated_at)
-			VALUES %s ON CONFLICT DO NOTHING`
+			VALUES %s ON CONFLICT (character_id, issue_id) DO UPDATE SET importance = EXCLUDED.importance
+			WHERE character_issues.importance IS NULL AND EXCLUDED.importance IS NOT NULL
+			RETURNING id`
 		values := ""
+		var withImportance []string
 		for i, c := range issues {
 			var importance string
 			if c.Importance == nil {
 				importance = "NULL"
 			} else {
-				importance = string(*c.Importance)
+				importance = strconv.Itoa(int(*c.Importance))
+				withImportance = append(withImportance, fmt.Sprintf("(%d, %d)", c.CharacterID, c.IssueID))
 			}
 			values += fmt.Sprintf("(%d, %d, '%08b', %s, now(), now())", c.CharacterID, c.IssueID, c.AppearanceType, importance)
 			if i != len(issues)-1 {
 				values += ", "
 			}
 		}
-		if _, err := r.db.Query(&issues, fmt.Sprintf(query, values)); err != nil {
+		return r.db.RunInTransaction(func(tx *pg.Tx) error {
+			// the issues that are about to get an importance weigh differently after, so their old weight comes off first.
+			if len(withImportance) > 0 {
+				where := fmt.Sprintf("ci.importance IS NULL AND (ci.character_id, ci.issue_id) IN (%s)", strings.Join(withImportance, ", "))
+				if _, err := tx.Exec(YearCountsDeltaSQL(where, -1)); err != nil {
+					return err
+				}
+			}
+			// only the issues that got inserted or updated come back.
+			var ids []CharacterIssueID
+			if _, err := tx.Query(&ids, fmt.Sprintf(query, values)); err != nil {
+				return err
+			}
+			if len(ids) == 0 {
+				return nil
+			}
+			_, err := tx.Exec(YearCountsDeltaSQL("ci.id IN (?0)", 1), pg.In(ids))
 			return err
-		}
+		})
 	}
 	return nil
 }
 
-// Create creates a character issue.
+// Create creates a character issue and adds it to the character's appearances per year.
 func (r *PGCharacterIssueRepository) Create(ci *CharacterIssue) error {
-	if _, err := r.db.Model(ci).Returning("*").Insert(); err != nil {
+	return r.db.RunInTransaction(func(tx *pg.Tx) error {
+		if _, err := tx.Model(ci).Returning("*").Insert(); err != nil {
+			return err
+		}
+		_, err := tx.Exec(YearCountsDeltaSQL("ci.id = ?0", 1), ci.ID)
 		return err
-	}
-	return nil
+	})
 }
 
 // FindOneBy finds a character issue by the params.
@@ -476,10 +662,101 @@ func (r *PGCharacterIssueRepository) FindOneBy(characterID CharacterID, issueID
 	return characterIssue, nil
 }
 
+// FindAll finds the character issues with their issues by the criteria.
+func (r *PGCharacterIssueRepository) FindAll(cr CharacterIssueCriteria) ([]*CharacterIssue, error) {
+	var characterIssues []*CharacterIssue
+	query := r.db.Model(&characterIssues).Column("character_issue.*", "Issue")
+
+	if len(cr.CharacterIDs) > 0 {
+		query.Where("character_issue.character_id IN (?)", pg.In(cr.CharacterIDs))
+	}
+
+	if cr.AppearanceType > 0 {
+		query.Where(fmt.Sprintf("character_issue.appearance_type & B'%08b' > 0::BIT(8)", cr.AppearanceType))
+	}
+
+	if len(cr.Formats) > 0 {
+		query.Where("issue.format IN (?)", pg.In(cr.Formats))
+	}
+
+	if len(cr.Years) > 0 {
+		query.Where("date_part('year', issue.sale_date) IN (?)", pg.In(cr.Years))
+	}
+
+	if cr.IsVariant != nil {
+		query.Where("issue.is_variant = ?", *cr.IsVariant)
+	}
+
+	if cr.IsReprint != nil {
+		query.Where("issue.is_reprint = ?", *cr.IsReprint)
+	}
+
+	if cr.Limit > 0 {
+		query.Limit(cr.Limit)
+	}
+
+	if cr.Offset > 0 {
+		query.Offset(cr.Offset)
+	}
+
+	sortBy := cr.SortBy
+	if sortBy == "" {
+		sortBy = SaleDateAsc
+	}
+
+	if err := query.Order(string(sortBy), "character_issue.id ASC").Select(); err != nil {
+		return nil, err
+	}
+
+	return characterIssues, nil
+}
+
+// UpdateImportance updates the character's importance in the issue and its weight in the character's appearances
+// per year.
+func (r *PGCharacterIssueRepository) UpdateImportance(ci *CharacterIssue) error {
+	return r.db.RunInTransaction(func(tx *pg.Tx) error {
+		if _, err := tx.Exec(YearCountsDeltaSQL("ci.id = ?0", -1), ci.ID); err != nil {
+			return err
+		}
+		if _, err := tx.Model(ci).Column("importance").WherePK().Update(); err != nil {
+			return err
+		}
+		_, err := tx.Exec(YearCountsDeltaSQL("ci.id = ?0", 1), ci.ID)
+		return err
+	})
+}
+
+// CreateExcluded creates the issues that didn't count as appearances. Issues that were already excluded are skipped.
+func (r *PGCharacterIssueRepository) CreateExcluded(issues []*CharacterExcludedIssue) error {
+	// pg-go gives error if you pass an empty slice.
+	if len(issues) > 0 {
+		if _, err := r.db.Model(&issues).OnConflict("DO NOTHING").Insert(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // RemoveAllByCharacterID removes ALL character issues associated with the given character ID.
+// The excluded issues and the appearances per year are removed too but aren't part of the count.
 func (r *PGCharacterIssueRepository) RemoveAllByCharacterID(id CharacterID) (int, error) {
-	res, err := r.db.Model(&CharacterIssue{}).Where("character_id = ?", id).Delete()
-	return res.RowsAffected(), err
+	removed := 0
+	err := r.db.RunInTransaction(func(tx *pg.Tx) error {
+		res, err := tx.Model(&CharacterIssue{}).Where("character_id = ?", id).Delete()
+		if err != nil {
+			return err
+		}
+		removed = res.RowsAffected()
+		if _, err := tx.Model(&CharacterExcludedIssue{}).Where("character_id = ?", id).Delete(); err != nil {
+			return err
+		}
+		_, err = tx.Model(&CharacterYearCount{}).Where("character_id = ?", id).Delete()
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return removed, nil
 }
 
 // Create creates a character source.
@@ -584,6 +861,23 @@ func (r *PGCharacterSyncLogRepository) FindByID(id CharacterSyncLogID) (*Charact
 	return syncLog, nil
 }
 
+// LastSuccess gets the most recent successful sync log for any character.
+func (r *PGCharacterSyncLogRepository) LastSuccess() (*CharacterSyncLog, error) {
+	syncLog := &CharacterSyncLog{}
+	if err := r.db.Model(syncLog).
+		Where("sync_status = ?", Success).
+		Where("synced_at IS NOT NULL").
+		Order("synced_at DESC").
+		Limit(1).
+		Select(); err != nil {
+		if err == pg.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return syncLog, nil
+}
+
 // LastSyncs gets the last successful sync logs for a character.
 func (r *PGCharacterSyncLogRepository) LastSyncs(id CharacterID) ([]*LastSync, error) {
 	var ls []*LastSync
@@ -616,9 +910,18 @@ func (r *PGIssueRepository) CreateAll(issues []*Issue) error {
 	return nil
 }
 
-// Update updates an issue.
+// Update updates an issue. Its characters' appearances per year get moved over in case the sale date changed.
 func (r *PGIssueRepository) Update(issue *Issue) error {
-	return r.db.Update(issue)
+	return r.db.RunInTransaction(func(tx *pg.Tx) error {
+		if _, err := tx.Exec(YearCountsDeltaSQL("ci.issue_id = ?0", -1), issue.ID); err != nil {
+			return err
+		}
+		if err := tx.Update(issue); err != nil {
+			return err
+		}
+		_, err := tx.Exec(YearCountsDeltaSQL("ci.issue_id = ?0", 1), issue.ID)
+		return err
+	})
 }
 
 // FindByVendorID finds the issues with the specified vendor IDs.
@@ -670,17 +973,380 @@ func (r *PGIssueRepository) FindAll(cr IssueCriteria) ([]*Issue, error) {
 // Stats gets stats for the comic repository.
 func (r *PGStatsRepository) Stats() (Stats, error) {
 	stats := Stats{}
-	_, err := r.db.QueryOne(&stats, `
+	_, err := r.db.QueryOne(pg.Scan(
+		&stats.MinYear,
+		&stats.MaxYear,
+		&stats.TotalAppearances,
+		&stats.MainAppearances,
+		&stats.AlternateAppearances,
+		&stats.TotalCharacters,
+		&stats.TotalIssues,
+		&stats.VariantIssues,
+		&stats.ReprintIssues), `
 		SELECT date_part('year', min(i.sale_date)) AS min_year, 
 		date_part('year', max(i.sale_date)) AS max_year,
 		count(ci.id) as total_appearances,
+		count(ci.id) FILTER (WHERE ci.appearance_type & B'00000001' > 0::BIT(8)) AS main_appearances,
+		count(ci.id) FILTER (WHERE ci.appearance_type & B'00000010' > 0::BIT(8)) AS alternate_appearances,
        	(SELECT count(*) FROM characters c
        		WHERE EXISTS (SELECT 1 FROM character_sources cs WHERE cs.character_id = c.id)
 			AND EXISTS (SELECT 1 FROM character_issues ci WHERE ci.character_id = C.id)) as total_characters,
-       	(SELECT count(*) FROM issues) AS total_issues
+       	(SELECT count(*) FROM issues) AS total_issues,
+		(SELECT count(*) FROM issues WHERE is_variant) AS variant_issues,
+		(SELECT count(*) FROM issues WHERE is_reprint) AS reprint_issues
 		FROM character_issues ci
 		INNER JOIN issues i ON ci.issue_id = i.id`)
-	return stats, err
+	if err != nil {
+		return stats, err
+	}
+	if _, err = r.db.Query(&stats.Publishers, `
+		SELECT p.slug, p.name, count(DISTINCT ci.character_id) AS total_characters, count(ci.id) AS total_appearances
+		FROM publishers p
+		INNER JOIN characters c ON c.publisher_id = p.id
+		INNER JOIN character_issues ci ON ci.character_id = c.id
+		GROUP BY p.id
+		ORDER BY total_appearances DESC`); err != nil {
+		return stats, err
+	}
+	if _, err = r.db.Query(&stats.Formats, `
+		SELECT format, count(*) AS total_issues
+		FROM issues
+		GROUP BY format
+		ORDER BY total_issues DESC, format`); err != nil {
+		return stats, err
+	}
+	if stats.FormatMix, err = formatMix(r.db, ""); err != nil {
+		return stats, err
+	}
+	if _, err = r.db.Query(&stats.Decades, `
+		SELECT (date_part('year', i.sale_date)::INT / 10) * 10 AS decade,
+		count(ci.id) FILTER (WHERE ci.appearance_type & B'00000001' > 0::BIT(8)) AS main_appearances,
+		count(ci.id) FILTER (WHERE ci.appearance_type & B'00000010' > 0::BIT(8)) AS alternate_appearances
+		FROM character_issues ci
+		INNER JOIN issues i ON ci.issue_id = i.id
+		GROUP BY decade
+		ORDER BY decade`); err != nil {
+		return stats, err
+	}
+	var refreshed, synced pg.NullTime
+	if _, err = r.db.QueryOne(pg.Scan(&refreshed, &synced), `
+		SELECT (SELECT max(refreshed_at) FROM view_refreshes),
+		(SELECT max(synced_at) FROM character_sync_logs WHERE sync_status = ?)`, Success); err != nil {
+		return stats, err
+	}
+	if !refreshed.IsZero() {
+		stats.LastRefreshedAt = &refreshed.Time
+	}
+	if !synced.IsZero() {
+		stats.LastSyncedAt = &synced.Time
+	}
+	return stats, nil
+}
+
+// Stats gets the cached stats or gets them from the repository and caches them if they aren't cached yet.
+func (r *RedisStatsRepository) Stats() (Stats, error) {
+	cached, err := r.r.Get(redisStatsKey).Result()
+	if err != nil && err != redis.Nil {
+		return Stats{}, err
+	}
+	if cached != "" {
+		stats := Stats{}
+		err = json.Unmarshal([]byte(cached), &stats)
+		if err == nil {
+			metrics.ObserveCache(metrics.CacheGlobalStats, 1, 0)
+			return stats, nil
+		}
+		log.COMIC().Error("error unmarshaling cached stats", zap.Error(err))
+	}
+	metrics.ObserveCache(metrics.CacheGlobalStats, 0, 1)
+	stats, err := r.repo.Stats()
+	if err != nil {
+		return stats, err
+	}
+	b, err := json.Marshal(stats)
+	if err != nil {
+		return stats, err
+	}
+	// Expire the stats eventually in case a refresh somewhere didn't invalidate them.
+	if err := r.r.Set(redisStatsKey, b, statsTTL).Err(); err != nil {
+		log.COMIC().Error("error caching stats", zap.Error(err))
+	}
+	return stats, nil
+}
+
+// LastRefreshed gets the cached time the views were last refreshed or gets it from the repository and caches it
+// if it isn't cached yet.
+func (r *RedisPopularRepository) LastRefreshed() (time.Time, error) {
+	cached, err := r.r.Get(redisLastRefreshedKey).Result()
+	if err != nil && err != redis.Nil {
+		return time.Time{}, err
+	}
+	if cached != "" {
+		t, err := time.Parse(time.RFC3339Nano, cached)
+		if err == nil {
+			metrics.ObserveCache(metrics.CacheLastRefreshed, 1, 0)
+			return t, nil
+		}
+		log.COMIC().Error("error parsing cached last refreshed time", zap.Error(err))
+	}
+	metrics.ObserveCache(metrics.CacheLastRefreshed, 0, 1)
+	t, err := r.PopularRepository.LastRefreshed()
+	if err != nil {
+		return t, err
+	}
+	if err := r.r.Set(redisLastRefreshedKey, t.Format(time.RFC3339Nano), lastRefreshedTTL).Err(); err != nil {
+		log.COMIC().Error("error caching last refreshed time", zap.Error(err))
+	}
+	return t, nil
+}
+
+// RefreshAll refreshes all the materialized views and removes the cached stats and the cached time the views
+// were last refreshed.
+func (r *StatsCacheRefresher) RefreshAll() error {
+	if err := r.PopularRefresher.RefreshAll(); err != nil {
+		return err
+	}
+	return r.r.Del(redisStatsKey, redisLastRefreshedKey).Err()
+}
+
+// RefreshAll refreshes the views, snapshots the ranks for today, and prunes the old snapshots.
+func (r *RankSnapshotRefresher) RefreshAll() error {
+	if err := r.PopularRefresher.RefreshAll(); err != nil {
+		return err
+	}
+	now := r.now()
+	if err := r.snapshots.Snapshot(now); err != nil {
+		return err
+	}
+	pruned, err := r.snapshots.Prune(now.Add(-rankSnapshotDailyRetention), now.Add(-rankSnapshotRetention))
+	if err != nil {
+		return err
+	}
+	log.COMIC().Info("snapshotted ranks", zap.Int("pruned", pruned))
+	return nil
+}
+
+// Career gets the facts about the character's career from all of their appearances.
+// Returns nil if the character doesn't have any appearances.
+func (r *PGCareerRepository) Career(id CharacterID) (*CareerStats, error) {
+	issueSQL := `
+		SELECT i.id AS issue_id, i.sale_date, i.vendor_series_name, i.vendor_series_number
+		FROM issues i
+		INNER JOIN character_issues ci ON ci.issue_id = i.id
+		WHERE ci.character_id = ?
+		ORDER BY i.sale_date %[1]s, i.id %[1]s
+		LIMIT 1`
+	first := CareerIssue{}
+	if _, err := r.db.QueryOne(&first, fmt.Sprintf(issueSQL, "ASC"), id); err != nil {
+		if err == pg.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	latest := CareerIssue{}
+	if _, err := r.db.QueryOne(&latest, fmt.Sprintf(issueSQL, "DESC"), id); err != nil {
+		return nil, err
+	}
+	var counts []struct {
+		Year  int
+		Count int
+	}
+	if _, err := r.db.Query(&counts, `
+		SELECT date_part('year', i.sale_date)::INT AS year, count(*) AS count
+		FROM issues i
+		INNER JOIN character_issues ci ON ci.issue_id = i.id
+		WHERE ci.character_id = ?
+		GROUP BY year`, id); err != nil {
+		return nil, err
+	}
+	years := make(map[int]int, len(counts))
+	for _, c := range counts {
+		years[c.Year] = c.Count
+	}
+	return NewCareerStats(first, latest, years, time.Now()), nil
+}
+
+// FormatMix gets the number of the character's issues in each format that counted as appearances and the number
+// that didn't for each reason, with the formats with the most issues first. This aggregates all the character's
+// issues, so you should use the Redis repo instead.
+func (r *PGFormatMixRepository) FormatMix(slug CharacterSlug) ([]FormatBreakdown, error) {
+	return formatMix(r.db, slug)
+}
+
+// Gets the format mix for the character or for every character if the slug is blank.
+func formatMix(db ORM, slug CharacterSlug) ([]FormatBreakdown, error) {
+	var counts []FormatCount
+	if _, err := db.Query(&counts, `
+		SELECT i.format, '' AS reason, count(DISTINCT i.id) AS count
+		FROM character_issues ci
+		INNER JOIN issues i ON i.id = ci.issue_id
+		WHERE ?0 = '' OR ci.character_id = (SELECT id FROM characters WHERE slug = ?0)
+		GROUP BY i.format
+		UNION ALL
+		SELECT i.format, cei.reason, count(DISTINCT i.id) AS count
+		FROM character_excluded_issues cei
+		INNER JOIN issues i ON i.id = cei.issue_id
+		WHERE ?0 = '' OR cei.character_id = (SELECT id FROM characters WHERE slug = ?0)
+		GROUP BY i.format, cei.reason`, slug); err != nil {
+		return nil, err
+	}
+	return NewFormatMix(counts), nil
+}
+
+// Partners gets the characters who appear in the same issues as the character with the number of issues they
+// share and their affinity.
+func (r *PGCoAppearanceRepository) Partners(id CharacterID, cr CoAppearanceCriteria) ([]*CoAppearance, error) {
+	sort := cr.SortBy
+	if sort == "" {
+		sort = MostSharedIssues
+	}
+	var partners []*CoAppearance
+	if _, err := r.db.Query(&partners, fmt.Sprintf(`
+		SELECT
+			co.issue_count,
+			co.affinity,
+			c.id,
+			c.publisher_id,
+			c.name,
+			c.other_name,
+			c.image,
+			c.slug,
+			c.vendor_image,
+			p.id AS publisher__id,
+			p.slug AS publisher__slug,
+			p.name AS publisher__name
+		FROM %s co
+			JOIN characters c ON c.id = co.partner_id
+			JOIN publishers p ON p.id = c.publisher_id
+		WHERE co.character_id = ?0
+		ORDER BY co.%s DESC, co.issue_count DESC, c.id
+		LIMIT ?1 OFFSET ?2`, CoAppearanceView, sort), id, cr.Limit, cr.Offset); err != nil {
+		return nil, err
+	}
+	slugs := make([]CharacterSlug, len(partners))
+	for i, c := range partners {
+		slugs[i] = c.Slug
+	}
+	thumbs, err := r.ctr.AllThumbnails(slugs...)
+	if err != nil {
+		return partners, err
+	}
+	for _, c := range partners {
+		thumb := thumbs[c.Slug]
+		if thumb != nil && (thumb.VendorImage != nil || thumb.Image != nil) {
+			c.Thumbnails = thumb
+		}
+	}
+	return partners, nil
+}
+
+// Graph gets the pairs of characters with the most shared issues as edges and the characters in them as nodes.
+func (r *PGCoAppearanceRepository) Graph(cr CoAppearanceGraphCriteria) (*CoAppearanceGraph, error) {
+	g := &CoAppearanceGraph{Nodes: []*CoAppearanceNode{}, Edges: []*CoAppearanceEdge{}}
+	if _, err := r.db.Query(&g.Edges, fmt.Sprintf(`
+		SELECT character_id AS source, partner_id AS target, issue_count, affinity
+		FROM %s
+		WHERE character_id < partner_id AND issue_count >= ?0
+		ORDER BY issue_count DESC, character_id, partner_id
+		LIMIT ?1`, CoAppearanceView), cr.MinIssueCount, cr.Limit); err != nil {
+		return nil, err
+	}
+	if len(g.Edges) == 0 {
+		return g, nil
+	}
+	seen := make(map[CharacterID]bool)
+	var ids []CharacterID
+	for _, e := range g.Edges {
+		for _, id := range []CharacterID{e.Source, e.Target} {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	_, err := r.db.Query(&g.Nodes, `
+		SELECT c.id, c.slug, c.name, p.slug AS publisher, count(ci.id) AS issue_count
+		FROM characters c
+			JOIN publishers p ON p.id = c.publisher_id
+			JOIN character_issues ci ON ci.character_id = c.id
+		WHERE c.id IN (?)
+		GROUP BY c.id, p.slug
+		ORDER BY c.id`, pg.In(ids))
+	return g, err
+}
+
+// Snapshot records every character's ranks for the date. Snapshotting again on the same date
+// replaces the ranks for that date.
+func (r *PGRankSnapshotRepository) Snapshot(date time.Time) error {
+	return r.db.RunInTransaction(func(tx *pg.Tx) error {
+		var publishers []*Publisher
+		if err := tx.Model(&publishers).Select(); err != nil {
+			return err
+		}
+		for _, cat := range rankSnapshotCategories {
+			for _, sql := range rankSnapshotSQLs(cat, publishers) {
+				_, err := tx.Exec(fmt.Sprintf(`
+					INSERT INTO rank_snapshots (character_id, category, issue_count_rank, issue_count, average_rank, average, snapshot_date)
+					SELECT id, ?0, issue_count_rank, issue_count, average_per_year_rank, average_per_year, ?1
+					FROM (%s) AS ranked
+					ON CONFLICT (character_id, category, snapshot_date) DO UPDATE SET
+						issue_count_rank = EXCLUDED.issue_count_rank,
+						issue_count = EXCLUDED.issue_count,
+						average_rank = EXCLUDED.average_rank,
+						average = EXCLUDED.average`, sql), cat, date.Format("2006-01-02"))
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// Gets the SQL for the ranks to snapshot for the category, so the history charts the same ranks the character's
+// stats show. The all time, alternate, and weighted ranks are among every publisher's characters like in the rankings.
+// The main ranks are among the characters of the same publisher like the main stats, so each publisher's get
+// snapshotted on their own.
+func rankSnapshotSQLs(cat CharacterStatsCategory, publishers []*Publisher) []string {
+	switch cat {
+	case MainStats:
+		sqls := make([]string, len(publishers))
+		for i, p := range publishers {
+			sqls[i] = RankedSQL(Main, p.ID)
+		}
+		return sqls
+	case AlternateStats:
+		return []string{RankedSQL(Alternate, 0)}
+	case WeightedStats:
+		return []string{WeightedSQL()}
+	}
+	return []string{RankedSQL(Main|Alternate, 0)}
+}
+
+// History gets the character's snapshots for the category, oldest first.
+func (r *PGRankSnapshotRepository) History(id CharacterID, cat CharacterStatsCategory) ([]*RankSnapshot, error) {
+	var snapshots []*RankSnapshot
+	err := r.db.Model(&snapshots).
+		Where("rank_snapshot.character_id = ?", id).
+		Where("rank_snapshot.category = ?", cat).
+		Order("rank_snapshot.snapshot_date ASC").
+		Select()
+	return snapshots, err
+}
+
+// Prune deletes the snapshots before `before` and thins out the ones before `dailyBefore` to the first
+// snapshot of each month. Returns the number of snapshots deleted.
+func (r *PGRankSnapshotRepository) Prune(dailyBefore, before time.Time) (int, error) {
+	res, err := r.db.Exec(`
+		DELETE FROM rank_snapshots
+		WHERE snapshot_date < ?0
+		OR (snapshot_date < ?1 AND snapshot_date NOT IN (
+			SELECT min(snapshot_date) FROM rank_snapshots GROUP BY date_trunc('month', snapshot_date)
+		))`, before, dailyBefore)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
 }
 
 func (r *PGAppearancesByYearsRepository) createQuery(slug CharacterSlug, t AppearanceType) string {
@@ -742,8 +1408,10 @@ func (r *RedisAppearancesByYearsRepository) List(s CharacterSlug) (AppearancesBy
 		return AppearancesByYears{}, err
 	}
 	if all != "" {
+		metrics.ObserveCache(metrics.CacheAppearances, 1, 0)
 		return NewAppearancesByYears(s, r.deserializer.Deserialize(all)), nil
 	}
+	metrics.ObserveCache(metrics.CacheAppearances, 0, 1)
 	return NewAppearancesByYears(s, nil), nil
 }
 
@@ -759,14 +1427,17 @@ func (r *RedisAppearancesByYearsRepository) ListMap(slugs ...CharacterSlug) (map
 		return nil, err
 	}
 	allApps := make(map[CharacterSlug]AppearancesByYears, slcLen)
+	hits := 0
 	for i, s := range slugs {
 		val := all[i]
 		if val != nil {
+			hits++
 			allApps[s] = NewAppearancesByYears(s, r.deserializer.Deserialize(val.(string)))
 		} else {
 			allApps[s] = NewAppearancesByYears(s, nil)
 		}
 	}
+	metrics.ObserveCache(metrics.CacheAppearances, hits, slcLen-hits)
 	return allApps, nil
 }
 
@@ -787,57 +1458,232 @@ func (r *RedisAppearancesByYearsRepository) Delete(slug CharacterSlug) (int64, e
 	return r.redisClient.Del(getAppearanceKey(slug)).Result()
 }
 
+// List gets the character's main and alternate appearances per month for their recent years. The issues with
+// an uncertain month are counted in their year's month 0. The months without appearances aren't filled in, so
+// they can be filled in up to when they're read. This isn't very efficient, so you should use the Redis repo instead.
+func (r *PGAppearancesByMonthsRepository) List(slug CharacterSlug) (AppearancesByMonths, error) {
+	var aggs []MonthlyAggregate
+	now := time.Now()
+	_, err := r.db.Query(&aggs, `
+		SELECT
+			   date_part('year', i.sale_date)::INT AS year,
+			   CASE WHEN i.month_uncertain THEN 0 ELSE date_part('month', i.sale_date)::INT END AS month,
+			   count(ci.id) FILTER (WHERE ci.appearance_type & B'00000001' > 0::BIT(8)) AS main,
+			   count(ci.id) FILTER (WHERE ci.appearance_type & B'00000010' > 0::BIT(8)) AS alternate
+		FROM character_issues ci
+					JOIN issues i ON i.id = ci.issue_id
+					JOIN characters c ON c.id = ci.character_id
+		WHERE c.slug = ?0 AND i.sale_date >= make_date(?1, 1, 1)
+		GROUP BY 1, 2
+		ORDER BY 1, 2`, slug, now.Year()-monthlyYears+1)
+	if err != nil {
+		return AppearancesByMonths{}, err
+	}
+	return NewAppearancesByMonths(slug, aggs), nil
+}
+
+// List gets the character's appearances per month from Redis. The aggregates are empty if they haven't been synced.
+func (r *RedisAppearancesByMonthsRepository) List(slug CharacterSlug) (AppearancesByMonths, error) {
+	all, err := r.redisClient.Get(redisMonthlyAppearancesKey(slug)).Result()
+	if err != nil && err != redis.Nil {
+		return AppearancesByMonths{}, err
+	}
+	if all != "" {
+		metrics.ObserveCache(metrics.CacheMonthlyAppearances, 1, 0)
+		return NewAppearancesByMonths(slug, r.deserializer.Deserialize(all)), nil
+	}
+	metrics.ObserveCache(metrics.CacheMonthlyAppearances, 0, 1)
+	return NewAppearancesByMonths(slug, nil), nil
+}
+
+// Set sets the character's appearances per month in Redis.
+func (r *RedisAppearancesByMonthsRepository) Set(apps AppearancesByMonths) error {
+	if apps.CharacterSlug.Value() == "" {
+		return errors.New("got blank character slug for monthly appearances")
+	}
+	return r.redisClient.Set(redisMonthlyAppearancesKey(apps.CharacterSlug), r.serializer.Serialize(apps.Aggregates), 0).Err()
+}
+
+// Delete deletes the character's appearances per month from Redis.
+func (r *RedisAppearancesByMonthsRepository) Delete(slug CharacterSlug) (int64, error) {
+	return r.redisClient.Del(redisMonthlyAppearancesKey(slug)).Result()
+}
+
+// Series gets the series the character appears in the most from all their issues. This isn't very efficient,
+// so you should use the Redis repo instead.
+func (r *PGSeriesRepository) Series(slug CharacterSlug) (CharacterSeries, error) {
+	var series []SeriesAppearances
+	if _, err := r.db.Query(&series, `
+		SELECT
+			i.vendor_series_name AS name,
+			count(ci.id) AS issue_count,
+			count(ci.id) FILTER (WHERE ci.appearance_type & B'00000001' > 0::BIT(8)) AS main,
+			count(ci.id) FILTER (WHERE ci.appearance_type & B'00000010' > 0::BIT(8)) AS alternate,
+			min(date_part('year', i.sale_date))::INT AS first_year,
+			max(date_part('year', i.sale_date))::INT AS last_year
+		FROM character_issues ci
+		INNER JOIN issues i ON i.id = ci.issue_id
+		INNER JOIN characters c ON c.id = ci.character_id
+		WHERE c.slug = ?
+		GROUP BY i.vendor_series_name
+		ORDER BY issue_count DESC, name`, slug); err != nil {
+		return CharacterSeries{}, err
+	}
+	return NewCharacterSeries(slug, series, seriesLimit), nil
+}
+
+// Series gets the character's series from Redis. The list of series is empty if they haven't been synced.
+func (r *RedisSeriesRepository) Series(slug CharacterSlug) (CharacterSeries, error) {
+	cached, err := r.r.Get(redisSeriesKey(slug)).Result()
+	if err != nil && err != redis.Nil {
+		return CharacterSeries{}, err
+	}
+	if cached == "" {
+		metrics.ObserveCache(metrics.CacheSeries, 0, 1)
+		return NewCharacterSeries(slug, nil, seriesLimit), nil
+	}
+	metrics.ObserveCache(metrics.CacheSeries, 1, 0)
+	series := CharacterSeries{}
+	err = json.Unmarshal([]byte(cached), &series)
+	return series, err
+}
+
+// Set sets the character's series in Redis.
+func (r *RedisSeriesRepository) Set(series CharacterSeries) error {
+	if series.CharacterSlug.Value() == "" {
+		return errors.New("got blank character slug for series")
+	}
+	b, err := json.Marshal(series)
+	if err != nil {
+		return err
+	}
+	return r.r.Set(redisSeriesKey(series.CharacterSlug), b, 0).Err()
+}
+
+// Delete deletes the character's series from Redis.
+func (r *RedisSeriesRepository) Delete(slug CharacterSlug) (int64, error) {
+	return r.r.Del(redisSeriesKey(slug)).Result()
+}
+
+// FormatMix gets the character's format mix from Redis. The format mix is empty if it hasn't been synced.
+func (r *RedisFormatMixRepository) FormatMix(slug CharacterSlug) ([]FormatBreakdown, error) {
+	cached, err := r.r.Get(redisFormatMixKey(slug)).Result()
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	if cached == "" {
+		metrics.ObserveCache(metrics.CacheFormatMix, 0, 1)
+		return NewFormatMix(nil), nil
+	}
+	metrics.ObserveCache(metrics.CacheFormatMix, 1, 0)
+	mix := make([]FormatBreakdown, 0)
+	err = json.Unmarshal([]byte(cached), &mix)
+	return mix, err
+}
+
+// Set sets the character's format mix in Redis.
+func (r *RedisFormatMixRepository) Set(slug CharacterSlug, mix []FormatBreakdown) error {
+	if slug.Value() == "" {
+		return errors.New("got blank character slug for format mix")
+	}
+	b, err := json.Marshal(mix)
+	if err != nil {
+		return err
+	}
+	return r.r.Set(redisFormatMixKey(slug), b, 0).Err()
+}
+
+// Delete deletes the character's format mix from Redis.
+func (r *RedisFormatMixRepository) Delete(slug CharacterSlug) (int64, error) {
+	return r.r.Del(redisFormatMixKey(slug)).Result()
+}
+
 func getAppearanceKey(s CharacterSlug) string {
 	return s.Value() + ":appearances"
 }
 
 // All returns all the popular characters for DC and Marvel.
 func (r *PGPopularRepository) All(cr PopularCriteria) ([]*RankedCharacter, error) {
-	if cr.AppearanceType == Main {
-		return r.query(MainView, cr)
+	table, cat := allTable(cr)
+	if cr.SortBy == MostRelevant {
+		return r.query(table, cat, cr, append(normalizedColumns, relevanceColumns...)...)
 	}
-	if cr.AppearanceType == Alternate {
-		return r.query(AltView, cr)
-	}
-	return r.query(AllView, cr)
+	return r.query(table, cat, cr, normalizedColumns...)
 }
 
-// DC gets the popular characters for DC characters only. The rank will be adjusted for DC.
-func (r *PGPopularRepository) DC(cr PopularCriteria) ([]*RankedCharacter, error) {
-	return r.query(DcMainView, cr)
+// Total gets the total number of ranked characters for the appearance type in the criteria.
+func (r *PGPopularRepository) Total(cr PopularCriteria) (int, error) {
+	table, _ := allTable(cr)
+	return r.count(table)
 }
 
-// Marvel gets the popular characters for Marvel characters only. The rank will be adjusted for Marvel.
-func (r *PGPopularRepository) Marvel(cr PopularCriteria) ([]*RankedCharacter, error) {
-	return r.query(MarvelMainView, cr)
+// PublisherTotal gets the total number of ranked characters for the publisher. The total is 0 if the publisher
+// doesn't exist.
+func (r *PGPopularRepository) PublisherTotal(slug PublisherSlug) (int, error) {
+	p, err := r.publisher(slug)
+	if p == nil || err != nil {
+		return 0, err
+	}
+	return r.count(rankedTable(RankedSQL(Main, p.ID)))
 }
 
-// MarvelTrending gets the trending characters for Marvel.
-func (r *PGPopularRepository) MarvelTrending(limit, offset int) ([]*RankedCharacter, error) {
-	return r.query(MarvelTrendingView, PopularCriteria{
-		AppearanceType: Main,
-		SortBy:         MostIssues,
-		Limit:          limit,
-		Offset:         offset,
-	})
+// Publisher gets the popular characters for the publisher's characters only. The rank will be adjusted for the publisher.
+// The list is empty if the publisher doesn't exist.
+func (r *PGPopularRepository) Publisher(slug PublisherSlug, cr PopularCriteria) ([]*RankedCharacter, error) {
+	p, err := r.publisher(slug)
+	if p == nil || err != nil {
+		return []*RankedCharacter{}, err
+	}
+	if cr.SortBy == MostRelevant {
+		return r.query(rankedTable(RelevanceSQL(Main, p.ID, nil, false, cr.HalfLife)), "main", cr, append(normalizedColumns, relevanceColumns...)...)
+	}
+	return r.query(rankedTable(RankedSQL(Main, p.ID)), "main", cr, normalizedColumns...)
+}
+
+// Gets the publisher for ranking their characters. The publisher is nil if they don't exist.
+func (r *PGPopularRepository) publisher(slug PublisherSlug) (*Publisher, error) {
+	p := &Publisher{}
+	if err := r.db.Model(p).Where("slug = ?", slug).Select(); err != nil {
+		if err == pg.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return p, nil
 }
 
-// DCTrending gets the trending characters for DC.
-func (r *PGPopularRepository) DCTrending(limit, offset int) ([]*RankedCharacter, error) {
-	return r.query(DCTrendingView, PopularCriteria{
+// Trending gets the trending characters for the publisher in the window with how their rank moved since the
+// previous window. The list is empty if the publisher's views haven't been created yet.
+func (r *PGPopularRepository) Trending(slug PublisherSlug, cr TrendingCriteria) ([]*RankedCharacter, error) {
+	w := cr.Window
+	if w == 0 {
+		w = TwelveMonths
+	}
+	characters, err := r.query(PublisherTrendingView(slug, w).Value(), "main", PopularCriteria{
 		AppearanceType: Main,
 		SortBy:         MostIssues,
-		Limit:          limit,
-		Offset:         offset,
-	})
+		Limit:          cr.Limit,
+		Offset:         cr.Offset,
+	}, "previous_issue_count_rank as previous_rank")
+	if isUndefinedTable(err) {
+		return []*RankedCharacter{}, nil
+	}
+	for _, c := range characters {
+		c.Trend = NewRankTrend(c.Stats.IssueCountRank, c.PreviousRank)
+	}
+	return characters, err
 }
 
-func (r *PGPopularRepository) findOneBy(id CharacterID, view MaterializedView) (*RankedCharacter, error) {
+// Finds the ranked character in the table.
+func (r *PGPopularRepository) findOneBy(id CharacterID, table string) (*RankedCharacter, error) {
 	sql := fmt.Sprintf(`SELECT
 		average_per_year_rank as stats__average_rank,
 		average_per_year as stats__average,
 		issue_count as stats__issue_count,
 		issue_count_rank as stats__issue_count_rank,
+		issue_count_percentile as stats__issue_count_percentile,
+		average_per_year_percentile as stats__average_percentile,
+		normalized_popularity as stats__normalized_popularity,
 		id,
 		publisher_id,
 		name,
@@ -853,44 +1699,85 @@ func (r *PGPopularRepository) findOneBy(id CharacterID, view MaterializedView) (
 		publisher__name
 		FROM %s
 		WHERE id = ?
-		`, view)
+		`, table)
 	c := &RankedCharacter{}
 	_, err := r.db.QueryOne(c, sql, id)
 	return c, err
 }
 
-// FindOneByDC finds a ranked character for DC main appearances.
-func (r *PGPopularRepository) FindOneByDC(id CharacterID) (*RankedCharacter, error) {
-	return r.findOneBy(id, DcMainView)
-}
-
-// FindOneByMarvel finds a ranked character for Marvel main appearances.
-func (r *PGPopularRepository) FindOneByMarvel(id CharacterID) (*RankedCharacter, error) {
-	return r.findOneBy(id, MarvelMainView)
+// FindOneByPublisher finds a ranked character for the publisher's main appearances.
+func (r *PGPopularRepository) FindOneByPublisher(slug PublisherSlug, id CharacterID) (*RankedCharacter, error) {
+	p, err := r.publisher(slug)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, pg.ErrNoRows
+	}
+	return r.findOneBy(id, rankedTable(RankedSQL(Main, p.ID)))
 }
 
 // FindOneByAll finds a ranked character for all-time types of appearances.
 func (r *PGPopularRepository) FindOneByAll(id CharacterID) (*RankedCharacter, error) {
-	return r.findOneBy(id, AllView)
+	return r.findOneBy(id, rankedTable(RankedSQL(Main|Alternate, 0)))
+}
+
+// FindOneByWeighted finds a ranked character for all types of appearances weighted by their importance.
+func (r *PGPopularRepository) FindOneByWeighted(id CharacterID) (*RankedCharacter, error) {
+	return r.findOneBy(id, rankedTable(WeightedSQL()))
 }
 
-// Refresh refreshes the specified the materialized view. Note this can take several seconds!
+// Refresh refreshes the specified the materialized view and records when it was refreshed.
+// Note this can take several seconds!
 func (r *PGPopularRepository) Refresh(view MaterializedView) error {
-	_, err := r.db.Exec("REFRESH MATERIALIZED VIEW CONCURRENTLY " + view.Value())
+	if _, err := r.db.Exec("REFRESH MATERIALIZED VIEW CONCURRENTLY " + view.Value()); err != nil {
+		return err
+	}
+	_, err := r.db.Model(&ViewRefresh{View: view, RefreshedAt: time.Now()}).
+		OnConflict("(view) DO UPDATE").
+		Set("refreshed_at = EXCLUDED.refreshed_at").
+		Insert()
 	return err
 }
 
-// RefreshAll refreshes all the materialized views in a transaction. Note this can take a while, so refreshing is done concurrently
-// for all tables!
+// LastRefreshed gets the last time any of the materialized views were refreshed.
+// Returns a zero time if they haven't been refreshed yet.
+func (r *PGPopularRepository) LastRefreshed() (time.Time, error) {
+	var t pg.NullTime
+	_, err := r.db.QueryOne(pg.Scan(&t), "SELECT max(refreshed_at) FROM view_refreshes")
+	return t.Time, err
+}
+
+// CreatePublisherViews creates the trending materialized views for the publisher if they don't exist yet.
+func (r *PGPopularRepository) CreatePublisherViews(p *Publisher) error {
+	var sqls []string
+	for _, w := range TrendingWindows {
+		trendingView := PublisherTrendingView(p.Slug, w)
+		sqls = append(sqls, TrendingViewSQL(trendingView, p.ID, w), ViewIndexSQL(trendingView))
+	}
+	for _, sql := range sqls {
+		if _, err := r.db.Exec(sql); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RefreshAll refreshes the co-appearance and trending materialized views. Note this can take a while, so refreshing is
+// done concurrently for all tables! The views for any new publishers get created first. The ranks don't need refreshing.
 func (r *PGPopularRepository) RefreshAll() error {
-	allViews := []MaterializedView{
-		AllView,
-		MainView,
-		AltView,
-		DcMainView,
-		MarvelMainView,
-		MarvelTrendingView,
-		MarvelMainView,
+	var publishers []*Publisher
+	if err := r.db.Model(&publishers).Select(); err != nil {
+		return err
+	}
+	allViews := []MaterializedView{CoAppearanceView}
+	for _, p := range publishers {
+		if err := r.CreatePublisherViews(p); err != nil {
+			return err
+		}
+		for _, w := range TrendingWindows {
+			allViews = append(allViews, PublisherTrendingView(p.Slug, w))
+		}
 	}
 	var wg sync.WaitGroup
 	wg.Add(len(allViews))
@@ -921,15 +1808,54 @@ func (r *PGPopularRepository) RefreshAll() error {
 	return nil
 }
 
-// Generates the SQL for the materialized view table.
-func (r *PGPopularRepository) sql(table MaterializedView, sort PopularSortCriteria) string {
+// Gets the table of ranked characters for all publishers and the category of their stats. The ranks are computed
+// from the characters' counts per year, which also works for any range of years and half-life.
+func allTable(cr PopularCriteria) (string, string) {
+	t := cr.AppearanceType
 	cat := "main"
-	if table == AllView {
+	if t != Main && t != Alternate {
+		t = Main | Alternate
 		cat = "all_time"
 	}
-	if table == AltView {
+	if t == Alternate {
 		cat = "alternate"
 	}
+	if cr.Weighted {
+		cat = string(WeightedStats)
+	}
+	if cr.SortBy == MostRelevant {
+		return rankedTable(RelevanceSQL(t, 0, cr.Years, cr.Weighted, cr.HalfLife)), cat
+	}
+	if cr.Years != nil {
+		if cr.Weighted {
+			return rankedTable(WeightedYearsSQL(*cr.Years)), cat
+		}
+		return rankedTable(RankedYearsSQL(t, *cr.Years)), cat
+	}
+	if cr.Weighted {
+		return rankedTable(WeightedSQL()), cat
+	}
+	return rankedTable(RankedSQL(t, 0)), cat
+}
+
+// Wraps the SQL for ranking the characters so it can be queried like a table.
+func rankedTable(sql string) string {
+	return "(" + sql + ") AS ranked"
+}
+
+// Generates the SQL for the table of ranked characters. The characters are ordered by the rank and then their ID so that
+// a cursor from the criteria can seek to the row by `(rank, id)` instead of scanning past an offset.
+// When the criteria has a cursor, `?2` and `?3` are its rank and ID. Any extra columns the table has get selected, too.
+func (r *PGPopularRepository) sql(table, cat string, cr PopularCriteria, columns ...string) string {
+	sort := string(cr.SortBy)
+	where := ""
+	order := "ASC"
+	if cr.After != nil {
+		where = fmt.Sprintf("WHERE (%s, id) > (?2, ?3)", sort)
+	} else if cr.Before != nil {
+		where = fmt.Sprintf("WHERE (%s, id) < (?2, ?3)", sort)
+		order = "DESC"
+	}
 	return fmt.Sprintf(`SELECT 
 			average_per_year_rank as stats__average_rank, 
 			average_per_year as stats__average,
@@ -948,19 +1874,30 @@ func (r *PGPopularRepository) sql(table MaterializedView, sort PopularSortCriter
 			vendor_description,
 			publisher__id,
 			publisher__slug,
-			publisher__name
+			publisher__name%s
 		FROM %s
-		ORDER BY %s ASC
-		LIMIT ?0 OFFSET ?1`, cat, table.Value(), string(sort))
+		%s
+		ORDER BY %s %s, id %s
+		LIMIT ?0 OFFSET ?1`, cat, extraColumns(columns), table, where, sort, order, order)
 }
 
 // queries the database for the table and criteria.
-func (r *PGPopularRepository) query(table MaterializedView, cr PopularCriteria) ([]*RankedCharacter, error) {
+func (r *PGPopularRepository) query(table, cat string, cr PopularCriteria, columns ...string) ([]*RankedCharacter, error) {
 	var characters []*RankedCharacter
-	_, err := r.db.Query(&characters, r.sql(table, cr.SortBy), cr.Limit, cr.Offset)
+	params := []interface{}{cr.Limit, cr.Offset}
+	if cursor := cursor(cr); cursor != nil {
+		params = []interface{}{cr.Limit, 0, cursor.Rank, cursor.ID}
+	}
+	_, err := r.db.Query(&characters, r.sql(table, cat, cr, columns...), params...)
 	if err != nil {
 		return nil, err
 	}
+	// seeking before the cursor gets the characters in reverse.
+	if cr.After == nil && cr.Before != nil {
+		for i, j := 0, len(characters)-1; i < j; i, j = i+1, j-1 {
+			characters[i], characters[j] = characters[j], characters[i]
+		}
+	}
 	slugs := make([]CharacterSlug, len(characters))
 	for i, c := range characters {
 		slugs[i] = c.Slug
@@ -978,6 +1915,36 @@ func (r *PGPopularRepository) query(table MaterializedView, cr PopularCriteria)
 	return characters, err
 }
 
+// Counts the characters in the table.
+func (r *PGPopularRepository) count(table string) (int, error) {
+	var total int
+	_, err := r.db.QueryOne(pg.Scan(&total), fmt.Sprintf("SELECT count(*) FROM %s", table))
+	return total, err
+}
+
+// Checks if the error is from querying a table or view that doesn't exist, like the views for a publisher
+// that was added since the views were last refreshed.
+func isUndefinedTable(err error) bool {
+	pgErr, ok := err.(pg.Error)
+	return ok && pgErr.Field('C') == "42P01"
+}
+
+// Joins the extra columns to add to the end of a select.
+func extraColumns(columns []string) string {
+	if len(columns) == 0 {
+		return ""
+	}
+	return ",\n\t\t\t" + strings.Join(columns, ",\n\t\t\t")
+}
+
+// Gets the cursor to seek from. The `after` cursor wins if both are set.
+func cursor(cr PopularCriteria) *RankCursor {
+	if cr.After != nil {
+		return cr.After
+	}
+	return cr.Before
+}
+
 // NewPGAppearancesPerYearRepository creates the new appearances by year repository for postgres.
 func NewPGAppearancesPerYearRepository(db ORM) *PGAppearancesByYearsRepository {
 	return &PGAppearancesByYearsRepository{
@@ -990,6 +1957,76 @@ func NewRedisAppearancesPerYearRepository(client RedisClient) *RedisAppearancesB
 	return &RedisAppearancesByYearsRepository{redisClient: client, deserializer: &RedisYearlyAggregateDeserializer{}, serializer: &RedisYearlyAggregateSerializer{}}
 }
 
+// NewPGAppearancesByMonthsRepository creates the new appearances per month repository for postgres.
+func NewPGAppearancesByMonthsRepository(db ORM) *PGAppearancesByMonthsRepository {
+	return &PGAppearancesByMonthsRepository{db: db}
+}
+
+// NewRedisAppearancesByMonthsRepository creates the Redis appearances per month repository.
+func NewRedisAppearancesByMonthsRepository(client RedisClient) *RedisAppearancesByMonthsRepository {
+	return &RedisAppearancesByMonthsRepository{
+		redisClient:  client,
+		deserializer: &RedisMonthlyAggregateDeserializer{},
+		serializer:   &RedisMonthlyAggregateSerializer{},
+	}
+}
+
+// NewPGSeriesRepository creates a new series repository for the postgres implementation.
+func NewPGSeriesRepository(db ORM) *PGSeriesRepository {
+	return &PGSeriesRepository{db: db}
+}
+
+// NewRedisSeriesRepository creates a new series repository for the Redis implementation.
+func NewRedisSeriesRepository(r RedisClient) *RedisSeriesRepository {
+	return &RedisSeriesRepository{r: r}
+}
+
+// NewRedisFormatMixRepository creates a new format mix repository for the Redis implementation.
+func NewRedisFormatMixRepository(r RedisClient) *RedisFormatMixRepository {
+	return &RedisFormatMixRepository{r: r}
+}
+
+// NewRedisStatsRepository creates a new stats repository that caches the stats from the repository in Redis.
+func NewRedisStatsRepository(r RedisClient, repo StatsRepository) *RedisStatsRepository {
+	return &RedisStatsRepository{r: r, repo: repo}
+}
+
+// NewRedisPopularRepository creates a popular repository that caches when the views from the repository
+// were last refreshed in Redis.
+func NewRedisPopularRepository(repo PopularRepository, r RedisClient) *RedisPopularRepository {
+	return &RedisPopularRepository{PopularRepository: repo, r: r}
+}
+
+// NewStatsCacheRefresher creates a refresher that removes the cached stats after the views get refreshed.
+func NewStatsCacheRefresher(refresher PopularRefresher, r RedisClient) *StatsCacheRefresher {
+	return &StatsCacheRefresher{PopularRefresher: refresher, r: r}
+}
+
+// NewPGCareerRepository creates a new career repository for the postgres implementation.
+func NewPGCareerRepository(db ORM) *PGCareerRepository {
+	return &PGCareerRepository{db: db}
+}
+
+// NewPGFormatMixRepository creates a new format mix repository for the postgres implementation.
+func NewPGFormatMixRepository(db ORM) *PGFormatMixRepository {
+	return &PGFormatMixRepository{db: db}
+}
+
+// NewPGCoAppearanceRepository creates a new co-appearance repository for the postgres implementation.
+func NewPGCoAppearanceRepository(db ORM, ctr CharacterThumbRepository) *PGCoAppearanceRepository {
+	return &PGCoAppearanceRepository{db: db, ctr: ctr}
+}
+
+// NewPGRankSnapshotRepository creates a new rank snapshot repository for the postgres implementation.
+func NewPGRankSnapshotRepository(db ORM) *PGRankSnapshotRepository {
+	return &PGRankSnapshotRepository{db: db}
+}
+
+// NewRankSnapshotRefresher creates a refresher that snapshots the ranks after the views get refreshed.
+func NewRankSnapshotRefresher(refresher PopularRefresher, snapshots RankSnapshotRepository) *RankSnapshotRefresher {
+	return &RankSnapshotRefresher{PopularRefresher: refresher, snapshots: snapshots, now: time.Now}
+}
+
 // NewPGStatsRepository creates a new stats repository for the postgres implementation.
 func NewPGStatsRepository(db ORM) *PGStatsRepository {
 	return &PGStatsRepository{db: db}
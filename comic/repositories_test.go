@@ -4,10 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"github.com/comiccruncher/comiccruncher/comic"
+	"github.com/comiccruncher/comiccruncher/internal/metrics"
 	"github.com/comiccruncher/comiccruncher/internal/mocks/comic"
 	"github.com/go-pg/pg/orm"
 	"github.com/go-redis/redis"
 	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"os"
 	"testing"
@@ -40,6 +42,8 @@ func tearDownData() {
 	must(db.Exec("DELETE FROM character_sync_logs"))
 	must(db.Exec("DELETE FROM character_sources"))
 	must(db.Exec("DELETE FROM character_issues"))
+	must(db.Exec("DELETE FROM character_excluded_issues"))
+	must(db.Exec("DELETE FROM character_year_counts"))
 	must(db.Exec("DELETE FROM issues"))
 	must(db.Exec("DELETE FROM characters"))
 	must(db.Exec("DELETE FROM publishers"))
@@ -114,6 +118,7 @@ func setupTestData() {
 		&comic.CharacterIssue{CharacterID: comic.CharacterID(character2.ID), IssueID: comic.IssueID(issue3.ID), AppearanceType: comic.Alternate}); err != nil {
 		panic(err)
 	}
+	must(db.Exec(comic.YearCountsSQL("TRUE")))
 }
 
 func TestPGPublisherRepositoryFindBySlug(t *testing.T) {
@@ -249,6 +254,39 @@ func TestCharacterIssueRepositoryCreateAndFindOneBy(t *testing.T) {
 	assert.Equal(t, issue.ID, nci.IssueID)
 }
 
+func TestPGCharacterIssueRepositoryFindAll(t *testing.T) {
+	cr := comic.NewPGCharacterRepository(testInstance)
+	character, err := cr.FindBySlug("emma-frost-2", true)
+	assert.Nil(t, err)
+
+	r := comic.NewPGCharacterIssueRepository(testInstance)
+	cis, err := r.FindAll(comic.CharacterIssueCriteria{
+		CharacterIDs: []comic.CharacterID{character.ID},
+		SortBy:       comic.SaleDateDesc,
+	})
+	assert.Nil(t, err)
+	assert.Len(t, cis, 3)
+	assert.NotNil(t, cis[0].Issue)
+	assert.Equal(t, 1980, cis[0].Issue.SaleDate.Year())
+	assert.Equal(t, 1979, cis[2].Issue.SaleDate.Year())
+
+	cis, err = r.FindAll(comic.CharacterIssueCriteria{
+		CharacterIDs:   []comic.CharacterID{character.ID},
+		AppearanceType: comic.Alternate,
+		Years:          []int{1980},
+	})
+	assert.Nil(t, err)
+	assert.Len(t, cis, 2)
+
+	isVariant := true
+	cis, err = r.FindAll(comic.CharacterIssueCriteria{
+		CharacterIDs: []comic.CharacterID{character.ID},
+		IsVariant:    &isVariant,
+	})
+	assert.Nil(t, err)
+	assert.Len(t, cis, 0)
+}
+
 func TestPGIssueRepositoryFindByVendorIdReturnsNil(t *testing.T) {
 	r := comic.NewPGIssueRepository(testInstance)
 	result, err := r.FindByVendorID("98332")
@@ -327,6 +365,49 @@ func TestPGCharacterIssueRepositoryCreateAll(t *testing.T) {
 	// Test the ID is loaded.
 	assert.True(t, characterIssues[0].ID > 0)
 	assert.True(t, characterIssues[1].ID > 0)
+
+	var counts []*comic.CharacterYearCount
+	assert.Nil(t, testInstance.Model(&counts).Where("character_id = ?", character.ID).Select())
+	assert.Len(t, counts, 1)
+	assert.Equal(t, time.Now().Year(), counts[0].Year)
+	// the issue from creating a single character issue is counted, too.
+	assert.Equal(t, uint(3), counts[0].Total)
+	assert.Equal(t, uint(1), counts[0].Alternate)
+}
+
+func TestPGCharacterIssueRepositoryYearCounts(t *testing.T) {
+	cr := comic.NewPGCharacterRepository(testInstance)
+	character, err := cr.FindBySlug("emma-frost-2", true)
+	assert.Nil(t, err)
+
+	var counts []*comic.CharacterYearCount
+	assert.Nil(t, testInstance.Model(&counts).Where("character_id = ?", character.ID).Order("year").Select())
+	assert.Len(t, counts, 2)
+	assert.Equal(t, comic.CharacterYearCount{CharacterID: character.ID, Year: 1979, Total: 1, Main: 1, Weighted: 0.5}, *counts[0])
+	assert.Equal(t, comic.CharacterYearCount{CharacterID: character.ID, Year: 1980, Total: 2, Main: 1, Alternate: 2, Weighted: 1}, *counts[1])
+}
+
+func TestPGIssueRepositoryUpdateMovesYearCounts(t *testing.T) {
+	character, err := comic.NewPGCharacterRepository(testInstance).FindBySlug("emma-frost-2", true)
+	assert.Nil(t, err)
+	r := comic.NewPGIssueRepository(testInstance)
+	issue, err := r.FindByVendorID("123")
+	assert.Nil(t, err)
+	saleDate := issue.SaleDate
+	issue.SaleDate = saleDate.AddDate(2, 0, 0)
+	assert.Nil(t, r.Update(issue))
+
+	var counts []*comic.CharacterYearCount
+	assert.Nil(t, testInstance.Model(&counts).Where("character_id = ?", character.ID).Order("year").Select())
+	assert.Len(t, counts, 2)
+	assert.Equal(t, 1980, counts[0].Year)
+	assert.Equal(t, comic.CharacterYearCount{CharacterID: character.ID, Year: 1981, Total: 1, Main: 1, Weighted: 0.5}, *counts[1])
+
+	issue.SaleDate = saleDate
+	assert.Nil(t, r.Update(issue))
+	count, err := testInstance.Model(&comic.CharacterYearCount{}).Where("character_id = ? AND year = 1979", character.ID).Count()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
 }
 
 // Test that the query returns both main and alternate appearances as slices.
@@ -343,6 +424,87 @@ func TestPGAppearanceRepositoryList(t *testing.T) {
 	assert.Len(t, bogus.Aggregates, 0)
 }
 
+func TestPGCareerRepositoryCareer(t *testing.T) {
+	r := comic.NewPGCareerRepository(testInstance)
+	ch, err := comic.NewPGCharacterRepository(testInstance).FindBySlug("emma-frost-2", false)
+	assert.Nil(t, err)
+	career, err := r.Career(ch.ID)
+	assert.Nil(t, err)
+	assert.NotNil(t, career)
+	assert.Equal(t, 1979, career.FirstAppearance.SaleDate.Year())
+	assert.Equal(t, "129", career.FirstAppearance.VendorSeriesNumber)
+	assert.True(t, career.ActiveYears >= 2)
+	assert.True(t, career.LongestStreak >= 2)
+
+	// No appearances.
+	career, err = r.Career(comic.CharacterID(999999))
+	assert.Nil(t, err)
+	assert.Nil(t, career)
+}
+
+func TestPGSeriesRepositorySeries(t *testing.T) {
+	series, err := comic.NewPGSeriesRepository(testInstance).Series("emma-frost-2")
+	assert.Nil(t, err)
+	assert.Equal(t, comic.CharacterSlug("emma-frost-2"), series.CharacterSlug)
+	assert.True(t, series.SeriesCount >= 1)
+	assert.Equal(t, "Uncanny X-Men", series.Series[0].Name)
+	assert.Equal(t, 1979, series.Series[0].FirstYear)
+	assert.True(t, series.Series[0].Main >= 2)
+	assert.True(t, series.Series[0].Alternate >= 2)
+}
+
+func TestPGFormatMixRepositoryFormatMix(t *testing.T) {
+	ch, err := comic.NewPGCharacterRepository(testInstance).FindBySlug("emma-frost-2", false)
+	assert.Nil(t, err)
+	date := time.Date(1980, time.March, 1, 0, 0, 0, 0, time.UTC)
+	variant := &comic.Issue{
+		PublicationDate:    date,
+		SaleDate:           date,
+		Format:             comic.FormatStandard,
+		IsVariant:          true,
+		VendorPublisher:    "Marvel",
+		VendorSeriesName:   "Uncanny X-Men",
+		VendorSeriesNumber: "131",
+		VendorID:           "format-mix-1",
+	}
+	tpb := &comic.Issue{
+		PublicationDate:    date,
+		SaleDate:           date,
+		Format:             comic.FormatTPB,
+		VendorPublisher:    "Marvel",
+		VendorSeriesName:   "Dark Phoenix Saga",
+		VendorSeriesNumber: "1",
+		VendorID:           "format-mix-2",
+	}
+	assert.Nil(t, testInstance.Insert(variant, tpb))
+	defer testInstance.Delete(variant)
+	defer testInstance.Delete(tpb)
+
+	cir := comic.NewPGCharacterIssueRepository(testInstance)
+	excluded := []*comic.CharacterExcludedIssue{
+		comic.NewCharacterExcludedIssue(ch.ID, variant.ID, comic.ExcludedVariant),
+		comic.NewCharacterExcludedIssue(ch.ID, tpb.ID, comic.ExcludedFormat),
+	}
+	assert.Nil(t, cir.CreateExcluded(excluded))
+	// Excluding them again doesn't error.
+	assert.Nil(t, cir.CreateExcluded(excluded))
+	assert.Nil(t, cir.CreateExcluded(nil))
+
+	mix, err := comic.NewPGFormatMixRepository(testInstance).FormatMix(ch.Slug)
+	assert.Nil(t, err)
+	assert.Len(t, mix, 2)
+	assert.Equal(t, comic.FormatStandard, mix[0].Format)
+	assert.Equal(t, 3, mix[0].Counted)
+	assert.Equal(t, 1, mix[0].Excluded[comic.ExcludedVariant])
+	assert.Equal(t, comic.FormatTPB, mix[1].Format)
+	assert.Equal(t, 0, mix[1].Counted)
+	assert.Equal(t, 1, mix[1].Excluded[comic.ExcludedFormat])
+
+	mix, err = comic.NewPGFormatMixRepository(testInstance).FormatMix(comic.CharacterSlug("nobody"))
+	assert.Nil(t, err)
+	assert.Empty(t, mix)
+}
+
 func TestPGStatsRepository_Stats(t *testing.T) {
 	s := comic.NewPGStatsRepository(testInstance)
 	stats, err := s.Stats()
@@ -353,6 +515,8 @@ func TestPGStatsRepository_Stats(t *testing.T) {
 	assert.Equal(t, 6, stats.TotalAppearances)
 	assert.Equal(t, 1979, stats.MinYear)
 	assert.Equal(t, time.Now().Year(), stats.MaxYear)
+	assert.NotEmpty(t, stats.Formats)
+	assert.NotEmpty(t, stats.Decades)
 }
 
 func TestPGCharacterSyncLogRepositoryCreateAndFind(t *testing.T) {
@@ -388,6 +552,187 @@ func TestPGCharacterRepositoryTotal(t *testing.T) {
 func TestPGPopularRepositoryRefreshAll(t *testing.T) {
 	r := comic.NewPopularRefresher(testInstance)
 	assert.Nil(t, r.RefreshAll())
+	last, err := r.LastRefreshed()
+	assert.Nil(t, err)
+	assert.False(t, last.IsZero())
+}
+
+func TestPGPopularRepositoryAllYears(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctr := mock_comic.NewMockCharacterThumbRepository(ctrl)
+	ctr.EXPECT().AllThumbnails(gomock.Any()).AnyTimes().Return(map[comic.CharacterSlug]*comic.CharacterThumbnails{}, nil)
+	r := comic.NewPGPopularRepository(testInstance, ctr)
+	cr := comic.PopularCriteria{
+		SortBy:         comic.AverageIssuesPerYear,
+		AppearanceType: comic.Main,
+		Years:          &comic.YearRange{From: 1961, To: 1985},
+		Limit:          10,
+	}
+	characters, err := r.All(cr)
+	assert.Nil(t, err)
+	total, err := r.Total(cr)
+	assert.Nil(t, err)
+	assert.True(t, len(characters) <= total)
+	for _, c := range characters {
+		assert.Equal(t, comic.MainStats, c.Stats.Category)
+	}
+}
+
+func TestPGPopularRepositoryAllRelevance(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctr := mock_comic.NewMockCharacterThumbRepository(ctrl)
+	ctr.EXPECT().AllThumbnails(gomock.Any()).AnyTimes().Return(map[comic.CharacterSlug]*comic.CharacterThumbnails{}, nil)
+	r := comic.NewPGPopularRepository(testInstance, ctr)
+	cr := comic.PopularCriteria{
+		SortBy:         comic.MostRelevant,
+		AppearanceType: comic.Main | comic.Alternate,
+		HalfLife:       5,
+		Limit:          10,
+	}
+	characters, err := r.All(cr)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, characters)
+	for _, c := range characters {
+		assert.Equal(t, comic.AllTimeStats, c.Stats.Category)
+		assert.True(t, c.Stats.RelevanceRank > 0)
+	}
+	publisherCharacters, err := r.Publisher("marvel", cr)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, publisherCharacters)
+}
+
+func TestPGPopularRepositoryAllPercentiles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctr := mock_comic.NewMockCharacterThumbRepository(ctrl)
+	ctr.EXPECT().AllThumbnails(gomock.Any()).AnyTimes().Return(map[comic.CharacterSlug]*comic.CharacterThumbnails{}, nil)
+	r := comic.NewPGPopularRepository(testInstance, ctr)
+	characters, err := r.All(comic.PopularCriteria{
+		SortBy:         comic.MostIssues,
+		AppearanceType: comic.Main | comic.Alternate,
+		Limit:          10,
+	})
+	assert.Nil(t, err)
+	assert.NotEmpty(t, characters)
+	for _, c := range characters {
+		assert.True(t, c.Stats.IssueCountPercentile >= 0 && c.Stats.IssueCountPercentile <= 100)
+		assert.True(t, c.Stats.AveragePercentile >= 0 && c.Stats.AveragePercentile <= 100)
+	}
+	assert.True(t, characters[0].Stats.IssueCountPercentile >= characters[len(characters)-1].Stats.IssueCountPercentile)
+	rc, err := r.FindOneByAll(characters[0].ID)
+	assert.Nil(t, err)
+	assert.Equal(t, characters[0].Stats.IssueCountPercentile, rc.Stats.IssueCountPercentile)
+	assert.Equal(t, characters[0].Stats.NormalizedPopularity, rc.Stats.NormalizedPopularity)
+}
+
+func TestPGPopularRepositoryPublisherWithoutViews(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctr := mock_comic.NewMockCharacterThumbRepository(ctrl)
+	r := comic.NewPGPopularRepository(testInstance, ctr)
+	characters, err := r.Publisher("image", comic.PopularCriteria{SortBy: comic.MostIssues, Limit: 10})
+	assert.Nil(t, err)
+	assert.Empty(t, characters)
+	characters, err = r.Trending("image", comic.TrendingCriteria{Window: comic.TwelveMonths, Limit: 10})
+	assert.Nil(t, err)
+	assert.Empty(t, characters)
+	total, err := r.PublisherTotal("image")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, total)
+}
+
+func TestPGCoAppearanceRepository(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctr := mock_comic.NewMockCharacterThumbRepository(ctrl)
+	ctr.EXPECT().AllThumbnails(comic.CharacterSlug("cyclops")).Return(map[comic.CharacterSlug]*comic.CharacterThumbnails{}, nil)
+	db := testInstance
+	emma, err := comic.NewPGCharacterRepository(db).FindBySlug("emma-frost-2", false)
+	assert.Nil(t, err)
+	cyclops := &comic.Character{Name: "Cyclops", Slug: "cyclops", VendorID: "3", PublisherID: emma.PublisherID}
+	must(db.Model(cyclops).Insert())
+	defer func() {
+		// deletes the character issue, too.
+		must(db.Model(cyclops).WherePK().Delete())
+	}()
+	issue, err := comic.NewPGIssueRepository(db).FindByVendorID("123")
+	assert.Nil(t, err)
+	assert.Nil(t, db.Insert(&comic.CharacterIssue{CharacterID: cyclops.ID, IssueID: issue.ID, AppearanceType: comic.Main}))
+	assert.Nil(t, comic.NewPopularRefresher(db).Refresh(comic.CoAppearanceView))
+
+	r := comic.NewPGCoAppearanceRepository(db, ctr)
+	partners, err := r.Partners(emma.ID, comic.CoAppearanceCriteria{Limit: 10})
+	assert.Nil(t, err)
+	assert.Len(t, partners, 1)
+	assert.Equal(t, comic.CharacterSlug("cyclops"), partners[0].Slug)
+	assert.Equal(t, "marvel", partners[0].Publisher.Slug.Value())
+	assert.Equal(t, uint(1), partners[0].IssueCount)
+	// 1 shared issue over the 3 issues either of them are in.
+	assert.Equal(t, 0.3333, partners[0].Affinity)
+
+	g, err := r.Graph(comic.CoAppearanceGraphCriteria{MinIssueCount: 1, Limit: 10})
+	assert.Nil(t, err)
+	assert.Len(t, g.Edges, 1)
+	assert.Len(t, g.Nodes, 2)
+	assert.True(t, g.Edges[0].Source < g.Edges[0].Target)
+
+	g, err = r.Graph(comic.CoAppearanceGraphCriteria{MinIssueCount: 2, Limit: 10})
+	assert.Nil(t, err)
+	assert.Len(t, g.Edges, 0)
+	assert.Len(t, g.Nodes, 0)
+}
+
+func TestPGRankSnapshotRepository(t *testing.T) {
+	r := comic.NewPGRankSnapshotRepository(testInstance)
+	assert.Nil(t, comic.NewPopularRefresher(testInstance).RefreshAll())
+	now := time.Now()
+	assert.Nil(t, r.Snapshot(now))
+	// snapshotting the same day again replaces the day's ranks.
+	assert.Nil(t, r.Snapshot(now))
+	snapshots, err := r.History(1, comic.AllTimeStats)
+	assert.Nil(t, err)
+	assert.True(t, len(snapshots) <= 1)
+	for _, s := range snapshots {
+		assert.Equal(t, comic.AllTimeStats, s.Category)
+	}
+	// the main ranks are within the character's publisher, like their main stats.
+	emma, err := comic.NewPGCharacterRepository(testInstance).FindBySlug("emma-frost-2", false)
+	assert.Nil(t, err)
+	ranked, err := comic.NewPGPopularRepository(testInstance, nil).FindOneByPublisher("marvel", emma.ID)
+	assert.Nil(t, err)
+	main, err := r.History(emma.ID, comic.MainStats)
+	assert.Nil(t, err)
+	assert.Len(t, main, 1)
+	assert.Equal(t, ranked.Stats.IssueCountRank, main[0].IssueCountRank)
+	_, err = r.Prune(now.Add(-24*time.Hour), now.Add(-48*time.Hour))
+	assert.Nil(t, err)
+	pruned, err := r.Prune(now.Add(24*time.Hour), now.Add(24*time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, len(snapshots) > 0, pruned > 0)
+}
+
+func TestRankSnapshotRefresherRefreshAll(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p := mock_comic.NewMockPopularRefresher(ctrl)
+	p.EXPECT().RefreshAll().Return(nil)
+	s := mock_comic.NewMockRankSnapshotRepository(ctrl)
+	s.EXPECT().Snapshot(gomock.Any()).Return(nil)
+	s.EXPECT().Prune(gomock.Any(), gomock.Any()).Return(10, nil)
+	assert.Nil(t, comic.NewRankSnapshotRefresher(p, s).RefreshAll())
+}
+
+func TestRankSnapshotRefresherRefreshAllError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p := mock_comic.NewMockPopularRefresher(ctrl)
+	p.EXPECT().RefreshAll().Return(errors.New("error"))
+	s := mock_comic.NewMockRankSnapshotRepository(ctrl)
+	s.EXPECT().Snapshot(gomock.Any()).Times(0)
+	s.EXPECT().Prune(gomock.Any(), gomock.Any()).Times(0)
+	assert.Error(t, comic.NewRankSnapshotRefresher(p, s).RefreshAll())
 }
 
 func TestRedisCharacterThumbRepositoryThumbnails(t *testing.T) {
@@ -458,6 +803,153 @@ func TestNewRedisCharacterThumbRepository(t *testing.T) {
 	assert.NotNil(t, ctr)
 }
 
+func TestRedisSeriesRepositorySeries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	r := mock_comic.NewMockRedisClient(ctrl)
+	r.EXPECT().Get("emma-frost:series").Return(redis.NewStringResult(`{"slug":"emma-frost","issue_count":8,"series_count":1,"series":[{"name":"X-Men","issue_count":8,"share":1}]}`, nil))
+	r.EXPECT().Get("jean-grey:series").Return(redis.NewStringResult("", redis.Nil))
+	repo := comic.NewRedisSeriesRepository(r)
+	series, err := repo.Series("emma-frost")
+	assert.Nil(t, err)
+	assert.Equal(t, 8, series.IssueCount)
+	assert.Equal(t, []comic.SeriesAppearances{{Name: "X-Men", IssueCount: 8, Share: 1}}, series.Series)
+
+	series, err = repo.Series("jean-grey")
+	assert.Nil(t, err)
+	assert.Equal(t, comic.CharacterSlug("jean-grey"), series.CharacterSlug)
+	assert.Len(t, series.Series, 0)
+}
+
+func TestRedisSeriesRepositorySet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	r := mock_comic.NewMockRedisClient(ctrl)
+	r.EXPECT().Set("emma-frost:series", gomock.Any(), time.Duration(0)).Return(redis.NewStatusResult("OK", nil))
+	repo := comic.NewRedisSeriesRepository(r)
+	assert.Nil(t, repo.Set(comic.CharacterSeries{CharacterSlug: "emma-frost"}))
+	assert.Error(t, repo.Set(comic.CharacterSeries{}))
+}
+
+func TestRedisFormatMixRepositoryFormatMix(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	r := mock_comic.NewMockRedisClient(ctrl)
+	r.EXPECT().Get("emma-frost:format_mix").Return(redis.NewStringResult(`[{"format":"standard","counted":8,"excluded":{"variant":2}}]`, nil))
+	r.EXPECT().Get("jean-grey:format_mix").Return(redis.NewStringResult("", redis.Nil))
+	repo := comic.NewRedisFormatMixRepository(r)
+	mix, err := repo.FormatMix("emma-frost")
+	assert.Nil(t, err)
+	assert.Len(t, mix, 1)
+	assert.Equal(t, comic.FormatStandard, mix[0].Format)
+	assert.Equal(t, 8, mix[0].Counted)
+	assert.Equal(t, 2, mix[0].Excluded[comic.ExcludedVariant])
+
+	mix, err = repo.FormatMix("jean-grey")
+	assert.Nil(t, err)
+	assert.NotNil(t, mix)
+	assert.Len(t, mix, 0)
+}
+
+func TestRedisFormatMixRepositorySet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	r := mock_comic.NewMockRedisClient(ctrl)
+	r.EXPECT().Set("emma-frost:format_mix", gomock.Any(), time.Duration(0)).Return(redis.NewStatusResult("OK", nil))
+	repo := comic.NewRedisFormatMixRepository(r)
+	assert.Nil(t, repo.Set("emma-frost", []comic.FormatBreakdown{}))
+	assert.Error(t, repo.Set("", nil))
+}
+
+func TestRedisStatsRepositoryStatsCached(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	r := mock_comic.NewMockRedisClient(ctrl)
+	r.EXPECT().Get("stats:all").Return(redis.NewStringResult(`{"total_issues":8,"formats":[{"format":"standard","total_issues":8}]}`, nil))
+	repo := mock_comic.NewMockStatsRepository(ctrl)
+	repo.EXPECT().Stats().Times(0)
+	globalHits := testutil.ToFloat64(metrics.CacheRequests.WithLabelValues(metrics.CacheGlobalStats, "hit"))
+	characterHits := testutil.ToFloat64(metrics.CacheRequests.WithLabelValues(metrics.CacheStats, "hit"))
+	stats, err := comic.NewRedisStatsRepository(r, repo).Stats()
+	assert.Nil(t, err)
+	// the global stats don't get counted with the characters' stats.
+	assert.Equal(t, globalHits+1, testutil.ToFloat64(metrics.CacheRequests.WithLabelValues(metrics.CacheGlobalStats, "hit")))
+	assert.Equal(t, characterHits, testutil.ToFloat64(metrics.CacheRequests.WithLabelValues(metrics.CacheStats, "hit")))
+	assert.Equal(t, 8, stats.TotalIssues)
+	assert.Equal(t, []comic.FormatStats{{Format: comic.FormatStandard, TotalIssues: 8}}, stats.Formats)
+}
+
+func TestRedisStatsRepositoryStatsNotCached(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	r := mock_comic.NewMockRedisClient(ctrl)
+	r.EXPECT().Get("stats:all").Return(redis.NewStringResult("", redis.Nil))
+	r.EXPECT().Set("stats:all", gomock.Any(), 24*time.Hour).Return(redis.NewStatusResult("OK", nil))
+	repo := mock_comic.NewMockStatsRepository(ctrl)
+	repo.EXPECT().Stats().Return(comic.Stats{TotalIssues: 8}, nil)
+	stats, err := comic.NewRedisStatsRepository(r, repo).Stats()
+	assert.Nil(t, err)
+	assert.Equal(t, 8, stats.TotalIssues)
+}
+
+func TestRedisPopularRepositoryLastRefreshedCached(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	r := mock_comic.NewMockRedisClient(ctrl)
+	r.EXPECT().Get("views:last_refreshed").Return(redis.NewStringResult("2018-10-01T12:00:00Z", nil))
+	repo := mock_comic.NewMockPopularRepository(ctrl)
+	repo.EXPECT().LastRefreshed().Times(0)
+	last, err := comic.NewRedisPopularRepository(repo, r).LastRefreshed()
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2018, time.October, 1, 12, 0, 0, 0, time.UTC), last)
+}
+
+func TestRedisPopularRepositoryLastRefreshedNotCached(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	refreshed := time.Date(2018, time.October, 1, 12, 0, 0, 0, time.UTC)
+	r := mock_comic.NewMockRedisClient(ctrl)
+	r.EXPECT().Get("views:last_refreshed").Return(redis.NewStringResult("", redis.Nil))
+	r.EXPECT().Set("views:last_refreshed", "2018-10-01T12:00:00Z", time.Hour).Return(redis.NewStatusResult("OK", nil))
+	repo := mock_comic.NewMockPopularRepository(ctrl)
+	repo.EXPECT().LastRefreshed().Return(refreshed, nil)
+	last, err := comic.NewRedisPopularRepository(repo, r).LastRefreshed()
+	assert.Nil(t, err)
+	assert.Equal(t, refreshed, last)
+}
+
+func TestRedisPopularRepositoryLastRefreshedError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	r := mock_comic.NewMockRedisClient(ctrl)
+	r.EXPECT().Get("views:last_refreshed").Return(redis.NewStringResult("", redis.Nil))
+	r.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	repo := mock_comic.NewMockPopularRepository(ctrl)
+	repo.EXPECT().LastRefreshed().Return(time.Time{}, errors.New("error"))
+	_, err := comic.NewRedisPopularRepository(repo, r).LastRefreshed()
+	assert.Error(t, err)
+}
+
+func TestStatsCacheRefresherRefreshAll(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p := mock_comic.NewMockPopularRefresher(ctrl)
+	p.EXPECT().RefreshAll().Return(nil)
+	r := mock_comic.NewMockRedisClient(ctrl)
+	r.EXPECT().Del("stats:all", "views:last_refreshed").Return(redis.NewIntResult(2, nil))
+	assert.Nil(t, comic.NewStatsCacheRefresher(p, r).RefreshAll())
+}
+
+func TestStatsCacheRefresherRefreshAllError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p := mock_comic.NewMockPopularRefresher(ctrl)
+	p.EXPECT().RefreshAll().Return(errors.New("error"))
+	r := mock_comic.NewMockRedisClient(ctrl)
+	r.EXPECT().Del(gomock.Any()).Times(0)
+	assert.Error(t, comic.NewStatsCacheRefresher(p, r).RefreshAll())
+}
+
 func TestNewRedisAppearancesPerYearRepository(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/comiccruncher/comiccruncher/imaging"
 	"github.com/comiccruncher/comiccruncher/internal/log"
+	"github.com/comiccruncher/comiccruncher/internal/metrics"
 	"github.com/go-pg/pg"
 	"go.uber.org/zap"
 	"strconv"
@@ -65,6 +66,8 @@ type CharacterServicer interface {
 	Create(character *Character) error
 	// Character gets a character by its slug.
 	Character(slug CharacterSlug) (*Character, error)
+	// CharacterBySlug gets a character by its slug. If `includeIsDisabled` is true, it will include disabled characters.
+	CharacterBySlug(slug CharacterSlug, includeIsDisabled bool) (*Character, error)
 	// Updates a character.
 	Update(character *Character) error
 	// UpdateAll updates all characters
@@ -85,10 +88,14 @@ type CharacterServicer interface {
 	// MustNormalizeSources so that main vs alternate sources are categorized correctly and disables any unnecessary sources.
 	// panics if there's an error.
 	MustNormalizeSources(*Character)
+	// NormalizeSources is the same as MustNormalizeSources but returns an error instead of panicking.
+	NormalizeSources(*Character) error
 	// Source gets a unique source by its character ID and vendor url.
 	Source(id CharacterID, vendorURL string) (*CharacterSource, error)
 	// Sources gets all the sources for a  character.
 	Sources(id CharacterID, vendorType VendorType, isMain *bool) ([]*CharacterSource, error)
+	// AllSources gets all the sources for a character, including disabled sources.
+	AllSources(id CharacterID) ([]*CharacterSource, error)
 	// TotalSources gets the total sources for a character.
 	TotalSources(id CharacterID) (int64, error)
 	// CreateIssueP creates an issue for a character with the parameters.
@@ -101,8 +108,14 @@ type CharacterServicer interface {
 	CreateIssue(issue *CharacterIssue) error
 	// CreateIssues creates multiple issues for a character. // TODO: Autogenerated IDs not returned in struct!!
 	CreateIssues(issues []*CharacterIssue) error
+	// UpdateIssueImportance overrides the character's importance in the issue. A nil importance clears it.
+	UpdateIssueImportance(issue *CharacterIssue, importance *Importance) error
+	// ExcludeIssues records the issues a character is in that don't count as appearances.
+	ExcludeIssues(issues []*CharacterExcludedIssue) error
 	// Issue gets a character issue by its character ID and issue ID
 	Issue(characterID CharacterID, issueID IssueID) (*CharacterIssue, error)
+	// Issues gets the character issues with their issues from the criteria.
+	Issues(cr CharacterIssueCriteria) ([]*CharacterIssue, error)
 	// RemoveIssues removes all the issues w/ the associated character ID.
 	RemoveIssues(ids ...CharacterID) (int, error)
 	// CreateSyncLogP creates a sync log for a character with the parameters.
@@ -115,20 +128,29 @@ type CharacterServicer interface {
 	CreateSyncLog(syncLog *CharacterSyncLog) error
 	// UpdateSyncLog updates a sync log
 	UpdateSyncLog(syncLog *CharacterSyncLog) error
+	// LastSuccessfulSync gets the most recent successful sync log for any character.
+	LastSuccessfulSync() (*CharacterSyncLog, error)
 }
 
 // RankedServicer is the interface for getting ranked and popular characters.
 type RankedServicer interface {
 	AllPopular(cr PopularCriteria) ([]*RankedCharacter, error)
-	DCPopular(cr PopularCriteria) ([]*RankedCharacter, error)
-	MarvelPopular(cr PopularCriteria) ([]*RankedCharacter, error)
-	MarvelTrending(limit, offset int) ([]*RankedCharacter, error)
-	DCTrending(limit, offset int) ([]*RankedCharacter, error)
+	PublisherPopular(slug PublisherSlug, cr PopularCriteria) ([]*RankedCharacter, error)
+	Trending(slug PublisherSlug, cr TrendingCriteria) ([]*RankedCharacter, error)
+	AllPopularTotal(cr PopularCriteria) (int, error)
+	PublisherPopularTotal(slug PublisherSlug) (int, error)
+	LastRefreshed() (time.Time, error)
+	RankHistory(id CharacterID, cat CharacterStatsCategory) ([]*RankSnapshot, error)
+	CoAppearances(id CharacterID, cr CoAppearanceCriteria) ([]*CoAppearance, error)
+	CoAppearanceGraph(cr CoAppearanceGraphCriteria) (*CoAppearanceGraph, error)
 }
 
 // ExpandedServicer is the interface for getting a character with expanded details.
 type ExpandedServicer interface {
 	Character(slug CharacterSlug) (*ExpandedCharacter, error)
+	Compare(slugs ...CharacterSlug) (*Comparison, error)
+	Series(slug CharacterSlug) (*CharacterSeries, error)
+	MonthlyAppearances(slug CharacterSlug) (*AppearancesByMonths, error)
 }
 
 // CharacterThumbServicer is the interface for creating and getting thumbnails for a character.
@@ -140,14 +162,20 @@ type CharacterThumbServicer interface {
 type ExpandedService struct {
 	cr  CharacterRepository
 	ar  AppearancesByYearsRepository
+	amr AppearancesByYearsMapRepository
 	r   RedisClient
 	slr CharacterSyncLogRepository
 	ctr CharacterThumbRepository
+	sr  SeriesRepository
+	fmr FormatMixRepository
+	mar AppearancesByMonthsRepository
 }
 
 // RankedService is the service for getting ranked and popular characters.
 type RankedService struct {
-	popRepo PopularRepository
+	popRepo      PopularRepository
+	snapshotRepo RankSnapshotRepository
+	coRepo       CoAppearanceRepository
 }
 
 // CharacterThumbService is the service for creating and uploading thumbnails for characters.
@@ -234,51 +262,11 @@ func (s *ExpandedService) Character(slug CharacterSlug) (*ExpandedCharacter, err
 	if err != nil {
 		return nil, err
 	}
-	res, err := s.r.HGetAll(fmt.Sprintf("%s:stats", slug.Value())).Result()
+	stats, career, err := s.stats(slug)
 	if err != nil {
 		return nil, err
 	}
-	ec := &ExpandedCharacter{}
-	if len(res) > 0 {
-		atCount, err := parseUint(res["all_time_issue_count"])
-		if err != nil {
-			return nil, err
-		}
-		atRank, err := parseUint(res["all_time_issue_count_rank"])
-		if err != nil {
-			return nil, err
-		}
-		atAvg, err := strconv.ParseFloat(res["all_time_average_per_year"], 64)
-		if err != nil {
-			return nil, err
-		}
-		atAvgRank, err := parseUint(res["all_time_average_per_year_rank"])
-		if err != nil {
-			return nil, err
-		}
-		allTime := NewCharacterStats(AllTimeStats, atRank, atCount, atAvgRank, atAvg)
-		miCount, err := parseUint(res["main_issue_count"])
-		if err != nil {
-			return nil, err
-		}
-		miRank, err := parseUint(res["main_issue_count_rank"])
-		if err != nil {
-			return nil, err
-		}
-		miAvgRank, err := parseUint(res["main_average_per_year_rank"])
-		if err != nil {
-			return nil, err
-		}
-		miAvg, err := strconv.ParseFloat(res["main_average_per_year"], 64)
-		if err != nil {
-			return nil, err
-		}
-		mainStats := NewCharacterStats(MainStats, miRank, miCount, miAvgRank, miAvg)
-		stats := make([]CharacterStats, 2)
-		stats[0] = allTime
-		stats[1] = mainStats
-		ec.Stats = stats
-	}
+	ec := &ExpandedCharacter{Stats: stats, Career: career}
 	apps, err := s.ar.List(slug)
 	if err != nil {
 		return nil, err
@@ -287,6 +275,11 @@ func (s *ExpandedService) Character(slug CharacterSlug) (*ExpandedCharacter, err
 	if err != nil {
 		return nil, err
 	}
+	mix, err := s.fmr.FormatMix(slug)
+	if err != nil {
+		return nil, err
+	}
+	ec.FormatMix = mix
 	ec.Appearances = apps
 	ec.Character = c
 	ec.LastSyncs = sl
@@ -294,31 +287,215 @@ func (s *ExpandedService) Character(slug CharacterSlug) (*ExpandedCharacter, err
 	return ec, nil
 }
 
+// Series gets the series the character appears in the most. Returns nil if the character doesn't exist.
+func (s *ExpandedService) Series(slug CharacterSlug) (*CharacterSeries, error) {
+	c, err := s.cr.FindBySlug(slug, false)
+	if err != nil || c == nil {
+		return nil, err
+	}
+	series, err := s.sr.Series(slug)
+	if err != nil {
+		return nil, err
+	}
+	return &series, nil
+}
+
+// MonthlyAppearances gets the character's appearances per month for their recent years, with the months without
+// appearances filled in up to now. Returns nil if the character doesn't exist.
+func (s *ExpandedService) MonthlyAppearances(slug CharacterSlug) (*AppearancesByMonths, error) {
+	c, err := s.cr.FindBySlug(slug, false)
+	if err != nil || c == nil {
+		return nil, err
+	}
+	apps, err := s.mar.List(slug)
+	if err != nil {
+		return nil, err
+	}
+	return apps.Fill(time.Now()), nil
+}
+
+// Compare gets the characters with their stats and appearances lined up on the same span of years so
+// they can be compared to each other. Characters that don't exist or are disabled are left out.
+func (s *ExpandedService) Compare(slugs ...CharacterSlug) (*Comparison, error) {
+	characters, err := s.cr.FindAll(CharacterCriteria{Slugs: slugs})
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[CharacterSlug]*Character, len(characters))
+	for _, c := range characters {
+		found[c.Slug] = c
+	}
+	// keep the order the caller asked for.
+	ordered := make([]CharacterSlug, 0, len(characters))
+	for _, slug := range slugs {
+		if found[slug] != nil {
+			ordered = append(ordered, slug)
+		}
+	}
+	cmp := &Comparison{Years: []int{}, Characters: make([]*ExpandedCharacter, len(ordered))}
+	if len(ordered) == 0 {
+		return cmp, nil
+	}
+	apps, err := s.amr.ListMap(ordered...)
+	if err != nil {
+		return nil, err
+	}
+	thumbs, err := s.ctr.AllThumbnails(ordered...)
+	if err != nil {
+		return nil, err
+	}
+	minYear, maxYear := 0, 0
+	for _, a := range apps {
+		for _, agg := range a.Aggregates {
+			if minYear == 0 || agg.Year < minYear {
+				minYear = agg.Year
+			}
+			if agg.Year > maxYear {
+				maxYear = agg.Year
+			}
+		}
+	}
+	for y := minYear; minYear > 0 && y <= maxYear; y++ {
+		cmp.Years = append(cmp.Years, y)
+	}
+	for i, slug := range ordered {
+		stats, career, err := s.stats(slug)
+		if err != nil {
+			return nil, err
+		}
+		a := apps[slug]
+		a.CharacterSlug = slug
+		if len(cmp.Years) > 0 {
+			a.Fill(minYear, maxYear)
+		}
+		ec := &ExpandedCharacter{
+			Character:   found[slug],
+			Stats:       stats,
+			Career:      career,
+			Appearances: a,
+		}
+		if th := thumbs[slug]; th != nil && (th.Image != nil || th.VendorImage != nil) {
+			ec.Thumbnails = th
+		}
+		cmp.Characters[i] = ec
+	}
+	return cmp, nil
+}
+
+// stats gets the all-time and main stats and the career stats for the character from Redis.
+// Returns nil if the character's stats haven't been synced yet.
+func (s *ExpandedService) stats(slug CharacterSlug) ([]CharacterStats, *CareerStats, error) {
+	res, err := s.r.HGetAll(fmt.Sprintf("%s:stats", slug.Value())).Result()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(res) == 0 {
+		metrics.ObserveCache(metrics.CacheStats, 0, 1)
+		return nil, nil, nil
+	}
+	metrics.ObserveCache(metrics.CacheStats, 1, 0)
+	atCount, err := parseUint(res["all_time_issue_count"])
+	if err != nil {
+		return nil, nil, err
+	}
+	atRank, err := parseUint(res["all_time_issue_count_rank"])
+	if err != nil {
+		return nil, nil, err
+	}
+	atAvg, err := strconv.ParseFloat(res["all_time_average_per_year"], 64)
+	if err != nil {
+		return nil, nil, err
+	}
+	atAvgRank, err := parseUint(res["all_time_average_per_year_rank"])
+	if err != nil {
+		return nil, nil, err
+	}
+	allTime := NewCharacterStats(AllTimeStats, atRank, atCount, atAvgRank, atAvg)
+	if err := parseRedisNormalizedStats(res, "all_time", &allTime); err != nil {
+		return nil, nil, err
+	}
+	miCount, err := parseUint(res["main_issue_count"])
+	if err != nil {
+		return nil, nil, err
+	}
+	miRank, err := parseUint(res["main_issue_count_rank"])
+	if err != nil {
+		return nil, nil, err
+	}
+	miAvgRank, err := parseUint(res["main_average_per_year_rank"])
+	if err != nil {
+		return nil, nil, err
+	}
+	miAvg, err := strconv.ParseFloat(res["main_average_per_year"], 64)
+	if err != nil {
+		return nil, nil, err
+	}
+	mainStats := NewCharacterStats(MainStats, miRank, miCount, miAvgRank, miAvg)
+	if err := parseRedisNormalizedStats(res, "main", &mainStats); err != nil {
+		return nil, nil, err
+	}
+	stats := make([]CharacterStats, 2)
+	stats[0] = allTime
+	stats[1] = mainStats
+	weighted, err := parseRedisWeightedStats(res)
+	if err != nil {
+		return nil, nil, err
+	}
+	if weighted != nil {
+		stats = append(stats, *weighted)
+	}
+	career, err := parseRedisCareer(res, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	return stats, career, nil
+}
+
 // AllPopular gets the most popular characters per year ordered by either issue count or
 // average appearances per year.
 func (s *RankedService) AllPopular(cr PopularCriteria) ([]*RankedCharacter, error) {
 	return s.popRepo.All(cr)
 }
 
-// DCPopular gets DC's most popular characters per year.
-func (s *RankedService) DCPopular(cr PopularCriteria) ([]*RankedCharacter, error) {
-	return s.popRepo.DC(cr)
+// PublisherPopular gets the publisher's most popular characters ordered by either issue count or average
+// issues per year.
+func (s *RankedService) PublisherPopular(slug PublisherSlug, cr PopularCriteria) ([]*RankedCharacter, error) {
+	return s.popRepo.Publisher(slug, cr)
+}
+
+// AllPopularTotal gets the total number of ranked characters for the criteria's appearance type.
+func (s *RankedService) AllPopularTotal(cr PopularCriteria) (int, error) {
+	return s.popRepo.Total(cr)
+}
+
+// PublisherPopularTotal gets the total number of the publisher's ranked characters.
+func (s *RankedService) PublisherPopularTotal(slug PublisherSlug) (int, error) {
+	return s.popRepo.PublisherTotal(slug)
+}
+
+// RankHistory gets the character's rank snapshots for the category, oldest first.
+func (s *RankedService) RankHistory(id CharacterID, cat CharacterStatsCategory) ([]*RankSnapshot, error) {
+	return s.snapshotRepo.History(id, cat)
+}
+
+// CoAppearances gets the characters who appear in the same issues as the character.
+func (s *RankedService) CoAppearances(id CharacterID, cr CoAppearanceCriteria) ([]*CoAppearance, error) {
+	return s.coRepo.Partners(id, cr)
 }
 
-// MarvelPopular gets Marvel's most popular characters per year ordered by either issue count o
-// or average appearances per year.
-func (s *RankedService) MarvelPopular(cr PopularCriteria) ([]*RankedCharacter, error) {
-	return s.popRepo.Marvel(cr)
+// CoAppearanceGraph gets the graph of the characters who appear together the most.
+func (s *RankedService) CoAppearanceGraph(cr CoAppearanceGraphCriteria) (*CoAppearanceGraph, error) {
+	return s.coRepo.Graph(cr)
 }
 
-// MarvelTrending gets the trending characters for marvel.
-func (s *RankedService) MarvelTrending(limit, offset int) ([]*RankedCharacter, error) {
-	return s.popRepo.MarvelTrending(limit, offset)
+// Trending gets the trending characters for the publisher in the criteria's window.
+func (s *RankedService) Trending(slug PublisherSlug, cr TrendingCriteria) ([]*RankedCharacter, error) {
+	return s.popRepo.Trending(slug, cr)
 }
 
-// DCTrending gets the trending characters for marvel.
-func (s *RankedService) DCTrending(limit, offset int) ([]*RankedCharacter, error) {
-	return s.popRepo.DCTrending(limit, offset)
+// LastRefreshed gets the last time the rankings were refreshed.
+func (s *RankedService) LastRefreshed() (time.Time, error) {
+	return s.popRepo.LastRefreshed()
 }
 
 // PublisherService is the service for publishers.
@@ -397,6 +574,11 @@ func (s *CharacterService) Character(slug CharacterSlug) (*Character, error) {
 	return s.repository.FindBySlug(slug, false)
 }
 
+// CharacterBySlug gets a character by its slug and whether the character is disabled or not.
+func (s *CharacterService) CharacterBySlug(slug CharacterSlug, includeIsDisabled bool) (*Character, error) {
+	return s.repository.FindBySlug(slug, includeIsDisabled)
+}
+
 // Update updates a character.
 func (s *CharacterService) Update(c *Character) error {
 	return s.repository.Update(c)
@@ -472,8 +654,22 @@ func (s *CharacterService) Sources(id CharacterID, vendorType VendorType, isMain
 	})
 }
 
+// AllSources lists all the character sources for the character, including disabled sources.
+func (s *CharacterService) AllSources(id CharacterID) ([]*CharacterSource, error) {
+	return s.sourceRepository.FindAll(CharacterSourceCriteria{
+		CharacterIDs:      []CharacterID{id},
+		IncludeIsDisabled: true,
+	})
+}
+
 // MustNormalizeSources normalizes sources for main and alternate sources and disables any unneeded sources.
+// Panics if there's an error.
 func (s *CharacterService) MustNormalizeSources(c *Character) {
+	must(s.NormalizeSources(c))
+}
+
+// NormalizeSources normalizes sources for main and alternate sources and disables any unneeded sources.
+func (s *CharacterService) NormalizeSources(c *Character) error {
 	id := c.ID.Value()
 	var altUniverses []universeDefinition
 	var disabledUniverses []universeDefinition
@@ -484,23 +680,30 @@ func (s *CharacterService) MustNormalizeSources(c *Character) {
 		altUniverses = dcAltUniverses
 		disabledUniverses = dcDisabledUniverses
 	} else {
-		panic(fmt.Sprintf("unknown publisher: %s", c.Publisher.Slug.Value()))
+		return fmt.Errorf("unknown publisher: %s", c.Publisher.Slug.Value())
 	}
 	// todo: better to run all this in a transaction.
 	// disable clones, impostors, etc.
 	if !ignoreIDsForDisabled[id] {
-		must(s.sourceRepository.Raw(fmt.Sprintf(disableSourcesSQL, pgSearchString(disabledUniverses)), id))
+		if err := s.sourceRepository.Raw(fmt.Sprintf(disableSourcesSQL, pgSearchString(disabledUniverses)), id); err != nil {
+			return err
+		}
 	}
 	// set the main universes from alt universes.
-	must(s.sourceRepository.Raw(fmt.Sprintf(mainSourcesSQL, pgSearchString(altUniverses)), id))
+	if err := s.sourceRepository.Raw(fmt.Sprintf(mainSourcesSQL, pgSearchString(altUniverses)), id); err != nil {
+		return err
+	}
 	// now set the alternate sources from alternate sources.
 	// b/c if we add any more sources after running the above query, we
 	// won't be able to set is_main = false for any of them. sooo stupid and i'm sure there's a better way to do this but whatever.
-	must(s.sourceRepository.Raw(fmt.Sprintf(altSourcesSQL, pgSearchString(altUniverses)), id))
+	if err := s.sourceRepository.Raw(fmt.Sprintf(altSourcesSQL, pgSearchString(altUniverses)), id); err != nil {
+		return err
+	}
 	// Now make sure earth-616 is set as main. (Some sources have 616 .. some don't. :( )
 	if c.Publisher.Slug == "marvel" {
-		must(s.sourceRepository.Raw("UPDATE character_sources SET is_main = TRUE WHERE vendor_name ILIKE '%earth-616)%' AND character_id = ?", id))
+		return s.sourceRepository.Raw("UPDATE character_sources SET is_main = TRUE WHERE vendor_name ILIKE '%earth-616)%' AND character_id = ?", id)
 	}
+	return nil
 }
 
 // TotalSources gets the total number of sources for a character
@@ -530,11 +733,28 @@ func (s *CharacterService) CreateIssues(issues []*CharacterIssue) error {
 	return s.issueRepository.InsertFast(issues)
 }
 
+// UpdateIssueImportance overrides the character's importance in the issue. A nil importance clears it, so the
+// importer sets it again on the next import.
+func (s *CharacterService) UpdateIssueImportance(issue *CharacterIssue, importance *Importance) error {
+	issue.Importance = importance
+	return s.issueRepository.UpdateImportance(issue)
+}
+
+// ExcludeIssues records the issues a character is in that don't count as appearances.
+func (s *CharacterService) ExcludeIssues(issues []*CharacterExcludedIssue) error {
+	return s.issueRepository.CreateExcluded(issues)
+}
+
 // Issue gets a character issue by its character ID and issue ID.
 func (s *CharacterService) Issue(characterID CharacterID, issueID IssueID) (*CharacterIssue, error) {
 	return s.issueRepository.FindOneBy(characterID, issueID)
 }
 
+// Issues gets the character issues with their issues from the criteria. A `Limit` of `0` means unlimited.
+func (s *CharacterService) Issues(cr CharacterIssueCriteria) ([]*CharacterIssue, error) {
+	return s.issueRepository.FindAll(cr)
+}
+
 // CreateSyncLogP creates a sync log with the parameters.
 func (s *CharacterService) CreateSyncLogP(id CharacterID, status CharacterSyncLogStatus, syncType CharacterSyncLogType, syncedAt *time.Time) (*CharacterSyncLog, error) {
 	syncLog := &CharacterSyncLog{
@@ -557,6 +777,11 @@ func (s *CharacterService) UpdateSyncLog(syncLog *CharacterSyncLog) error {
 	return s.syncLogRepository.Update(syncLog)
 }
 
+// LastSuccessfulSync gets the most recent successful sync log for any character.
+func (s *CharacterService) LastSuccessfulSync() (*CharacterSyncLog, error) {
+	return s.syncLogRepository.LastSuccess()
+}
+
 // CharacterByVendor gets a character from the specified vendor and whether the character is disabled or not.
 func (s *CharacterService) CharacterByVendor(vendorID string, vendorType VendorType, includeIsDisabled bool) (*Character, error) {
 	characters, err := s.repository.FindAll(CharacterCriteria{
@@ -658,24 +883,32 @@ func NewIssueService(repository IssueRepository) *IssueService {
 
 // NewRankedServiceFactory creates a new service for ranked characters.
 func NewRankedServiceFactory(db ORM, r RedisClient) *RankedService {
-	return NewRankedService(NewPGPopularRepository(db, NewRedisCharacterThumbRepository(r)))
+	ctr := NewRedisCharacterThumbRepository(r)
+	return NewRankedService(NewRedisPopularRepository(NewPGPopularRepository(db, ctr), r), NewPGRankSnapshotRepository(db), NewPGCoAppearanceRepository(db, ctr))
 }
 
 // NewRankedService creates a new service.
-func NewRankedService(p PopularRepository) *RankedService {
+func NewRankedService(p PopularRepository, s RankSnapshotRepository, co CoAppearanceRepository) *RankedService {
 	return &RankedService{
-		popRepo: p,
+		popRepo:      p,
+		snapshotRepo: s,
+		coRepo:       co,
 	}
 }
 
 // NewExpandedServiceFactory creates a new service for getting expanded details for a character
 func NewExpandedServiceFactory(db ORM, r RedisClient) *ExpandedService {
+	ar := NewRedisAppearancesPerYearRepository(r)
 	return NewExpandedService(
 		NewPGCharacterRepository(db),
-		NewRedisAppearancesPerYearRepository(r),
+		ar,
+		ar,
 		r,
 		NewPGCharacterSyncLogRepository(db),
 		NewRedisCharacterThumbRepository(r),
+		NewRedisSeriesRepository(r),
+		NewRedisFormatMixRepository(r),
+		NewRedisAppearancesByMonthsRepository(r),
 	)
 }
 
@@ -683,15 +916,23 @@ func NewExpandedServiceFactory(db ORM, r RedisClient) *ExpandedService {
 func NewExpandedService(
 	cr CharacterRepository,
 	ar AppearancesByYearsRepository,
+	amr AppearancesByYearsMapRepository,
 	r RedisClient,
 	slr CharacterSyncLogRepository,
-	ctr CharacterThumbRepository) *ExpandedService {
+	ctr CharacterThumbRepository,
+	sr SeriesRepository,
+	fmr FormatMixRepository,
+	mar AppearancesByMonthsRepository) *ExpandedService {
 	return &ExpandedService{
 		cr:  cr,
 		ar:  ar,
+		amr: amr,
 		r:   r,
 		slr: slr,
 		ctr: ctr,
+		sr:  sr,
+		fmr: fmr,
+		mar: mar,
 	}
 }
 
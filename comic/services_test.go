@@ -39,6 +39,7 @@ func TestExpandedServiceCharacter(t *testing.T) {
 	cr := mock_comic.NewMockCharacterRepository(ctrl)
 	cr.EXPECT().FindBySlug(gomock.Any(), false).Times(1).Return(ch, nil)
 	ar := mock_comic.NewMockAppearancesByYearsRepository(ctrl)
+	amr := mock_comic.NewMockAppearancesByYearsMapRepository(ctrl)
 	ar.EXPECT().List(gomock.Any()).Return(comic.AppearancesByYears{
 		Aggregates: []comic.YearlyAggregate{
 			{Main: 10, Year: 1979},
@@ -54,6 +55,26 @@ func TestExpandedServiceCharacter(t *testing.T) {
 	val["main_issue_count_rank"] = "4"
 	val["main_average_per_year_rank"] = "5"
 	val["main_average_per_year"] = "60.23"
+	val["weighted_issue_count_rank"] = "3"
+	val["weighted_issue_count"] = "150"
+	val["weighted_average_per_year_rank"] = "6"
+	val["weighted_average_per_year"] = "30.5"
+	val["all_time_issue_count_percentile"] = "99.5"
+	val["all_time_average_per_year_percentile"] = "98.25"
+	val["all_time_normalized_popularity"] = "2.31"
+	val["main_issue_count_percentile"] = "97"
+	val["weighted_normalized_popularity"] = "-0.5"
+	val["career_first_appearance_date"] = "1980-01-01"
+	val["career_first_appearance_series_name"] = "X-Men"
+	val["career_first_appearance_series_number"] = "129"
+	val["career_latest_appearance_date"] = "2018-06-01"
+	val["career_latest_appearance_series_name"] = "X-Men Red"
+	val["career_latest_appearance_series_number"] = "5"
+	val["career_peak_year"] = "2011"
+	val["career_peak_year_count"] = "42"
+	val["career_longest_streak"] = "20"
+	val["career_longest_streak_start"] = "1998"
+	val["career_active_years"] = "35"
 	cmd := redis.NewStringStringMapResult(val, nil)
 	rc.EXPECT().HGetAll(fmt.Sprintf("%s:stats", ch.Slug)).Times(1).Return(cmd)
 
@@ -82,29 +103,57 @@ func TestExpandedServiceCharacter(t *testing.T) {
 			Large:  "f",
 		},
 	}, nil)
-	svc := comic.NewExpandedService(cr, ar, rc, slr, ctr)
+	fmr := mock_comic.NewMockFormatMixRepository(ctrl)
+	fmr.EXPECT().FormatMix(gomock.Any()).Return([]comic.FormatBreakdown{
+		{Format: comic.FormatStandard, Counted: 90, Excluded: map[comic.ExclusionReason]int{comic.ExcludedVariant: 12}},
+	}, nil)
+	svc := comic.NewExpandedService(cr, ar, amr, rc, slr, ctr, nil, fmr, nil)
 	ec, err := svc.Character(slug)
 	at := ec.Stats[0]
 	m := ec.Stats[1]
 	assert.Nil(t, err)
 	assert.NotNil(t, ec.Appearances)
-	assert.Len(t, ec.Stats, 2)
+	assert.Len(t, ec.Stats, 3)
 	assert.Equal(t, at.Category, comic.AllTimeStats)
 	assert.Equal(t, at.IssueCount, uint(100))
 	assert.Equal(t, at.IssueCountRank, uint(1))
 	assert.Equal(t, at.Average, float64(20.23))
 	assert.Equal(t, at.AverageRank, uint(2))
+	assert.Equal(t, 99.5, at.IssueCountPercentile)
+	assert.Equal(t, 98.25, at.AveragePercentile)
+	assert.Equal(t, 2.31, at.NormalizedPopularity)
 	assert.Equal(t, m.Category, comic.MainStats)
 	assert.Equal(t, m.IssueCount, uint(300))
 	assert.Equal(t, m.IssueCountRank, uint(4))
 	assert.Equal(t, m.Average, float64(60.23))
 	assert.Equal(t, m.AverageRank, uint(5))
+	assert.Equal(t, float64(97), m.IssueCountPercentile)
+	assert.Zero(t, m.NormalizedPopularity)
+	w := ec.Stats[2]
+	assert.Equal(t, comic.WeightedStats, w.Category)
+	assert.Equal(t, uint(150), w.IssueCount)
+	assert.Equal(t, uint(3), w.IssueCountRank)
+	assert.Equal(t, 30.5, w.Average)
+	assert.Equal(t, -0.5, w.NormalizedPopularity)
 	assert.NotNil(t, ec.LastSyncs)
 	assert.Len(t, ec.LastSyncs, 1)
 	assert.Equal(t, ec.LastSyncs[0].NumIssues, 10)
 	assert.Equal(t, ec.LastSyncs[0].SyncedAt, tm)
 	assert.Equal(t, ec.LastSyncs[0].CharacterID, comic.CharacterID(1))
 	assert.NotNil(t, ec.Thumbnails)
+	assert.NotNil(t, ec.Career)
+	assert.Equal(t, "X-Men", ec.Career.FirstAppearance.VendorSeriesName)
+	assert.Equal(t, "129", ec.Career.FirstAppearance.VendorSeriesNumber)
+	assert.Equal(t, 1980, ec.Career.FirstAppearance.SaleDate.Year())
+	assert.Equal(t, 2018, ec.Career.LatestAppearance.SaleDate.Year())
+	assert.Equal(t, 2011, ec.Career.PeakYear)
+	assert.Equal(t, 42, ec.Career.PeakYearCount)
+	assert.Equal(t, 20, ec.Career.LongestStreak)
+	assert.Equal(t, 1998, ec.Career.LongestStreakStart)
+	assert.Equal(t, 35, ec.Career.ActiveYears)
+	assert.Len(t, ec.FormatMix, 1)
+	assert.Equal(t, 90, ec.FormatMix[0].Counted)
+	assert.Equal(t, 12, ec.FormatMix[0].Excluded[comic.ExcludedVariant])
 }
 
 func TestExpandedServiceCharacterNoResult(t *testing.T) {
@@ -114,6 +163,7 @@ func TestExpandedServiceCharacterNoResult(t *testing.T) {
 	cr := mock_comic.NewMockCharacterRepository(ctrl)
 	cr.EXPECT().FindBySlug(gomock.Any(), false).Times(1).Return(nil, nil)
 	ar := mock_comic.NewMockAppearancesByYearsRepository(ctrl)
+	amr := mock_comic.NewMockAppearancesByYearsMapRepository(ctrl)
 	ar.EXPECT().List(gomock.Any()).Times(0)
 	rc := mock_comic.NewMockRedisClient(ctrl)
 	val := make(map[string]string, 0)
@@ -122,12 +172,53 @@ func TestExpandedServiceCharacterNoResult(t *testing.T) {
 	slr := mock_comic.NewMockCharacterSyncLogRepository(ctrl)
 	slr.EXPECT().LastSyncs(gomock.Any()).Times(0)
 	ctr := mock_comic.NewMockCharacterThumbRepository(ctrl)
-	svc := comic.NewExpandedService(cr, ar, rc, slr, ctr)
+	svc := comic.NewExpandedService(cr, ar, amr, rc, slr, ctr, nil, nil, nil)
 	ec, err := svc.Character(comic.CharacterSlug("emma-frost"))
 	assert.Nil(t, err)
 	assert.Nil(t, ec)
 }
 
+func TestExpandedServiceSeries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cr := mock_comic.NewMockCharacterRepository(ctrl)
+	cr.EXPECT().FindBySlug(comic.CharacterSlug("emma-frost"), false).Return(&comic.Character{Slug: "emma-frost"}, nil)
+	cr.EXPECT().FindBySlug(comic.CharacterSlug("nope"), false).Return(nil, nil)
+	sr := mock_comic.NewMockSeriesRepository(ctrl)
+	sr.EXPECT().Series(comic.CharacterSlug("emma-frost")).Times(1).Return(comic.CharacterSeries{CharacterSlug: "emma-frost", IssueCount: 10}, nil)
+	svc := comic.NewExpandedService(cr, nil, nil, nil, nil, nil, sr, nil, nil)
+	series, err := svc.Series("emma-frost")
+	assert.Nil(t, err)
+	assert.Equal(t, 10, series.IssueCount)
+
+	series, err = svc.Series("nope")
+	assert.Nil(t, err)
+	assert.Nil(t, series)
+}
+
+func TestExpandedServiceMonthlyAppearances(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cr := mock_comic.NewMockCharacterRepository(ctrl)
+	cr.EXPECT().FindBySlug(comic.CharacterSlug("emma-frost"), false).Return(&comic.Character{Slug: "emma-frost"}, nil)
+	cr.EXPECT().FindBySlug(comic.CharacterSlug("nope"), false).Return(nil, nil)
+	mar := mock_comic.NewMockAppearancesByMonthsRepository(ctrl)
+	now := time.Now()
+	mar.EXPECT().List(comic.CharacterSlug("emma-frost")).Times(1).Return(comic.NewAppearancesByMonths("emma-frost", []comic.MonthlyAggregate{{Year: now.Year(), Month: 1, Main: 1}}), nil)
+	svc := comic.NewExpandedService(cr, nil, nil, nil, nil, nil, nil, nil, mar)
+	apps, err := svc.MonthlyAppearances("emma-frost")
+	assert.Nil(t, err)
+	// the months since the last appearance get filled in when they're read.
+	assert.Len(t, apps.Aggregates, int(now.Month()))
+	assert.Equal(t, 1, apps.Aggregates[0].Main)
+
+	apps, err = svc.MonthlyAppearances("nope")
+	assert.Nil(t, err)
+	assert.Nil(t, apps)
+}
+
 func TestExpandedServiceCharacterNoRedisResult(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -145,6 +236,7 @@ func TestExpandedServiceCharacterNoRedisResult(t *testing.T) {
 	cr := mock_comic.NewMockCharacterRepository(ctrl)
 	cr.EXPECT().FindBySlug(gomock.Any(), false).Times(1).Return(ch, nil)
 	ar := mock_comic.NewMockAppearancesByYearsRepository(ctrl)
+	amr := mock_comic.NewMockAppearancesByYearsMapRepository(ctrl)
 	ar.EXPECT().List(gomock.Any()).Return(comic.AppearancesByYears{}, nil)
 	rc := mock_comic.NewMockRedisClient(ctrl)
 	val := make(map[string]string, 0)
@@ -154,34 +246,127 @@ func TestExpandedServiceCharacterNoRedisResult(t *testing.T) {
 	slr.EXPECT().LastSyncs(gomock.Any()).Times(1).Return([]*comic.LastSync{}, nil)
 	ctr := mock_comic.NewMockCharacterThumbRepository(ctrl)
 	ctr.EXPECT().Thumbnails(gomock.Any()).Return(nil, nil)
+	fmr := mock_comic.NewMockFormatMixRepository(ctrl)
+	fmr.EXPECT().FormatMix(ch.Slug).Return([]comic.FormatBreakdown{}, nil)
 
-	svc := comic.NewExpandedService(cr, ar, rc, slr, ctr)
+	svc := comic.NewExpandedService(cr, ar, amr, rc, slr, ctr, nil, fmr, nil)
 	ec, err := svc.Character(comic.CharacterSlug("emma-frost"))
 	assert.Nil(t, err)
 	assert.NotNil(t, ec.Appearances)
 	assert.Len(t, ec.Stats, 0)
 }
 
-func TestRankedServiceDCTrending(t *testing.T) {
+func TestExpandedServiceCompare(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
+	c1 := &comic.Character{ID: 1, Slug: "emma-frost"}
+	c2 := &comic.Character{ID: 2, Slug: "jean-grey"}
+	cr := mock_comic.NewMockCharacterRepository(ctrl)
+	cr.EXPECT().FindAll(gomock.Any()).Times(1).Return([]*comic.Character{c2, c1}, nil)
+	ar := mock_comic.NewMockAppearancesByYearsRepository(ctrl)
+	amr := mock_comic.NewMockAppearancesByYearsMapRepository(ctrl)
+	amr.EXPECT().ListMap(c1.Slug, c2.Slug).Times(1).Return(map[comic.CharacterSlug]comic.AppearancesByYears{
+		c1.Slug: {
+			CharacterSlug: c1.Slug,
+			Aggregates:    []comic.YearlyAggregate{{Year: 1979, Main: 10}, {Year: 1981, Main: 2}},
+		},
+		c2.Slug: {
+			CharacterSlug: c2.Slug,
+			Aggregates:    []comic.YearlyAggregate{{Year: 1980, Main: 5}},
+		},
+	}, nil)
+	rc := mock_comic.NewMockRedisClient(ctrl)
+	rc.EXPECT().HGetAll(gomock.Any()).Times(2).Return(redis.NewStringStringMapResult(map[string]string{}, nil))
+	slr := mock_comic.NewMockCharacterSyncLogRepository(ctrl)
+	ctr := mock_comic.NewMockCharacterThumbRepository(ctrl)
+	ctr.EXPECT().AllThumbnails(c1.Slug, c2.Slug).Times(1).Return(map[comic.CharacterSlug]*comic.CharacterThumbnails{}, nil)
+
+	svc := comic.NewExpandedService(cr, ar, amr, rc, slr, ctr, nil, nil, nil)
+	cmp, err := svc.Compare(c1.Slug, c2.Slug)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1979, 1980, 1981}, cmp.Years)
+	assert.Len(t, cmp.Characters, 2)
+	assert.Equal(t, c1.Slug, cmp.Characters[0].Slug)
+	assert.Equal(t, c2.Slug, cmp.Characters[1].Slug)
+	assert.Len(t, cmp.Characters[0].Appearances.Aggregates, 3)
+	assert.Equal(t, 0, cmp.Characters[0].Appearances.Aggregates[1].Main)
+	assert.Len(t, cmp.Characters[1].Appearances.Aggregates, 3)
+	assert.Equal(t, 5, cmp.Characters[1].Appearances.Aggregates[1].Main)
+}
+
+func TestExpandedServiceCompareNoResults(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cr := mock_comic.NewMockCharacterRepository(ctrl)
+	cr.EXPECT().FindAll(gomock.Any()).Times(1).Return([]*comic.Character{}, nil)
+	ar := mock_comic.NewMockAppearancesByYearsRepository(ctrl)
+	amr := mock_comic.NewMockAppearancesByYearsMapRepository(ctrl)
+	amr.EXPECT().ListMap(gomock.Any()).Times(0)
+	rc := mock_comic.NewMockRedisClient(ctrl)
+	slr := mock_comic.NewMockCharacterSyncLogRepository(ctrl)
+	ctr := mock_comic.NewMockCharacterThumbRepository(ctrl)
+
+	svc := comic.NewExpandedService(cr, ar, amr, rc, slr, ctr, nil, nil, nil)
+	cmp, err := svc.Compare("emma-frost")
+	assert.Nil(t, err)
+	assert.Len(t, cmp.Characters, 0)
+	assert.Len(t, cmp.Years, 0)
+}
+
+func TestRankedServicePublisherPopular(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cr := comic.PopularCriteria{Limit: 25}
 	r := mock_comic.NewMockPopularRepository(ctrl)
-	r.EXPECT().DCTrending(25, 0).Times(1).Return([]*comic.RankedCharacter{}, nil)
-	svc := comic.NewRankedService(r)
-	results, err := svc.DCTrending(25, 0)
+	r.EXPECT().Publisher(comic.PublisherSlug("dc"), cr).Times(1).Return([]*comic.RankedCharacter{}, nil)
+	svc := comic.NewRankedService(r, nil, nil)
+	results, err := svc.PublisherPopular("dc", cr)
 	assert.Nil(t, err)
 	assert.Len(t, results, 0)
 }
 
-func TestRankedServiceMarvelTrending(t *testing.T) {
+func TestRankedServiceRankHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := mock_comic.NewMockRankSnapshotRepository(ctrl)
+	s.EXPECT().History(comic.CharacterID(1), comic.MainStats).Times(1).Return([]*comic.RankSnapshot{{CharacterID: 1}}, nil)
+	svc := comic.NewRankedService(mock_comic.NewMockPopularRepository(ctrl), s, nil)
+	results, err := svc.RankHistory(1, comic.MainStats)
+	assert.Nil(t, err)
+	assert.Len(t, results, 1)
+}
+
+func TestRankedServiceCoAppearances(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	co := mock_comic.NewMockCoAppearanceRepository(ctrl)
+	cr := comic.CoAppearanceCriteria{SortBy: comic.HighestAffinity, Limit: 25}
+	co.EXPECT().Partners(comic.CharacterID(1), cr).Times(1).Return([]*comic.CoAppearance{{Slug: "cyclops", IssueCount: 10}}, nil)
+	gcr := comic.CoAppearanceGraphCriteria{MinIssueCount: 5, Limit: 100}
+	co.EXPECT().Graph(gcr).Times(1).Return(&comic.CoAppearanceGraph{}, nil)
+	svc := comic.NewRankedService(mock_comic.NewMockPopularRepository(ctrl), nil, co)
+	results, err := svc.CoAppearances(1, cr)
+	assert.Nil(t, err)
+	assert.Len(t, results, 1)
+	g, err := svc.CoAppearanceGraph(gcr)
+	assert.Nil(t, err)
+	assert.NotNil(t, g)
+}
+
+func TestRankedServiceTrending(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	r := mock_comic.NewMockPopularRepository(ctrl)
-	r.EXPECT().MarvelTrending(25, 0).Times(1).Return([]*comic.RankedCharacter{}, nil)
-	svc := comic.NewRankedService(r)
-	results, err := svc.MarvelTrending(25, 0)
+	cr := comic.TrendingCriteria{Window: comic.SixMonths, Limit: 25}
+	r.EXPECT().Trending(comic.PublisherSlug("marvel"), cr).Times(1).Return([]*comic.RankedCharacter{}, nil)
+	svc := comic.NewRankedService(r, nil, nil)
+	results, err := svc.Trending("marvel", cr)
 	assert.Nil(t, err)
 	assert.Len(t, results, 0)
 }
@@ -225,3 +410,24 @@ func TestCharacterThumbServiceUpload(t *testing.T) {
 	assert.NotEmpty(t, "e.jpg", img.Medium)
 	assert.NotEmpty(t, "f.jpg", img.Large)
 }
+
+func TestCharacterServiceNormalizeSourcesUnknownPublisher(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	csr := mock_comic.NewMockCharacterSourceRepository(ctrl)
+	csr.EXPECT().Raw(gomock.Any(), gomock.Any()).Times(0)
+	svc := comic.NewCharacterService(nil, nil, nil, csr, nil, nil)
+	err := svc.NormalizeSources(&comic.Character{ID: 1, Publisher: comic.Publisher{Slug: "image"}})
+	assert.EqualError(t, err, "unknown publisher: image")
+}
+
+func TestCharacterServiceNormalizeSources(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	csr := mock_comic.NewMockCharacterSourceRepository(ctrl)
+	csr.EXPECT().Raw(gomock.Any(), uint(1)).Times(3).Return(nil)
+	svc := comic.NewCharacterService(nil, nil, nil, csr, nil, nil)
+	assert.Nil(t, svc.NormalizeSources(&comic.Character{ID: 1, Publisher: comic.Publisher{Slug: "dc"}}))
+}
@@ -20,14 +20,39 @@ type AppearancesByYearsWriter interface {
 	Delete(slug CharacterSlug) (int64, error)
 }
 
-// AppearancesSyncer to sync yearly appearances from Postgres to Redis.
+// AppearancesByMonthsWriter sets the appearances per month for a character.
+type AppearancesByMonthsWriter interface {
+	Set(apps AppearancesByMonths) error
+	Delete(slug CharacterSlug) (int64, error)
+}
+
+// SeriesWriter sets the series a character appears in the most.
+type SeriesWriter interface {
+	Set(series CharacterSeries) error
+	Delete(slug CharacterSlug) (int64, error)
+}
+
+// FormatMixWriter sets the issues in each format that counted as a character's appearances.
+type FormatMixWriter interface {
+	Set(slug CharacterSlug, mix []FormatBreakdown) error
+	Delete(slug CharacterSlug) (int64, error)
+}
+
+// AppearancesSyncer to sync yearly and monthly appearances, the series they're in, and the format mix from
+// Postgres to Redis.
 type AppearancesSyncer struct {
-	reader AppearancesByYearsRepository
-	writer AppearancesByYearsWriter
+	reader          AppearancesByYearsRepository
+	writer          AppearancesByYearsWriter
+	seriesReader    SeriesRepository
+	seriesWriter    SeriesWriter
+	monthlyReader   AppearancesByMonthsRepository
+	monthlyWriter   AppearancesByMonthsWriter
+	formatMixReader FormatMixRepository
+	formatMixWriter FormatMixWriter
 }
 
-// Sync gets all the character's appearances from the database and syncs them to Redis.
-// returns the total number of issues synced and an error if any.
+// Sync gets all the character's appearances from the database and syncs them to Redis along with the series
+// they appear in the most, their appearances per month, and their format mix. returns the total number of issues synced and an error if any.
 func (s *AppearancesSyncer) Sync(slug CharacterSlug) (int, error) {
 	apps, err := s.reader.List(slug)
 	if err != nil {
@@ -40,11 +65,29 @@ func (s *AppearancesSyncer) Sync(slug CharacterSlug) (int, error) {
 		zap.Int("main", apps.MainTotal()),
 		zap.Int("alternate", apps.AlternateTotal()))
 	if apps.Aggregates != nil {
-		err = s.writer.Set(apps)
+		if err = s.writer.Set(apps); err != nil {
+			return total, err
+		}
+		log.COMIC().Info("successfully sent appearances to redis", zap.String("character", slug.Value()))
+		series, err := s.seriesReader.Series(slug)
+		if err != nil {
+			return total, err
+		}
+		if err = s.seriesWriter.Set(series); err != nil {
+			return total, err
+		}
+		months, err := s.monthlyReader.List(slug)
+		if err != nil {
+			return total, err
+		}
+		if err = s.monthlyWriter.Set(months); err != nil {
+			return total, err
+		}
+		mix, err := s.formatMixReader.FormatMix(slug)
 		if err != nil {
-			log.COMIC().Info("successfully sent appearances to redis", zap.String("character", slug.Value()))
+			return total, err
 		}
-		return total, err
+		return total, s.formatMixWriter.Set(slug, mix)
 	}
 	return 0, nil
 }
@@ -62,12 +105,13 @@ type RedisHmSetter interface {
 
 // RedisCharacterStatsSyncer is for syncing characters to redis.
 type RedisCharacterStatsSyncer struct {
-	r  RedisClient
-	cr CharacterRepository
-	pr PopularRepository
+	r   RedisClient
+	cr  CharacterRepository
+	pr  PopularRepository
+	car CareerRepository
 }
 
-// Sync syncs the character's ranking stats to Redis.
+// Sync syncs the character's ranking stats and career stats to Redis.
 func (s *RedisCharacterStatsSyncer) Sync(slug CharacterSlug) error {
 	c, err := s.cr.FindBySlug(slug, false)
 	if err != nil {
@@ -76,33 +120,23 @@ func (s *RedisCharacterStatsSyncer) Sync(slug CharacterSlug) error {
 	if c == nil {
 		return errors.New("character doesn't exist or is disabled")
 	}
-	if c.Publisher.Slug == "marvel" {
-		rcm, err := s.pr.FindOneByMarvel(c.ID)
-		if err != nil {
-			return err
-		}
-		rc, err := s.pr.FindOneByAll(c.ID)
-		if err != nil {
-			return err
-		}
-		if err := s.set(c, rc, rcm); err != nil {
-			return err
-		}
+	rcp, err := s.pr.FindOneByPublisher(c.Publisher.Slug, c.ID)
+	if err != nil {
+		return err
 	}
-	if c.Publisher.Slug == "dc" {
-		rcd, err := s.pr.FindOneByDC(c.ID)
-		if err != nil {
-			return err
-		}
-		rc, err := s.pr.FindOneByAll(c.ID)
-		if err != nil {
-			return err
-		}
-		if err := s.set(c, rc, rcd); err != nil {
-			return err
-		}
+	rc, err := s.pr.FindOneByAll(c.ID)
+	if err != nil {
+		return err
 	}
-	return nil
+	rcw, err := s.pr.FindOneByWeighted(c.ID)
+	if err != nil {
+		return err
+	}
+	career, err := s.car.Career(c.ID)
+	if err != nil {
+		return err
+	}
+	return s.set(c, rc, rcp, rcw, career)
 }
 
 // CharacterSyncResult is the result set for a synced character to redis and an error if any.
@@ -139,10 +173,26 @@ func (s *RedisCharacterStatsSyncer) SyncAll(characters []*Character) <-chan Char
 	return resultCh
 }
 
-func (s *RedisCharacterStatsSyncer) set(c *Character, allTime *RankedCharacter, main *RankedCharacter) error {
+// The fields for the character's career stats, which get removed when the character doesn't have a career anymore.
+var careerFields = []string{
+	"career_first_appearance_date",
+	"career_first_appearance_series_name",
+	"career_first_appearance_series_number",
+	"career_latest_appearance_date",
+	"career_latest_appearance_series_name",
+	"career_latest_appearance_series_number",
+	"career_peak_year",
+	"career_peak_year_count",
+	"career_longest_streak",
+	"career_longest_streak_start",
+	"career_active_years",
+}
+
+func (s *RedisCharacterStatsSyncer) set(c *Character, allTime, main, weighted *RankedCharacter, career *CareerStats) error {
 	at := allTime.Stats
 	ma := main.Stats
-	m := make(map[string]interface{}, 8)
+	we := weighted.Stats
+	m := make(map[string]interface{}, 33)
 	m["all_time_issue_count_rank"] = at.IssueCountRank
 	m["all_time_issue_count"] = at.IssueCount
 	m["all_time_average_per_year"] = at.Average
@@ -151,31 +201,88 @@ func (s *RedisCharacterStatsSyncer) set(c *Character, allTime *RankedCharacter,
 	m["main_issue_count"] = ma.IssueCount
 	m["main_average_per_year"] = ma.Average
 	m["main_average_per_year_rank"] = ma.AverageRank
+	m["weighted_issue_count_rank"] = we.IssueCountRank
+	m["weighted_issue_count"] = we.IssueCount
+	m["weighted_average_per_year"] = we.Average
+	m["weighted_average_per_year_rank"] = we.AverageRank
+	// the main percentiles are within the character's publisher, so they say how the character compares to the
+	// publisher's other characters.
+	m["all_time_issue_count_percentile"] = at.IssueCountPercentile
+	m["all_time_average_per_year_percentile"] = at.AveragePercentile
+	m["all_time_normalized_popularity"] = at.NormalizedPopularity
+	m["main_issue_count_percentile"] = ma.IssueCountPercentile
+	m["main_average_per_year_percentile"] = ma.AveragePercentile
+	m["main_normalized_popularity"] = ma.NormalizedPopularity
+	m["weighted_issue_count_percentile"] = we.IssueCountPercentile
+	m["weighted_average_per_year_percentile"] = we.AveragePercentile
+	m["weighted_normalized_popularity"] = we.NormalizedPopularity
+	// the years since the latest appearance aren't synced since they change without any new appearances.
+	if career != nil {
+		m["career_first_appearance_date"] = career.FirstAppearance.SaleDate.Format(careerDateFormat)
+		m["career_first_appearance_series_name"] = career.FirstAppearance.VendorSeriesName
+		m["career_first_appearance_series_number"] = career.FirstAppearance.VendorSeriesNumber
+		m["career_latest_appearance_date"] = career.LatestAppearance.SaleDate.Format(careerDateFormat)
+		m["career_latest_appearance_series_name"] = career.LatestAppearance.VendorSeriesName
+		m["career_latest_appearance_series_number"] = career.LatestAppearance.VendorSeriesNumber
+		m["career_peak_year"] = career.PeakYear
+		m["career_peak_year_count"] = career.PeakYearCount
+		m["career_longest_streak"] = career.LongestStreak
+		m["career_longest_streak_start"] = career.LongestStreakStart
+		m["career_active_years"] = career.ActiveYears
+	}
 	key := fmt.Sprintf("%s:stats", c.Slug)
-	return s.r.HMSet(key, m).Err()
+	if err := s.r.HMSet(key, m).Err(); err != nil {
+		return err
+	}
+	// the old career stats would stick around otherwise, like after all the character's issues got removed.
+	if career == nil {
+		return s.r.HDel(key, careerFields...).Err()
+	}
+	return nil
 }
 
 // NewAppearancesSyncer returns a new appearances syncer
 func NewAppearancesSyncer(db ORM, redis RedisClient) *AppearancesSyncer {
 	return &AppearancesSyncer{
-		reader: NewPGAppearancesPerYearRepository(db),
-		writer: NewRedisAppearancesPerYearRepository(redis),
+		reader:          NewPGAppearancesPerYearRepository(db),
+		writer:          NewRedisAppearancesPerYearRepository(redis),
+		seriesReader:    NewPGSeriesRepository(db),
+		seriesWriter:    NewRedisSeriesRepository(redis),
+		monthlyReader:   NewPGAppearancesByMonthsRepository(db),
+		monthlyWriter:   NewRedisAppearancesByMonthsRepository(redis),
+		formatMixReader: NewPGFormatMixRepository(db),
+		formatMixWriter: NewRedisFormatMixRepository(redis),
 	}
 }
 
-// NewAppearancesSyncerRW returns a new appearances syncer with the reader and writer for the cache.
-func NewAppearancesSyncerRW(r AppearancesByYearsRepository, w AppearancesByYearsWriter) *AppearancesSyncer {
+// NewAppearancesSyncerRW returns a new appearances syncer with the readers and writers for the cache.
+func NewAppearancesSyncerRW(
+	r AppearancesByYearsRepository,
+	w AppearancesByYearsWriter,
+	sr SeriesRepository,
+	sw SeriesWriter,
+	mr AppearancesByMonthsRepository,
+	mw AppearancesByMonthsWriter,
+	fr FormatMixRepository,
+	fw FormatMixWriter) *AppearancesSyncer {
 	return &AppearancesSyncer{
-		reader: r,
-		writer: w,
+		reader:          r,
+		writer:          w,
+		seriesReader:    sr,
+		seriesWriter:    sw,
+		monthlyReader:   mr,
+		monthlyWriter:   mw,
+		formatMixReader: fr,
+		formatMixWriter: fw,
 	}
 }
 
 // NewCharacterStatsSyncer returns a new character stats syncer with dependencies.
-func NewCharacterStatsSyncer(r RedisClient, cr CharacterRepository, pr PopularRepository) *RedisCharacterStatsSyncer {
+func NewCharacterStatsSyncer(r RedisClient, cr CharacterRepository, pr PopularRepository, car CareerRepository) *RedisCharacterStatsSyncer {
 	return &RedisCharacterStatsSyncer{
-		r:  r,
-		cr: cr,
-		pr: pr,
+		r:   r,
+		cr:  cr,
+		pr:  pr,
+		car: car,
 	}
 }
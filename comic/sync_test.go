@@ -8,6 +8,7 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestAppearancesSyncerSync(t *testing.T) {
@@ -23,13 +24,94 @@ func TestAppearancesSyncerSync(t *testing.T) {
 
 	w := mock_comic.NewMockAppearancesByYearsWriter(ctrl)
 	w.EXPECT().Set(mains).Return(nil)
+	series := comic.NewCharacterSeries("test", []comic.SeriesAppearances{{Name: "X-Men", IssueCount: 21}}, 100)
+	sr := mock_comic.NewMockSeriesRepository(ctrl)
+	sr.EXPECT().Series(comic.CharacterSlug("test")).Return(series, nil)
+	sw := mock_comic.NewMockSeriesWriter(ctrl)
+	sw.EXPECT().Set(series).Return(nil)
+	months := comic.NewAppearancesByMonths("test", []comic.MonthlyAggregate{{Year: 2018, Month: 0, Alternate: 2}, {Year: 2018, Month: 1, Alternate: 8}})
+	mr := mock_comic.NewMockAppearancesByMonthsRepository(ctrl)
+	mr.EXPECT().List(comic.CharacterSlug("test")).Return(months, nil)
+	mw := mock_comic.NewMockAppearancesByMonthsWriter(ctrl)
+	mw.EXPECT().Set(months).Return(nil)
+	mix := []comic.FormatBreakdown{{Format: comic.FormatStandard, Counted: 21, Excluded: map[comic.ExclusionReason]int{}}}
+	fr := mock_comic.NewMockFormatMixRepository(ctrl)
+	fr.EXPECT().FormatMix(comic.CharacterSlug("test")).Return(mix, nil)
+	fw := mock_comic.NewMockFormatMixWriter(ctrl)
+	fw.EXPECT().Set(comic.CharacterSlug("test"), mix).Return(nil)
 
-	s := comic.NewAppearancesSyncerRW(r, w)
+	s := comic.NewAppearancesSyncerRW(r, w, sr, sw, mr, mw, fr, fw)
 	total, err := s.Sync(comic.CharacterSlug("test"))
 	assert.Nil(t, err)
 	assert.Equal(t, 21, total)
 }
 
+func TestAppearancesSyncerSyncMonthlyError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mains := comic.AppearancesByYears{CharacterSlug: "test", Aggregates: []comic.YearlyAggregate{{Year: 2017, Main: 11}}}
+	r := mock_comic.NewMockAppearancesByYearsRepository(ctrl)
+	r.EXPECT().List(gomock.Any()).Return(mains, nil)
+	w := mock_comic.NewMockAppearancesByYearsWriter(ctrl)
+	w.EXPECT().Set(mains).Return(nil)
+	sr := mock_comic.NewMockSeriesRepository(ctrl)
+	sr.EXPECT().Series(comic.CharacterSlug("test")).Return(comic.CharacterSeries{}, nil)
+	sw := mock_comic.NewMockSeriesWriter(ctrl)
+	sw.EXPECT().Set(gomock.Any()).Return(nil)
+	mr := mock_comic.NewMockAppearancesByMonthsRepository(ctrl)
+	mr.EXPECT().List(comic.CharacterSlug("test")).Return(comic.AppearancesByMonths{}, errors.New("some error"))
+	mw := mock_comic.NewMockAppearancesByMonthsWriter(ctrl)
+	mw.EXPECT().Set(gomock.Any()).Times(0)
+
+	s := comic.NewAppearancesSyncerRW(r, w, sr, sw, mr, mw, nil, nil)
+	_, err := s.Sync(comic.CharacterSlug("test"))
+	assert.Error(t, err)
+}
+
+func TestAppearancesSyncerSyncFormatMixError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mains := comic.AppearancesByYears{CharacterSlug: "test", Aggregates: []comic.YearlyAggregate{{Year: 2017, Main: 11}}}
+	r := mock_comic.NewMockAppearancesByYearsRepository(ctrl)
+	r.EXPECT().List(gomock.Any()).Return(mains, nil)
+	w := mock_comic.NewMockAppearancesByYearsWriter(ctrl)
+	w.EXPECT().Set(mains).Return(nil)
+	sr := mock_comic.NewMockSeriesRepository(ctrl)
+	sr.EXPECT().Series(comic.CharacterSlug("test")).Return(comic.CharacterSeries{}, nil)
+	sw := mock_comic.NewMockSeriesWriter(ctrl)
+	sw.EXPECT().Set(gomock.Any()).Return(nil)
+	mr := mock_comic.NewMockAppearancesByMonthsRepository(ctrl)
+	mr.EXPECT().List(comic.CharacterSlug("test")).Return(comic.AppearancesByMonths{}, nil)
+	mw := mock_comic.NewMockAppearancesByMonthsWriter(ctrl)
+	mw.EXPECT().Set(gomock.Any()).Return(nil)
+	fr := mock_comic.NewMockFormatMixRepository(ctrl)
+	fr.EXPECT().FormatMix(comic.CharacterSlug("test")).Return(nil, errors.New("some error"))
+	fw := mock_comic.NewMockFormatMixWriter(ctrl)
+	fw.EXPECT().Set(gomock.Any(), gomock.Any()).Times(0)
+
+	s := comic.NewAppearancesSyncerRW(r, w, sr, sw, mr, mw, fr, fw)
+	_, err := s.Sync(comic.CharacterSlug("test"))
+	assert.Error(t, err)
+}
+
+func TestAppearancesSyncerSyncSeriesError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mains := comic.AppearancesByYears{CharacterSlug: "test", Aggregates: []comic.YearlyAggregate{{Year: 2017, Main: 11}}}
+	r := mock_comic.NewMockAppearancesByYearsRepository(ctrl)
+	r.EXPECT().List(gomock.Any()).Return(mains, nil)
+	w := mock_comic.NewMockAppearancesByYearsWriter(ctrl)
+	w.EXPECT().Set(mains).Return(nil)
+	sr := mock_comic.NewMockSeriesRepository(ctrl)
+	sr.EXPECT().Series(comic.CharacterSlug("test")).Return(comic.CharacterSeries{}, errors.New("some error"))
+	sw := mock_comic.NewMockSeriesWriter(ctrl)
+	sw.EXPECT().Set(gomock.Any()).Times(0)
+
+	s := comic.NewAppearancesSyncerRW(r, w, sr, sw, mock_comic.NewMockAppearancesByMonthsRepository(ctrl), mock_comic.NewMockAppearancesByMonthsWriter(ctrl), nil, nil)
+	_, err := s.Sync(comic.CharacterSlug("test"))
+	assert.Error(t, err)
+}
+
 func TestAppearancesSyncerSyncReaderError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -42,7 +124,7 @@ func TestAppearancesSyncerSyncReaderError(t *testing.T) {
 	r.EXPECT().List(gomock.Any()).Return(mains, errors.New("bad error"))
 
 	w := mock_comic.NewMockAppearancesByYearsWriter(ctrl)
-	s := comic.NewAppearancesSyncerRW(r, w)
+	s := comic.NewAppearancesSyncerRW(r, w, mock_comic.NewMockSeriesRepository(ctrl), mock_comic.NewMockSeriesWriter(ctrl), nil, nil, nil, nil)
 	_, err := s.Sync(comic.CharacterSlug("test"))
 	assert.Error(t, err)
 }
@@ -60,8 +142,10 @@ func TestAppearancesSyncerSyncWriterError(t *testing.T) {
 	r.EXPECT().List(gomock.Any()).Return(mains, nil)
 	w := mock_comic.NewMockAppearancesByYearsWriter(ctrl)
 	w.EXPECT().Set(mains).Return(errors.New("some error"))
+	sr := mock_comic.NewMockSeriesRepository(ctrl)
+	sr.EXPECT().Series(gomock.Any()).Times(0)
 
-	s := comic.NewAppearancesSyncerRW(r, w)
+	s := comic.NewAppearancesSyncerRW(r, w, sr, mock_comic.NewMockSeriesWriter(ctrl), nil, nil, nil, nil)
 	_, err := s.Sync(comic.CharacterSlug("test"))
 	assert.Error(t, err)
 }
@@ -71,7 +155,17 @@ func TestRedisCharacterStatsSyncerSyncMarvel(t *testing.T) {
 	defer ctrl.Finish()
 
 	rds := mock_comic.NewMockRedisClient(ctrl)
-	rds.EXPECT().HMSet(gomock.Any(), gomock.Any()).Return(&redis.StatusCmd{})
+	rds.EXPECT().HMSet("emma-frost:stats", gomock.Any()).DoAndReturn(func(key string, fields map[string]interface{}) *redis.StatusCmd {
+		assert.Equal(t, "1979-11-01", fields["career_first_appearance_date"])
+		assert.Equal(t, "Uncanny X-Men", fields["career_first_appearance_series_name"])
+		assert.Equal(t, 2011, fields["career_peak_year"])
+		assert.Equal(t, 3, fields["career_active_years"])
+		assert.Equal(t, uint(3), fields["weighted_issue_count_rank"])
+		assert.Equal(t, uint(150), fields["weighted_issue_count"])
+		assert.Equal(t, 99.5, fields["main_issue_count_percentile"])
+		assert.Equal(t, 1.75, fields["all_time_normalized_popularity"])
+		return &redis.StatusCmd{}
+	})
 	cr := mock_comic.NewMockCharacterRepository(ctrl)
 	cr.EXPECT().FindBySlug(gomock.Any(), false).Return(&comic.Character{
 		Slug: "emma-frost",
@@ -79,22 +173,35 @@ func TestRedisCharacterStatsSyncerSyncMarvel(t *testing.T) {
 			Slug: "marvel",
 		},
 	}, nil)
+	car := mock_comic.NewMockCareerRepository(ctrl)
+	car.EXPECT().Career(gomock.Any()).Return(comic.NewCareerStats(
+		comic.CareerIssue{SaleDate: time.Date(1979, time.November, 1, 0, 0, 0, 0, time.UTC), VendorSeriesName: "Uncanny X-Men", VendorSeriesNumber: "129"},
+		comic.CareerIssue{SaleDate: time.Date(2012, time.May, 1, 0, 0, 0, 0, time.UTC), VendorSeriesName: "X-Men", VendorSeriesNumber: "1"},
+		map[int]int{1979: 1, 2011: 30, 2012: 10},
+		time.Now()), nil)
 	pr := mock_comic.NewMockPopularRepository(ctrl)
-	pr.EXPECT().FindOneByDC(gomock.Any()).Times(0)
-	pr.EXPECT().FindOneByMarvel(gomock.Any()).Return(&comic.RankedCharacter{
+	pr.EXPECT().FindOneByPublisher(comic.PublisherSlug("marvel"), gomock.Any()).Return(&comic.RankedCharacter{
 		Stats: comic.CharacterStats{
-			IssueCountRank: 1,
-			IssueCount:     100,
+			IssueCountRank:       1,
+			IssueCount:           100,
+			IssueCountPercentile: 99.5,
 		},
 	}, nil)
 	pr.EXPECT().FindOneByAll(gomock.Any()).Return(&comic.RankedCharacter{
 		Stats: comic.CharacterStats{
-			IssueCountRank: 2,
-			IssueCount:     200,
+			IssueCountRank:       2,
+			IssueCount:           200,
+			NormalizedPopularity: 1.75,
+		},
+	}, nil)
+	pr.EXPECT().FindOneByWeighted(gomock.Any()).Return(&comic.RankedCharacter{
+		Stats: comic.CharacterStats{
+			IssueCountRank: 3,
+			IssueCount:     150,
 		},
 	}, nil)
 
-	syncer := comic.NewCharacterStatsSyncer(rds, cr, pr)
+	syncer := comic.NewCharacterStatsSyncer(rds, cr, pr, car)
 	err := syncer.Sync(comic.CharacterSlug("emma-frost"))
 	assert.Nil(t, err)
 }
@@ -105,6 +212,12 @@ func TestRedisCharacterStatsSyncerSyncDC(t *testing.T) {
 
 	rds := mock_comic.NewMockRedisClient(ctrl)
 	rds.EXPECT().HMSet(gomock.Any(), gomock.Any()).Return(&redis.StatusCmd{})
+	// there's no career, so the old career stats get removed.
+	rds.EXPECT().HDel("emma-frost:stats", gomock.Any()).DoAndReturn(func(key string, fields ...string) *redis.IntCmd {
+		assert.Contains(t, fields, "career_first_appearance_date")
+		assert.Contains(t, fields, "career_active_years")
+		return redis.NewIntResult(11, nil)
+	})
 	cr := mock_comic.NewMockCharacterRepository(ctrl)
 	cr.EXPECT().FindBySlug(gomock.Any(), false).Return(&comic.Character{
 		Slug: "emma-frost",
@@ -113,8 +226,7 @@ func TestRedisCharacterStatsSyncerSyncDC(t *testing.T) {
 		},
 	}, nil)
 	pr := mock_comic.NewMockPopularRepository(ctrl)
-	pr.EXPECT().FindOneByMarvel(gomock.Any()).Times(0)
-	pr.EXPECT().FindOneByDC(gomock.Any()).Return(&comic.RankedCharacter{
+	pr.EXPECT().FindOneByPublisher(comic.PublisherSlug("dc"), gomock.Any()).Return(&comic.RankedCharacter{
 		Stats: comic.CharacterStats{
 			IssueCountRank: 1,
 			IssueCount:     100,
@@ -126,8 +238,16 @@ func TestRedisCharacterStatsSyncerSyncDC(t *testing.T) {
 			IssueCount:     200,
 		},
 	}, nil)
+	pr.EXPECT().FindOneByWeighted(gomock.Any()).Return(&comic.RankedCharacter{
+		Stats: comic.CharacterStats{
+			IssueCountRank: 3,
+			IssueCount:     150,
+		},
+	}, nil)
 
-	syncer := comic.NewCharacterStatsSyncer(rds, cr, pr)
+	car := mock_comic.NewMockCareerRepository(ctrl)
+	car.EXPECT().Career(gomock.Any()).Return(nil, nil)
+	syncer := comic.NewCharacterStatsSyncer(rds, cr, pr, car)
 	err := syncer.Sync(comic.CharacterSlug("emma-frost"))
 	assert.Nil(t, err)
 }
@@ -141,7 +261,9 @@ func TestRedisCharacterStatsSyncerNoSyncNotFound(t *testing.T) {
 	pr := mock_comic.NewMockPopularRepository(ctrl)
 	cr := mock_comic.NewMockCharacterRepository(ctrl)
 	cr.EXPECT().FindBySlug(gomock.Any(), false).Return(nil, nil)
-	syncer := comic.NewCharacterStatsSyncer(rds, cr, pr)
+	car := mock_comic.NewMockCareerRepository(ctrl)
+	car.EXPECT().Career(gomock.Any()).Times(0)
+	syncer := comic.NewCharacterStatsSyncer(rds, cr, pr, car)
 	err := syncer.Sync(comic.CharacterSlug("emma-frost"))
 	assert.Error(t, err)
 }
@@ -153,6 +275,7 @@ func TestRedisCharacterStatsSyncerSyncAll(t *testing.T) {
 	rds := mock_comic.NewMockRedisClient(ctrl)
 	rds.EXPECT().HMSet(gomock.Any(), gomock.Any()).Return(&redis.StatusCmd{})
 	rds.EXPECT().HMSet(gomock.Any(), gomock.Any()).Return(&redis.StatusCmd{})
+	rds.EXPECT().HDel(gomock.Any(), gomock.Any()).Times(2).Return(redis.NewIntResult(0, nil))
 	cr := mock_comic.NewMockCharacterRepository(ctrl)
 	cr.EXPECT().FindBySlug(gomock.Any(), false).Return(&comic.Character{
 		Slug: "a",
@@ -167,8 +290,7 @@ func TestRedisCharacterStatsSyncerSyncAll(t *testing.T) {
 		},
 	}, nil)
 	pr := mock_comic.NewMockPopularRepository(ctrl)
-	pr.EXPECT().FindOneByDC(gomock.Any()).Times(0)
-	pr.EXPECT().FindOneByMarvel(gomock.Any()).Return(&comic.RankedCharacter{
+	pr.EXPECT().FindOneByPublisher(comic.PublisherSlug("marvel"), gomock.Any()).Return(&comic.RankedCharacter{
 		Stats: comic.CharacterStats{
 			IssueCountRank: 1,
 			IssueCount:     100,
@@ -180,7 +302,7 @@ func TestRedisCharacterStatsSyncerSyncAll(t *testing.T) {
 			IssueCount:     200,
 		},
 	}, nil)
-	pr.EXPECT().FindOneByMarvel(gomock.Any()).Return(&comic.RankedCharacter{
+	pr.EXPECT().FindOneByPublisher(comic.PublisherSlug("marvel"), gomock.Any()).Return(&comic.RankedCharacter{
 		Stats: comic.CharacterStats{
 			IssueCountRank: 10,
 			IssueCount:     1,
@@ -193,7 +315,10 @@ func TestRedisCharacterStatsSyncerSyncAll(t *testing.T) {
 		},
 	}, nil)
 
-	syncer := comic.NewCharacterStatsSyncer(rds, cr, pr)
+	pr.EXPECT().FindOneByWeighted(gomock.Any()).Times(2).Return(&comic.RankedCharacter{}, nil)
+	car := mock_comic.NewMockCareerRepository(ctrl)
+	car.EXPECT().Career(gomock.Any()).Times(2).Return(nil, nil)
+	syncer := comic.NewCharacterStatsSyncer(rds, cr, pr, car)
 
 	c := []*comic.Character{
 		{Slug: "a"},
@@ -228,8 +353,7 @@ func TestRedisCharacterStatsSyncerSyncAllError(t *testing.T) {
 		},
 	}, nil)
 	pr := mock_comic.NewMockPopularRepository(ctrl)
-	pr.EXPECT().FindOneByDC(gomock.Any()).Times(0)
-	pr.EXPECT().FindOneByMarvel(gomock.Any()).Return(&comic.RankedCharacter{
+	pr.EXPECT().FindOneByPublisher(comic.PublisherSlug("marvel"), gomock.Any()).Return(&comic.RankedCharacter{
 		Stats: comic.CharacterStats{
 			IssueCountRank: 1,
 			IssueCount:     100,
@@ -241,7 +365,7 @@ func TestRedisCharacterStatsSyncerSyncAllError(t *testing.T) {
 			IssueCount:     200,
 		},
 	}, errors.New("some error"))
-	pr.EXPECT().FindOneByMarvel(gomock.Any()).Return(&comic.RankedCharacter{
+	pr.EXPECT().FindOneByPublisher(comic.PublisherSlug("marvel"), gomock.Any()).Return(&comic.RankedCharacter{
 		Stats: comic.CharacterStats{
 			IssueCountRank: 10,
 			IssueCount:     1,
@@ -254,7 +378,10 @@ func TestRedisCharacterStatsSyncerSyncAllError(t *testing.T) {
 		},
 	}, errors.New("some error"))
 
-	syncer := comic.NewCharacterStatsSyncer(rds, cr, pr)
+	pr.EXPECT().FindOneByWeighted(gomock.Any()).Times(0)
+	car := mock_comic.NewMockCareerRepository(ctrl)
+	car.EXPECT().Career(gomock.Any()).Times(0)
+	syncer := comic.NewCharacterStatsSyncer(rds, cr, pr, car)
 
 	c := []*comic.Character{
 		{Slug: "a"},
@@ -0,0 +1,340 @@
+package comic
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+)
+
+// LegacyPublisherViews are the names the publisher's ranked view had before the ranks were computed when they're queried.
+func LegacyPublisherViews(slug PublisherSlug) []MaterializedView {
+	return []MaterializedView{
+		MaterializedView(fmt.Sprintf("mv_ranked_characters_%s_main", viewSafe(slug))),
+		MaterializedView(fmt.Sprintf("mv_ranks_%s_main", viewSafe(slug))),
+	}
+}
+
+// PublisherTrendingView is the materialized view for the publisher's trending characters in the window
+// for main appearances only.
+func PublisherTrendingView(slug PublisherSlug, w TrendingWindow) MaterializedView {
+	return MaterializedView(fmt.Sprintf("mv_trending_characters_%s_%dm", viewSafe(slug), w))
+}
+
+// Replaces anything in the slug that can't go in an unquoted table name, like `dark-horse` => `dark_horse`.
+// Slugs with anything besides lowercase letters, digits, and hyphens get a hash of the slug on the end,
+// so `dark_horse` doesn't get the same views as `dark-horse`.
+func viewSafe(slug PublisherSlug) string {
+	safe := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, strings.ToLower(slug.Value()))
+	if strings.Replace(safe, "_", "-", -1) != slug.Value() {
+		h := fnv.New32a()
+		h.Write([]byte(slug.Value()))
+		safe += fmt.Sprintf("_%08x", h.Sum32())
+	}
+	return safe
+}
+
+// ViewIndexSQL generates the SQL for the unique index needed to refresh the materialized view concurrently
+// and the indexes for paginating by rank.
+func ViewIndexSQL(view MaterializedView) string {
+	return fmt.Sprintf(`
+		CREATE UNIQUE INDEX IF NOT EXISTS %[1]s_id_idx ON %[1]s(id);
+		CREATE INDEX IF NOT EXISTS %[1]s_issue_count_rank_idx ON %[1]s(issue_count_rank, id);
+		CREATE INDEX IF NOT EXISTS %[1]s_average_per_year_rank_idx ON %[1]s(average_per_year_rank, id);`, view)
+}
+
+// RankedSQL generates the SQL for ranking the characters by their appearances of the type. A `publisherID` of `0`
+// ranks the characters for all publishers. It only reads the counts per year, so it's quick enough to run on the fly
+// and gets queried like a table. The ranks change as soon as the counts do instead of waiting on a refresh.
+func RankedSQL(t AppearanceType, publisherID PublisherID) string {
+	return rankedSQL(t, publisherID, nil, false, 0)
+}
+
+// WeightedSQL generates the SQL for ranking the characters by all their appearances weighted by their importance
+// in the issues. The issue count is the weighted count rounded to a whole number.
+func WeightedSQL() string {
+	return rankedSQL(Main|Alternate, 0, nil, true, 0)
+}
+
+// RankedYearsSQL generates the SQL for ranking the characters by their appearances in the years only.
+// The average per year is the appearances in the range divided by the years from the character's first year in
+// the range to the end of the range, or to this year if the range doesn't end. It's divided by 1 if those are
+// the same year. It gets queried like a table with the same columns as `RankedSQL`.
+func RankedYearsSQL(t AppearanceType, years YearRange) string {
+	return rankedSQL(t, 0, &years, false, 0)
+}
+
+// WeightedYearsSQL generates the SQL for ranking the characters by their weighted appearances in the years only.
+func WeightedYearsSQL(years YearRange) string {
+	return rankedSQL(Main|Alternate, 0, &years, true, 0)
+}
+
+// RelevanceSQL generates the SQL for ranking the characters by their appearances weighted by how recent they are,
+// along with the same columns as `RankedSQL`. Each appearance counts half as much for every `halfLife` years since it.
+// A `publisherID` of `0` ranks the characters for all publishers.
+func RelevanceSQL(t AppearanceType, publisherID PublisherID, years *YearRange, weighted bool, halfLife int) string {
+	if halfLife <= 0 {
+		halfLife = DefaultHalfLife
+	}
+	return rankedSQL(t, publisherID, years, weighted, halfLife)
+}
+
+// Generates the SQL for ranking the characters by their number of appearances and their average per year.
+// The appearances come from the characters' counts per year instead of their issues, so the ranks are cheap to build.
+// The average is from the character's first year to the current year, or to the end of the years if set.
+// If `weighted` is true, each appearance counts by the character's importance in the issue instead of as one.
+// If `halfLife` isn't 0, the characters also get ranked by their relevance with the half-life in years.
+// The characters' percentiles and normalized popularity are always included.
+func rankedSQL(t AppearanceType, publisherID PublisherID, years *YearRange, weighted bool, halfLife int) string {
+	lastYear := "date_part('year', current_date)"
+	if years != nil && years.To != 0 {
+		lastYear = strconv.Itoa(years.To)
+	}
+	column := "yc.total"
+	if t == Main {
+		column = "yc.main"
+	}
+	if t == Alternate {
+		column = "yc.alternate"
+	}
+	if weighted {
+		column = "yc.weighted"
+	}
+	count := "sum(" + column + ")"
+	issueCount := count + "::BIGINT"
+	if weighted {
+		issueCount = "round(" + count + ")::BIGINT"
+	}
+	// the percentiles and the normalized popularity are within the ranked characters, so a publisher's are
+	// comparable to another publisher's even though their catalogs are different sizes.
+	normalized := fmt.Sprintf(`
+		  round((100 * percent_rank() OVER (ORDER BY %[2]s))::DECIMAL, 2) AS issue_count_percentile,
+		  round((100 * percent_rank() OVER (ORDER BY %[2]s / greatest((%[1]s) - min(yc.year), 1)))::DECIMAL, 2) AS average_per_year_percentile,
+		  round(coalesce(
+			(ln(1 + %[2]s) - avg(ln(1 + %[2]s)) OVER ()) / nullif(stddev_pop(ln(1 + %[2]s)) OVER (), 0), 0
+		  )::DECIMAL, 2) AS normalized_popularity,`, lastYear, count)
+	relevance := ""
+	if halfLife > 0 {
+		decayed := fmt.Sprintf("sum(%s * power(0.5, (date_part('year', current_date) - yc.year) / %d::DECIMAL))", column, halfLife)
+		relevance = fmt.Sprintf(`
+		  dense_rank() OVER (ORDER BY %[1]s DESC) AS relevance_rank,
+		  round((%[1]s)::DECIMAL, 2) AS relevance,`, decayed)
+	}
+	sql := fmt.Sprintf(`
+		  SELECT
+		  dense_rank() OVER (ORDER BY %[2]s DESC) AS issue_count_rank,
+		  %[3]s as issue_count,
+		  dense_rank() OVER (
+		   ORDER BY
+			 (
+				%[2]s
+				/
+				(
+				  CASE
+					WHEN
+					 (%[1]s) -  min(yc.year) = 0
+					 THEN 1 -- avoid division by 0
+					ELSE (%[1]s) -  min(yc.year)
+				  END
+				)
+			 ) DESC) AS average_per_year_rank,
+		  round(%[2]s
+		  /
+		  (
+			 CASE
+			   WHEN
+				 (%[1]s) -  min(yc.year) = 0
+					   THEN 1 -- avoid division by 0
+			   ELSE (%[1]s) -  min(yc.year)
+				 END
+			 )::DECIMAL, 2) as average_per_year,%[6]s%[5]s
+		  c.id,
+		  c.publisher_id,
+		  c.name,
+		  c.other_name,
+		  c.description,
+		  c.image,
+		  c.slug,
+		  c.vendor_image,
+		  c.vendor_url,
+		  c.vendor_description,
+          p.id as publisher__id,
+          p.slug as publisher__slug,
+          p.name as publisher__name
+		  FROM characters c
+			JOIN character_year_counts yc ON yc.character_id = c.id
+            JOIN publishers p ON p.id = c.publisher_id
+          WHERE %[4]s > 0
+		`, lastYear, count, issueCount, column, relevance, normalized)
+	if publisherID != 0 {
+		sql += fmt.Sprintf(" AND c.publisher_id = %d", publisherID)
+	}
+	if years != nil {
+		sql += fmt.Sprintf(" AND yc.year >= %d", years.From)
+		if years.To != 0 {
+			sql += fmt.Sprintf(" AND yc.year <= %d", years.To)
+		}
+	}
+	sql += ` AND c.is_disabled = false
+				GROUP BY c.id, p.id`
+	return sql
+}
+
+// YearCountsSQL generates the SQL for recounting the appearances per year of the characters that match the `where`
+// condition on the `character_id` column. The characters' old counts get replaced, so it's safe to run again.
+// Appearances in issues without a sale date aren't counted since they don't have a year. It aggregates all of the
+// characters' issues, so it's only for backfilling the counts. Changes to the issues use `YearCountsDeltaSQL`.
+func YearCountsSQL(where string) string {
+	return fmt.Sprintf(`
+		DELETE FROM character_year_counts WHERE %[1]s;
+		INSERT INTO character_year_counts (character_id, year, total, main, alternate, weighted)
+		SELECT
+			   ci.character_id,
+			   date_part('year', i.sale_date) AS year,
+			   count(ci.id) AS total,
+			   count(ci.id) FILTER (WHERE ci.appearance_type & B'00000001' > 0::BIT(8)) AS main,
+			   count(ci.id) FILTER (WHERE ci.appearance_type & B'00000010' > 0::BIT(8)) AS alternate,
+			   sum(%[2]s) AS weighted
+		FROM character_issues ci
+					JOIN issues i ON i.id = ci.issue_id
+		WHERE %[1]s AND i.sale_date IS NOT NULL
+		GROUP BY ci.character_id, date_part('year', i.sale_date);`, where, importanceWeightSQL())
+}
+
+// YearCountsDeltaSQL generates the SQL for adding the character issues that match the `where` condition on `ci` to
+// the characters' counts per year, or for taking them away when `sign` is negative. Taking an appearance away and
+// adding it back after it changes keeps the counts right without recounting all of the character's issues.
+// Years left without any appearances get removed.
+func YearCountsDeltaSQL(where string, sign int) string {
+	s := "1"
+	if sign < 0 {
+		s = "-1"
+	}
+	sql := fmt.Sprintf(`
+		INSERT INTO character_year_counts AS yc (character_id, year, total, main, alternate, weighted)
+		SELECT
+			   ci.character_id,
+			   date_part('year', i.sale_date) AS year,
+			   %[2]s * count(ci.id) AS total,
+			   %[2]s * count(ci.id) FILTER (WHERE ci.appearance_type & B'00000001' > 0::BIT(8)) AS main,
+			   %[2]s * count(ci.id) FILTER (WHERE ci.appearance_type & B'00000010' > 0::BIT(8)) AS alternate,
+			   %[2]s * sum(%[3]s) AS weighted
+		FROM character_issues ci
+					JOIN issues i ON i.id = ci.issue_id
+		WHERE %[1]s AND i.sale_date IS NOT NULL
+		GROUP BY ci.character_id, date_part('year', i.sale_date)
+		ON CONFLICT (character_id, year) DO UPDATE SET
+			total = yc.total + EXCLUDED.total,
+			main = yc.main + EXCLUDED.main,
+			alternate = yc.alternate + EXCLUDED.alternate,
+			weighted = yc.weighted + EXCLUDED.weighted;`, where, s, importanceWeightSQL())
+	if sign < 0 {
+		sql += fmt.Sprintf(`
+		DELETE FROM character_year_counts
+		WHERE total <= 0 AND character_id IN (SELECT ci.character_id FROM character_issues ci WHERE %s);`, where)
+	}
+	return sql
+}
+
+// Generates the SQL for the weight of an appearance from the character's importance in the issue.
+func importanceWeightSQL() string {
+	sql := "CASE ci.importance"
+	for _, i := range []Importance{Cameo, Minor, Major} {
+		sql += fmt.Sprintf(" WHEN %d THEN %s", i, strconv.FormatFloat(i.Weight(), 'f', -1, 64))
+	}
+	return sql + " ELSE " + strconv.FormatFloat(unknownImportanceWeight, 'f', -1, 64) + " END"
+}
+
+// CoAppearanceViewSQL generates the SQL for creating a materialized view of every pair of characters who appear
+// in the same issues. Each pair is in the view both ways so a character's partners can be looked up by their ID.
+// The affinity is the Jaccard index of the characters' issues: the shared issues over the issues either one is in.
+func CoAppearanceViewSQL(view MaterializedView) string {
+	return fmt.Sprintf(`
+		CREATE MATERIALIZED VIEW IF NOT EXISTS %s AS
+		WITH totals AS (
+			SELECT ci.character_id, count(ci.id) AS issue_count
+			FROM character_issues ci
+			JOIN characters c ON c.id = ci.character_id
+			WHERE c.is_disabled = FALSE
+			GROUP BY ci.character_id
+		)
+		SELECT
+			   a.character_id,
+			   b.character_id AS partner_id,
+			   count(a.id) AS issue_count,
+			   round(count(a.id) / (ta.issue_count + tb.issue_count - count(a.id))::DECIMAL, 4) AS affinity
+		FROM character_issues a
+					JOIN character_issues b ON b.issue_id = a.issue_id AND b.character_id != a.character_id
+					JOIN totals ta ON ta.character_id = a.character_id
+					JOIN totals tb ON tb.character_id = b.character_id
+		GROUP BY a.character_id, b.character_id, ta.issue_count, tb.issue_count;`, view)
+}
+
+// CoAppearanceViewIndexSQL generates the SQL for the unique index needed to refresh the co-appearance view
+// concurrently and the index for the pairs with the most shared issues.
+func CoAppearanceViewIndexSQL(view MaterializedView) string {
+	return fmt.Sprintf(`
+		CREATE UNIQUE INDEX IF NOT EXISTS %[1]s_character_id_partner_id_idx ON %[1]s(character_id, partner_id);
+		CREATE INDEX IF NOT EXISTS %[1]s_issue_count_idx ON %[1]s(issue_count DESC);`, view)
+}
+
+// TrendingViewSQL generates the SQL for creating a materialized view of the publisher's trending characters
+// for the window. Each character gets their rank from the previous window of the same length, so the movement
+// shows momentum instead of only the recent volume. The average per year is the window's appearances over a year.
+func TrendingViewSQL(view MaterializedView, publisherID PublisherID, w TrendingWindow) string {
+	sql := fmt.Sprintf(`
+		CREATE MATERIALIZED VIEW IF NOT EXISTS %[1]s AS
+		WITH current_window AS (
+			SELECT ci.character_id, count(ci.id) AS issue_count
+			FROM character_issues ci
+			JOIN issues i ON i.id = ci.issue_id
+			JOIN characters c ON c.id = ci.character_id
+			WHERE c.publisher_id = %[2]d
+			AND c.is_disabled = FALSE
+			AND i.sale_date > date_trunc('month', CURRENT_DATE) - INTERVAL '%[3]d months'
+			AND ci.appearance_type & B'00000001' > 0::BIT(8)
+			GROUP BY ci.character_id
+		), previous_window AS (
+			SELECT ci.character_id, dense_rank() OVER (ORDER BY count(ci.id) DESC) AS issue_count_rank
+			FROM character_issues ci
+			JOIN issues i ON i.id = ci.issue_id
+			JOIN characters c ON c.id = ci.character_id
+			WHERE c.publisher_id = %[2]d
+			AND c.is_disabled = FALSE
+			AND i.sale_date > date_trunc('month', CURRENT_DATE) - INTERVAL '%[4]d months'
+			AND i.sale_date <= date_trunc('month', CURRENT_DATE) - INTERVAL '%[3]d months'
+			AND ci.appearance_type & B'00000001' > 0::BIT(8)
+			GROUP BY ci.character_id
+		)
+		SELECT
+			   dense_rank() OVER (ORDER BY cw.issue_count DESC) AS issue_count_rank,
+			   cw.issue_count,
+			   dense_rank() OVER (ORDER BY cw.issue_count DESC) AS average_per_year_rank,
+			   round(cw.issue_count * 12 / %[3]d::DECIMAL, 2) as average_per_year,
+			   pw.issue_count_rank AS previous_issue_count_rank,
+			   c.id,
+			   c.publisher_id,
+			   c.name,
+			   c.other_name,
+			   c.description,
+			   c.image,
+			   c.slug,
+			   c.vendor_image,
+			   c.vendor_url,
+			   c.vendor_description,
+			   p.id as publisher__id,
+			   p.slug as publisher__slug,
+			   p.name as publisher__name
+		FROM current_window cw
+					JOIN characters c ON c.id = cw.character_id
+					JOIN publishers p ON p.id = c.publisher_id
+					LEFT JOIN previous_window pw ON pw.character_id = cw.character_id
+		ORDER BY cw.issue_count DESC, c.id
+		LIMIT 50;`, view, publisherID, w, 2*w)
+	return sql
+}
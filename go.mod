@@ -13,6 +13,7 @@ require (
 	github.com/go-redis/redis v6.14.2+incompatible
 	github.com/golang/mock v1.2.0
 	github.com/gosimple/slug v1.4.2
+	github.com/graphql-go/graphql v0.7.8
 	github.com/inconshreveable/mousetrap v1.0.0 // indirect
 	github.com/jinzhu/inflection v0.0.0-20180308033659-04140366298a // indirect
 	github.com/kr/pretty v0.1.0 // indirect
@@ -21,6 +22,7 @@ require (
 	github.com/onsi/ginkgo v1.8.0 // indirect
 	github.com/onsi/gomega v1.5.0 // indirect
 	github.com/pkg/errors v0.8.1 // indirect
+	github.com/prometheus/client_golang v1.2.1
 	github.com/rainycape/unidecode v0.0.0-20150907023854-cb7f23ec59be // indirect
 	github.com/spf13/cobra v0.0.3
 	github.com/spf13/pflag v1.0.3
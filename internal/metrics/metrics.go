@@ -0,0 +1,145 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const namespace = "comiccruncher"
+
+// The keys in Redis that get counted for cache hits and misses.
+const (
+	// CacheThumbnails is for the `:profile:thumbnails` keys.
+	CacheThumbnails = "thumbnails"
+	// CacheAppearances is for the `:appearances` keys.
+	CacheAppearances = "appearances"
+	// CacheMonthlyAppearances is for the `:appearances:monthly` keys.
+	CacheMonthlyAppearances = "monthly_appearances"
+	// CacheStats is for the `:stats` keys.
+	CacheStats = "stats"
+	// CacheGlobalStats is for the `stats:all` key.
+	CacheGlobalStats = "global_stats"
+	// CacheSeries is for the `:series` keys.
+	CacheSeries = "series"
+	// CacheFormatMix is for the `:format_mix` keys.
+	CacheFormatMix = "format_mix"
+	// CacheLastRefreshed is for the `views:last_refreshed` key.
+	CacheLastRefreshed = "last_refreshed"
+)
+
+// Registry is the registry for all the metrics, including the Go runtime and process metrics.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequests counts the requests by the echo route and status code.
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "The number of HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+	// HTTPDuration is the latency of requests by the echo route and status code.
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "The latency of HTTP requests by method, route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+	// DBQueryDuration is how long Postgres queries take by the repository method that made them.
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "postgres",
+		Name:      "query_duration_seconds",
+		Help:      "The duration of Postgres queries by the method that made them.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+	// CacheRequests counts the hits and misses for the keys in Redis.
+	CacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "redis",
+		Name:      "cache_requests_total",
+		Help:      "The number of Redis cache hits and misses by key type.",
+	}, []string{"key", "result"})
+	// IssuesFetched counts the issues cerebro fetched from the external source.
+	IssuesFetched = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cerebro",
+		Name:      "issues_fetched_total",
+		Help:      "The number of issues fetched from the external source.",
+	})
+	// Retries counts the requests cerebro retried because of a connection error.
+	Retries = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cerebro",
+		Name:      "retries_total",
+		Help:      "The number of requests to the external source retried after a connection error.",
+	})
+	// BlankIssuesSkipped counts the blank issues cerebro skipped.
+	BlankIssuesSkipped = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cerebro",
+		Name:      "blank_issues_skipped_total",
+		Help:      "The number of blank issues skipped when importing.",
+	})
+	// SyncLogs counts the finished character syncs by their status.
+	SyncLogs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cerebro",
+		Name:      "sync_logs_total",
+		Help:      "The number of finished character sync logs by status.",
+	}, []string{"status"})
+)
+
+func init() {
+	Registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPDuration,
+		DBQueryDuration,
+		CacheRequests,
+		IssuesFetched,
+		Retries,
+		BlankIssuesSkipped,
+		SyncLogs,
+	)
+}
+
+// ObserveQuery records how long the query took for the function that made it.
+// The function name from go-pg like `(*PGCharacterRepository).FindBySlug` gets recorded as `PGCharacterRepository.FindBySlug`.
+func ObserveQuery(funcName string, d time.Duration) {
+	method := strings.NewReplacer("(", "", ")", "", "*", "").Replace(funcName)
+	if method == "" {
+		method = "unknown"
+	}
+	DBQueryDuration.WithLabelValues(method).Observe(d.Seconds())
+}
+
+// ObserveCache records the hits and misses for the key type.
+func ObserveCache(key string, hits, misses int) {
+	if hits > 0 {
+		CacheRequests.WithLabelValues(key, "hit").Add(float64(hits))
+	}
+	if misses > 0 {
+		CacheRequests.WithLabelValues(key, "miss").Add(float64(misses))
+	}
+}
+
+// Handler is the handler for Prometheus to scrape the metrics.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// Push sends the metrics for a job that doesn't run long enough to get scraped. If the sink is a URL,
+// the metrics get pushed to the Pushgateway there. Otherwise the sink is a file for node_exporter's textfile collector.
+func Push(sink, job string) error {
+	if strings.HasPrefix(sink, "http://") || strings.HasPrefix(sink, "https://") {
+		return push.New(sink, job).Gatherer(Registry).Push()
+	}
+	return prometheus.WriteToTextfile(sink, Registry)
+}
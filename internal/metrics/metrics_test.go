@@ -0,0 +1,64 @@
+package metrics_test
+
+import (
+	"github.com/comiccruncher/comiccruncher/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestObserveQuery(t *testing.T) {
+	metrics.ObserveQuery("(*PGCharacterRepository).FindBySlug", time.Millisecond)
+	metrics.ObserveQuery("", time.Millisecond)
+
+	body := scrape(t)
+	assert.Contains(t, body, `comiccruncher_postgres_query_duration_seconds_count{method="PGCharacterRepository.FindBySlug"} 1`)
+	assert.Contains(t, body, `comiccruncher_postgres_query_duration_seconds_count{method="unknown"} 1`)
+}
+
+func TestObserveCache(t *testing.T) {
+	metrics.ObserveCache(metrics.CacheAppearances, 2, 1)
+	metrics.ObserveCache(metrics.CacheAppearances, 0, 0)
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(metrics.CacheRequests.WithLabelValues(metrics.CacheAppearances, "hit")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.CacheRequests.WithLabelValues(metrics.CacheAppearances, "miss")))
+}
+
+func TestPushTextfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metrics")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	metrics.IssuesFetched.Inc()
+
+	file := filepath.Join(dir, "cerebro.prom")
+	assert.Nil(t, metrics.Push(file, "cerebro"))
+	b, err := ioutil.ReadFile(file)
+	assert.Nil(t, err)
+	assert.Contains(t, string(b), "comiccruncher_cerebro_issues_fetched_total")
+}
+
+func TestPushGateway(t *testing.T) {
+	var path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	assert.Nil(t, metrics.Push(srv.URL, "cerebro"))
+	assert.Equal(t, "/metrics/job/cerebro", path)
+}
+
+func scrape(t *testing.T) string {
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	return strings.TrimSpace(rec.Body.String())
+}
@@ -98,6 +98,23 @@ func (mr *MockRedisClientMockRecorder) HGetAll(key interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HGetAll", reflect.TypeOf((*MockRedisClient)(nil).HGetAll), key)
 }
 
+// HDel mocks base method
+func (m *MockRedisClient) HDel(key string, fields ...string) *redis.IntCmd {
+	varargs := []interface{}{key}
+	for _, a := range fields {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "HDel", varargs...)
+	ret0, _ := ret[0].(*redis.IntCmd)
+	return ret0
+}
+
+// HDel indicates an expected call of HDel
+func (mr *MockRedisClientMockRecorder) HDel(key interface{}, fields ...interface{}) *gomock.Call {
+	varargs := append([]interface{}{key}, fields...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HDel", reflect.TypeOf((*MockRedisClient)(nil).HDel), varargs...)
+}
+
 // Del mocks base method
 func (m *MockRedisClient) Del(keys ...string) *redis.IntCmd {
 	varargs := []interface{}{}
@@ -113,3 +130,32 @@ func (m *MockRedisClient) Del(keys ...string) *redis.IntCmd {
 func (mr *MockRedisClientMockRecorder) Del(keys ...interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Del", reflect.TypeOf((*MockRedisClient)(nil).Del), keys...)
 }
+
+// Eval mocks base method
+func (m *MockRedisClient) Eval(script string, keys []string, args ...interface{}) *redis.Cmd {
+	varargs := []interface{}{script, keys}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Eval", varargs...)
+	ret0, _ := ret[0].(*redis.Cmd)
+	return ret0
+}
+
+// Eval indicates an expected call of Eval
+func (mr *MockRedisClientMockRecorder) Eval(script, keys interface{}, args ...interface{}) *gomock.Call {
+	varargs := append([]interface{}{script, keys}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Eval", reflect.TypeOf((*MockRedisClient)(nil).Eval), varargs...)
+}
+
+// Ping mocks base method
+func (m *MockRedisClient) Ping() *redis.StatusCmd {
+	ret := m.ctrl.Call(m, "Ping")
+	ret0, _ := ret[0].(*redis.StatusCmd)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping
+func (mr *MockRedisClientMockRecorder) Ping() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockRedisClient)(nil).Ping))
+}
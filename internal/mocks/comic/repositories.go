@@ -10,6 +10,7 @@ import (
 	orm "github.com/go-pg/pg/orm"
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
+	time "time"
 )
 
 // MockORM is a mock of ORM interface
@@ -586,6 +587,19 @@ func (mr *MockCharacterSyncLogRepositoryMockRecorder) LastSyncs(id interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LastSyncs", reflect.TypeOf((*MockCharacterSyncLogRepository)(nil).LastSyncs), id)
 }
 
+// LastSuccess mocks base method
+func (m *MockCharacterSyncLogRepository) LastSuccess() (*comic.CharacterSyncLog, error) {
+	ret := m.ctrl.Call(m, "LastSuccess")
+	ret0, _ := ret[0].(*comic.CharacterSyncLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LastSuccess indicates an expected call of LastSuccess
+func (mr *MockCharacterSyncLogRepositoryMockRecorder) LastSuccess() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LastSuccess", reflect.TypeOf((*MockCharacterSyncLogRepository)(nil).LastSuccess))
+}
+
 // MockCharacterIssueRepository is a mock of CharacterIssueRepository interface
 type MockCharacterIssueRepository struct {
 	ctrl     *gomock.Controller
@@ -646,6 +660,19 @@ func (mr *MockCharacterIssueRepositoryMockRecorder) FindOneBy(characterID, issue
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindOneBy", reflect.TypeOf((*MockCharacterIssueRepository)(nil).FindOneBy), characterID, issueID)
 }
 
+// FindAll mocks base method
+func (m *MockCharacterIssueRepository) FindAll(cr comic.CharacterIssueCriteria) ([]*comic.CharacterIssue, error) {
+	ret := m.ctrl.Call(m, "FindAll", cr)
+	ret0, _ := ret[0].([]*comic.CharacterIssue)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAll indicates an expected call of FindAll
+func (mr *MockCharacterIssueRepositoryMockRecorder) FindAll(cr interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAll", reflect.TypeOf((*MockCharacterIssueRepository)(nil).FindAll), cr)
+}
+
 // InsertFast mocks base method
 func (m *MockCharacterIssueRepository) InsertFast(issues []*comic.CharacterIssue) error {
 	ret := m.ctrl.Call(m, "InsertFast", issues)
@@ -658,6 +685,30 @@ func (mr *MockCharacterIssueRepositoryMockRecorder) InsertFast(issues interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertFast", reflect.TypeOf((*MockCharacterIssueRepository)(nil).InsertFast), issues)
 }
 
+// UpdateImportance mocks base method
+func (m *MockCharacterIssueRepository) UpdateImportance(ci *comic.CharacterIssue) error {
+	ret := m.ctrl.Call(m, "UpdateImportance", ci)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateImportance indicates an expected call of UpdateImportance
+func (mr *MockCharacterIssueRepositoryMockRecorder) UpdateImportance(ci interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateImportance", reflect.TypeOf((*MockCharacterIssueRepository)(nil).UpdateImportance), ci)
+}
+
+// CreateExcluded mocks base method
+func (m *MockCharacterIssueRepository) CreateExcluded(issues []*comic.CharacterExcludedIssue) error {
+	ret := m.ctrl.Call(m, "CreateExcluded", issues)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateExcluded indicates an expected call of CreateExcluded
+func (mr *MockCharacterIssueRepositoryMockRecorder) CreateExcluded(issues interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateExcluded", reflect.TypeOf((*MockCharacterIssueRepository)(nil).CreateExcluded), issues)
+}
+
 // RemoveAllByCharacterID mocks base method
 func (m *MockCharacterIssueRepository) RemoveAllByCharacterID(id comic.CharacterID) (int, error) {
 	ret := m.ctrl.Call(m, "RemoveAllByCharacterID", id)
@@ -707,6 +758,42 @@ func (mr *MockAppearancesByYearsRepositoryMockRecorder) List(slugs interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockAppearancesByYearsRepository)(nil).List), slugs)
 }
 
+// MockAppearancesByMonthsRepository is a mock of AppearancesByMonthsRepository interface
+type MockAppearancesByMonthsRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAppearancesByMonthsRepositoryMockRecorder
+}
+
+// MockAppearancesByMonthsRepositoryMockRecorder is the mock recorder for MockAppearancesByMonthsRepository
+type MockAppearancesByMonthsRepositoryMockRecorder struct {
+	mock *MockAppearancesByMonthsRepository
+}
+
+// NewMockAppearancesByMonthsRepository creates a new mock instance
+func NewMockAppearancesByMonthsRepository(ctrl *gomock.Controller) *MockAppearancesByMonthsRepository {
+	mock := &MockAppearancesByMonthsRepository{ctrl: ctrl}
+	mock.recorder = &MockAppearancesByMonthsRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockAppearancesByMonthsRepository) EXPECT() *MockAppearancesByMonthsRepositoryMockRecorder {
+	return m.recorder
+}
+
+// List mocks base method
+func (m *MockAppearancesByMonthsRepository) List(slug comic.CharacterSlug) (comic.AppearancesByMonths, error) {
+	ret := m.ctrl.Call(m, "List", slug)
+	ret0, _ := ret[0].(comic.AppearancesByMonths)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List
+func (mr *MockAppearancesByMonthsRepositoryMockRecorder) List(slug interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockAppearancesByMonthsRepository)(nil).List), slug)
+}
+
 // MockAppearancesByYearsMapRepository is a mock of AppearancesByYearsMapRepository interface
 type MockAppearancesByYearsMapRepository struct {
 	ctrl     *gomock.Controller
@@ -731,13 +818,13 @@ func (m *MockAppearancesByYearsMapRepository) EXPECT() *MockAppearancesByYearsMa
 }
 
 // ListMap mocks base method
-func (m *MockAppearancesByYearsMapRepository) ListMap(slugs ...comic.CharacterSlug) (map[comic.CharacterSlug][]comic.AppearancesByYears, error) {
+func (m *MockAppearancesByYearsMapRepository) ListMap(slugs ...comic.CharacterSlug) (map[comic.CharacterSlug]comic.AppearancesByYears, error) {
 	varargs := []interface{}{}
 	for _, a := range slugs {
 		varargs = append(varargs, a)
 	}
 	ret := m.ctrl.Call(m, "ListMap", varargs...)
-	ret0, _ := ret[0].(map[comic.CharacterSlug][]comic.AppearancesByYears)
+	ret0, _ := ret[0].(map[comic.CharacterSlug]comic.AppearancesByYears)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -747,6 +834,42 @@ func (mr *MockAppearancesByYearsMapRepositoryMockRecorder) ListMap(slugs ...inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMap", reflect.TypeOf((*MockAppearancesByYearsMapRepository)(nil).ListMap), slugs...)
 }
 
+// MockSeriesRepository is a mock of SeriesRepository interface
+type MockSeriesRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSeriesRepositoryMockRecorder
+}
+
+// MockSeriesRepositoryMockRecorder is the mock recorder for MockSeriesRepository
+type MockSeriesRepositoryMockRecorder struct {
+	mock *MockSeriesRepository
+}
+
+// NewMockSeriesRepository creates a new mock instance
+func NewMockSeriesRepository(ctrl *gomock.Controller) *MockSeriesRepository {
+	mock := &MockSeriesRepository{ctrl: ctrl}
+	mock.recorder = &MockSeriesRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockSeriesRepository) EXPECT() *MockSeriesRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Series mocks base method
+func (m *MockSeriesRepository) Series(slug comic.CharacterSlug) (comic.CharacterSeries, error) {
+	ret := m.ctrl.Call(m, "Series", slug)
+	ret0, _ := ret[0].(comic.CharacterSeries)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Series indicates an expected call of Series
+func (mr *MockSeriesRepositoryMockRecorder) Series(slug interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Series", reflect.TypeOf((*MockSeriesRepository)(nil).Series), slug)
+}
+
 // MockStatsRepository is a mock of StatsRepository interface
 type MockStatsRepository struct {
 	ctrl     *gomock.Controller
@@ -819,95 +942,290 @@ func (mr *MockPopularRepositoryMockRecorder) All(cr interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "All", reflect.TypeOf((*MockPopularRepository)(nil).All), cr)
 }
 
-// DC mocks base method
-func (m *MockPopularRepository) DC(cr comic.PopularCriteria) ([]*comic.RankedCharacter, error) {
-	ret := m.ctrl.Call(m, "DC", cr)
+// Publisher mocks base method
+func (m *MockPopularRepository) Publisher(slug comic.PublisherSlug, cr comic.PopularCriteria) ([]*comic.RankedCharacter, error) {
+	ret := m.ctrl.Call(m, "Publisher", slug, cr)
 	ret0, _ := ret[0].([]*comic.RankedCharacter)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// DC indicates an expected call of DC
-func (mr *MockPopularRepositoryMockRecorder) DC(cr interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DC", reflect.TypeOf((*MockPopularRepository)(nil).DC), cr)
+// Publisher indicates an expected call of Publisher
+func (mr *MockPopularRepositoryMockRecorder) Publisher(slug, cr interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publisher", reflect.TypeOf((*MockPopularRepository)(nil).Publisher), slug, cr)
 }
 
-// Marvel mocks base method
-func (m *MockPopularRepository) Marvel(cr comic.PopularCriteria) ([]*comic.RankedCharacter, error) {
-	ret := m.ctrl.Call(m, "Marvel", cr)
-	ret0, _ := ret[0].([]*comic.RankedCharacter)
+// FindOneByPublisher mocks base method
+func (m *MockPopularRepository) FindOneByPublisher(slug comic.PublisherSlug, id comic.CharacterID) (*comic.RankedCharacter, error) {
+	ret := m.ctrl.Call(m, "FindOneByPublisher", slug, id)
+	ret0, _ := ret[0].(*comic.RankedCharacter)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// Marvel indicates an expected call of Marvel
-func (mr *MockPopularRepositoryMockRecorder) Marvel(cr interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Marvel", reflect.TypeOf((*MockPopularRepository)(nil).Marvel), cr)
+// FindOneByPublisher indicates an expected call of FindOneByPublisher
+func (mr *MockPopularRepositoryMockRecorder) FindOneByPublisher(slug, id interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindOneByPublisher", reflect.TypeOf((*MockPopularRepository)(nil).FindOneByPublisher), slug, id)
 }
 
-// FindOneByDC mocks base method
-func (m *MockPopularRepository) FindOneByDC(id comic.CharacterID) (*comic.RankedCharacter, error) {
-	ret := m.ctrl.Call(m, "FindOneByDC", id)
+// FindOneByAll mocks base method
+func (m *MockPopularRepository) FindOneByAll(id comic.CharacterID) (*comic.RankedCharacter, error) {
+	ret := m.ctrl.Call(m, "FindOneByAll", id)
 	ret0, _ := ret[0].(*comic.RankedCharacter)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// FindOneByDC indicates an expected call of FindOneByDC
-func (mr *MockPopularRepositoryMockRecorder) FindOneByDC(id interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindOneByDC", reflect.TypeOf((*MockPopularRepository)(nil).FindOneByDC), id)
+// FindOneByAll indicates an expected call of FindOneByAll
+func (mr *MockPopularRepositoryMockRecorder) FindOneByAll(id interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindOneByAll", reflect.TypeOf((*MockPopularRepository)(nil).FindOneByAll), id)
 }
 
-// FindOneByMarvel mocks base method
-func (m *MockPopularRepository) FindOneByMarvel(id comic.CharacterID) (*comic.RankedCharacter, error) {
-	ret := m.ctrl.Call(m, "FindOneByMarvel", id)
+// FindOneByWeighted mocks base method
+func (m *MockPopularRepository) FindOneByWeighted(id comic.CharacterID) (*comic.RankedCharacter, error) {
+	ret := m.ctrl.Call(m, "FindOneByWeighted", id)
 	ret0, _ := ret[0].(*comic.RankedCharacter)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// FindOneByMarvel indicates an expected call of FindOneByMarvel
-func (mr *MockPopularRepositoryMockRecorder) FindOneByMarvel(id interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindOneByMarvel", reflect.TypeOf((*MockPopularRepository)(nil).FindOneByMarvel), id)
+// FindOneByWeighted indicates an expected call of FindOneByWeighted
+func (mr *MockPopularRepositoryMockRecorder) FindOneByWeighted(id interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindOneByWeighted", reflect.TypeOf((*MockPopularRepository)(nil).FindOneByWeighted), id)
 }
 
-// FindOneByAll mocks base method
-func (m *MockPopularRepository) FindOneByAll(id comic.CharacterID) (*comic.RankedCharacter, error) {
-	ret := m.ctrl.Call(m, "FindOneByAll", id)
-	ret0, _ := ret[0].(*comic.RankedCharacter)
+// Trending mocks base method
+func (m *MockPopularRepository) Trending(slug comic.PublisherSlug, cr comic.TrendingCriteria) ([]*comic.RankedCharacter, error) {
+	ret := m.ctrl.Call(m, "Trending", slug, cr)
+	ret0, _ := ret[0].([]*comic.RankedCharacter)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// FindOneByAll indicates an expected call of FindOneByAll
-func (mr *MockPopularRepositoryMockRecorder) FindOneByAll(id interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindOneByAll", reflect.TypeOf((*MockPopularRepository)(nil).FindOneByAll), id)
+// Trending indicates an expected call of Trending
+func (mr *MockPopularRepositoryMockRecorder) Trending(slug, cr interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Trending", reflect.TypeOf((*MockPopularRepository)(nil).Trending), slug, cr)
 }
 
-// MarvelTrending mocks base method
-func (m *MockPopularRepository) MarvelTrending(limit, offset int) ([]*comic.RankedCharacter, error) {
-	ret := m.ctrl.Call(m, "MarvelTrending", limit, offset)
-	ret0, _ := ret[0].([]*comic.RankedCharacter)
+// Total mocks base method
+func (m *MockPopularRepository) Total(cr comic.PopularCriteria) (int, error) {
+	ret := m.ctrl.Call(m, "Total", cr)
+	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// MarvelTrending indicates an expected call of MarvelTrending
-func (mr *MockPopularRepositoryMockRecorder) MarvelTrending(limit, offset interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarvelTrending", reflect.TypeOf((*MockPopularRepository)(nil).MarvelTrending), limit, offset)
+// Total indicates an expected call of Total
+func (mr *MockPopularRepositoryMockRecorder) Total(cr interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Total", reflect.TypeOf((*MockPopularRepository)(nil).Total), cr)
 }
 
-// DCTrending mocks base method
-func (m *MockPopularRepository) DCTrending(limit, offset int) ([]*comic.RankedCharacter, error) {
-	ret := m.ctrl.Call(m, "DCTrending", limit, offset)
-	ret0, _ := ret[0].([]*comic.RankedCharacter)
+// PublisherTotal mocks base method
+func (m *MockPopularRepository) PublisherTotal(slug comic.PublisherSlug) (int, error) {
+	ret := m.ctrl.Call(m, "PublisherTotal", slug)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublisherTotal indicates an expected call of PublisherTotal
+func (mr *MockPopularRepositoryMockRecorder) PublisherTotal(slug interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublisherTotal", reflect.TypeOf((*MockPopularRepository)(nil).PublisherTotal), slug)
+}
+
+// LastRefreshed mocks base method
+func (m *MockPopularRepository) LastRefreshed() (time.Time, error) {
+	ret := m.ctrl.Call(m, "LastRefreshed")
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LastRefreshed indicates an expected call of LastRefreshed
+func (mr *MockPopularRepositoryMockRecorder) LastRefreshed() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LastRefreshed", reflect.TypeOf((*MockPopularRepository)(nil).LastRefreshed))
+}
+
+// MockCareerRepository is a mock of CareerRepository interface
+type MockCareerRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockCareerRepositoryMockRecorder
+}
+
+// MockCareerRepositoryMockRecorder is the mock recorder for MockCareerRepository
+type MockCareerRepositoryMockRecorder struct {
+	mock *MockCareerRepository
+}
+
+// NewMockCareerRepository creates a new mock instance
+func NewMockCareerRepository(ctrl *gomock.Controller) *MockCareerRepository {
+	mock := &MockCareerRepository{ctrl: ctrl}
+	mock.recorder = &MockCareerRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockCareerRepository) EXPECT() *MockCareerRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Career mocks base method
+func (m *MockCareerRepository) Career(id comic.CharacterID) (*comic.CareerStats, error) {
+	ret := m.ctrl.Call(m, "Career", id)
+	ret0, _ := ret[0].(*comic.CareerStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Career indicates an expected call of Career
+func (mr *MockCareerRepositoryMockRecorder) Career(id interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Career", reflect.TypeOf((*MockCareerRepository)(nil).Career), id)
+}
+
+// MockFormatMixRepository is a mock of FormatMixRepository interface
+type MockFormatMixRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockFormatMixRepositoryMockRecorder
+}
+
+// MockFormatMixRepositoryMockRecorder is the mock recorder for MockFormatMixRepository
+type MockFormatMixRepositoryMockRecorder struct {
+	mock *MockFormatMixRepository
+}
+
+// NewMockFormatMixRepository creates a new mock instance
+func NewMockFormatMixRepository(ctrl *gomock.Controller) *MockFormatMixRepository {
+	mock := &MockFormatMixRepository{ctrl: ctrl}
+	mock.recorder = &MockFormatMixRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockFormatMixRepository) EXPECT() *MockFormatMixRepositoryMockRecorder {
+	return m.recorder
+}
+
+// FormatMix mocks base method
+func (m *MockFormatMixRepository) FormatMix(slug comic.CharacterSlug) ([]comic.FormatBreakdown, error) {
+	ret := m.ctrl.Call(m, "FormatMix", slug)
+	ret0, _ := ret[0].([]comic.FormatBreakdown)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FormatMix indicates an expected call of FormatMix
+func (mr *MockFormatMixRepositoryMockRecorder) FormatMix(slug interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FormatMix", reflect.TypeOf((*MockFormatMixRepository)(nil).FormatMix), slug)
+}
+
+// MockCoAppearanceRepository is a mock of CoAppearanceRepository interface
+type MockCoAppearanceRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockCoAppearanceRepositoryMockRecorder
+}
+
+// MockCoAppearanceRepositoryMockRecorder is the mock recorder for MockCoAppearanceRepository
+type MockCoAppearanceRepositoryMockRecorder struct {
+	mock *MockCoAppearanceRepository
+}
+
+// NewMockCoAppearanceRepository creates a new mock instance
+func NewMockCoAppearanceRepository(ctrl *gomock.Controller) *MockCoAppearanceRepository {
+	mock := &MockCoAppearanceRepository{ctrl: ctrl}
+	mock.recorder = &MockCoAppearanceRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockCoAppearanceRepository) EXPECT() *MockCoAppearanceRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Partners mocks base method
+func (m *MockCoAppearanceRepository) Partners(id comic.CharacterID, cr comic.CoAppearanceCriteria) ([]*comic.CoAppearance, error) {
+	ret := m.ctrl.Call(m, "Partners", id, cr)
+	ret0, _ := ret[0].([]*comic.CoAppearance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Partners indicates an expected call of Partners
+func (mr *MockCoAppearanceRepositoryMockRecorder) Partners(id, cr interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Partners", reflect.TypeOf((*MockCoAppearanceRepository)(nil).Partners), id, cr)
+}
+
+// Graph mocks base method
+func (m *MockCoAppearanceRepository) Graph(cr comic.CoAppearanceGraphCriteria) (*comic.CoAppearanceGraph, error) {
+	ret := m.ctrl.Call(m, "Graph", cr)
+	ret0, _ := ret[0].(*comic.CoAppearanceGraph)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Graph indicates an expected call of Graph
+func (mr *MockCoAppearanceRepositoryMockRecorder) Graph(cr interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Graph", reflect.TypeOf((*MockCoAppearanceRepository)(nil).Graph), cr)
+}
+
+// MockRankSnapshotRepository is a mock of RankSnapshotRepository interface
+type MockRankSnapshotRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRankSnapshotRepositoryMockRecorder
+}
+
+// MockRankSnapshotRepositoryMockRecorder is the mock recorder for MockRankSnapshotRepository
+type MockRankSnapshotRepositoryMockRecorder struct {
+	mock *MockRankSnapshotRepository
+}
+
+// NewMockRankSnapshotRepository creates a new mock instance
+func NewMockRankSnapshotRepository(ctrl *gomock.Controller) *MockRankSnapshotRepository {
+	mock := &MockRankSnapshotRepository{ctrl: ctrl}
+	mock.recorder = &MockRankSnapshotRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockRankSnapshotRepository) EXPECT() *MockRankSnapshotRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Snapshot mocks base method
+func (m *MockRankSnapshotRepository) Snapshot(date time.Time) error {
+	ret := m.ctrl.Call(m, "Snapshot", date)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Snapshot indicates an expected call of Snapshot
+func (mr *MockRankSnapshotRepositoryMockRecorder) Snapshot(date interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Snapshot", reflect.TypeOf((*MockRankSnapshotRepository)(nil).Snapshot), date)
+}
+
+// History mocks base method
+func (m *MockRankSnapshotRepository) History(id comic.CharacterID, cat comic.CharacterStatsCategory) ([]*comic.RankSnapshot, error) {
+	ret := m.ctrl.Call(m, "History", id, cat)
+	ret0, _ := ret[0].([]*comic.RankSnapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// History indicates an expected call of History
+func (mr *MockRankSnapshotRepositoryMockRecorder) History(id, cat interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "History", reflect.TypeOf((*MockRankSnapshotRepository)(nil).History), id, cat)
+}
+
+// Prune mocks base method
+func (m *MockRankSnapshotRepository) Prune(dailyBefore, before time.Time) (int, error) {
+	ret := m.ctrl.Call(m, "Prune", dailyBefore, before)
+	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// DCTrending indicates an expected call of DCTrending
-func (mr *MockPopularRepositoryMockRecorder) DCTrending(limit, offset interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DCTrending", reflect.TypeOf((*MockPopularRepository)(nil).DCTrending), limit, offset)
+// Prune indicates an expected call of Prune
+func (mr *MockRankSnapshotRepositoryMockRecorder) Prune(dailyBefore, before interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Prune", reflect.TypeOf((*MockRankSnapshotRepository)(nil).Prune), dailyBefore, before)
 }
 
 // MockPopularRefresher is a mock of PopularRefresher interface
@@ -957,6 +1275,18 @@ func (mr *MockPopularRefresherMockRecorder) RefreshAll() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshAll", reflect.TypeOf((*MockPopularRefresher)(nil).RefreshAll))
 }
 
+// CreatePublisherViews mocks base method
+func (m *MockPopularRefresher) CreatePublisherViews(p *comic.Publisher) error {
+	ret := m.ctrl.Call(m, "CreatePublisherViews", p)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePublisherViews indicates an expected call of CreatePublisherViews
+func (mr *MockPopularRefresherMockRecorder) CreatePublisherViews(p interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePublisherViews", reflect.TypeOf((*MockPopularRefresher)(nil).CreatePublisherViews), p)
+}
+
 // MockCharacterThumbRepository is a mock of CharacterThumbRepository interface
 type MockCharacterThumbRepository struct {
 	ctrl     *gomock.Controller
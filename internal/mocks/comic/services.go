@@ -168,6 +168,19 @@ func (mr *MockCharacterServicerMockRecorder) Character(slug interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Character", reflect.TypeOf((*MockCharacterServicer)(nil).Character), slug)
 }
 
+// CharacterBySlug mocks base method
+func (m *MockCharacterServicer) CharacterBySlug(slug comic.CharacterSlug, includeIsDisabled bool) (*comic.Character, error) {
+	ret := m.ctrl.Call(m, "CharacterBySlug", slug, includeIsDisabled)
+	ret0, _ := ret[0].(*comic.Character)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CharacterBySlug indicates an expected call of CharacterBySlug
+func (mr *MockCharacterServicerMockRecorder) CharacterBySlug(slug, includeIsDisabled interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CharacterBySlug", reflect.TypeOf((*MockCharacterServicer)(nil).CharacterBySlug), slug, includeIsDisabled)
+}
+
 // Update mocks base method
 func (m *MockCharacterServicer) Update(character *comic.Character) error {
 	ret := m.ctrl.Call(m, "Update", character)
@@ -278,6 +291,18 @@ func (mr *MockCharacterServicerMockRecorder) MustNormalizeSources(arg0 interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MustNormalizeSources", reflect.TypeOf((*MockCharacterServicer)(nil).MustNormalizeSources), arg0)
 }
 
+// NormalizeSources mocks base method
+func (m *MockCharacterServicer) NormalizeSources(arg0 *comic.Character) error {
+	ret := m.ctrl.Call(m, "NormalizeSources", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// NormalizeSources indicates an expected call of NormalizeSources
+func (mr *MockCharacterServicerMockRecorder) NormalizeSources(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NormalizeSources", reflect.TypeOf((*MockCharacterServicer)(nil).NormalizeSources), arg0)
+}
+
 // Source mocks base method
 func (m *MockCharacterServicer) Source(id comic.CharacterID, vendorURL string) (*comic.CharacterSource, error) {
 	ret := m.ctrl.Call(m, "Source", id, vendorURL)
@@ -304,6 +329,19 @@ func (mr *MockCharacterServicerMockRecorder) Sources(id, vendorType, isMain inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sources", reflect.TypeOf((*MockCharacterServicer)(nil).Sources), id, vendorType, isMain)
 }
 
+// AllSources mocks base method
+func (m *MockCharacterServicer) AllSources(id comic.CharacterID) ([]*comic.CharacterSource, error) {
+	ret := m.ctrl.Call(m, "AllSources", id)
+	ret0, _ := ret[0].([]*comic.CharacterSource)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AllSources indicates an expected call of AllSources
+func (mr *MockCharacterServicerMockRecorder) AllSources(id interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllSources", reflect.TypeOf((*MockCharacterServicer)(nil).AllSources), id)
+}
+
 // TotalSources mocks base method
 func (m *MockCharacterServicer) TotalSources(id comic.CharacterID) (int64, error) {
 	ret := m.ctrl.Call(m, "TotalSources", id)
@@ -354,6 +392,30 @@ func (mr *MockCharacterServicerMockRecorder) CreateIssues(issues interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIssues", reflect.TypeOf((*MockCharacterServicer)(nil).CreateIssues), issues)
 }
 
+// UpdateIssueImportance mocks base method
+func (m *MockCharacterServicer) UpdateIssueImportance(issue *comic.CharacterIssue, importance *comic.Importance) error {
+	ret := m.ctrl.Call(m, "UpdateIssueImportance", issue, importance)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateIssueImportance indicates an expected call of UpdateIssueImportance
+func (mr *MockCharacterServicerMockRecorder) UpdateIssueImportance(issue, importance interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateIssueImportance", reflect.TypeOf((*MockCharacterServicer)(nil).UpdateIssueImportance), issue, importance)
+}
+
+// ExcludeIssues mocks base method
+func (m *MockCharacterServicer) ExcludeIssues(issues []*comic.CharacterExcludedIssue) error {
+	ret := m.ctrl.Call(m, "ExcludeIssues", issues)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExcludeIssues indicates an expected call of ExcludeIssues
+func (mr *MockCharacterServicerMockRecorder) ExcludeIssues(issues interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExcludeIssues", reflect.TypeOf((*MockCharacterServicer)(nil).ExcludeIssues), issues)
+}
+
 // Issue mocks base method
 func (m *MockCharacterServicer) Issue(characterID comic.CharacterID, issueID comic.IssueID) (*comic.CharacterIssue, error) {
 	ret := m.ctrl.Call(m, "Issue", characterID, issueID)
@@ -367,6 +429,19 @@ func (mr *MockCharacterServicerMockRecorder) Issue(characterID, issueID interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Issue", reflect.TypeOf((*MockCharacterServicer)(nil).Issue), characterID, issueID)
 }
 
+// Issues mocks base method
+func (m *MockCharacterServicer) Issues(cr comic.CharacterIssueCriteria) ([]*comic.CharacterIssue, error) {
+	ret := m.ctrl.Call(m, "Issues", cr)
+	ret0, _ := ret[0].([]*comic.CharacterIssue)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Issues indicates an expected call of Issues
+func (mr *MockCharacterServicerMockRecorder) Issues(cr interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Issues", reflect.TypeOf((*MockCharacterServicer)(nil).Issues), cr)
+}
+
 // RemoveIssues mocks base method
 func (m *MockCharacterServicer) RemoveIssues(ids ...comic.CharacterID) (int, error) {
 	varargs := []interface{}{}
@@ -421,6 +496,19 @@ func (mr *MockCharacterServicerMockRecorder) UpdateSyncLog(syncLog interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSyncLog", reflect.TypeOf((*MockCharacterServicer)(nil).UpdateSyncLog), syncLog)
 }
 
+// LastSuccessfulSync mocks base method
+func (m *MockCharacterServicer) LastSuccessfulSync() (*comic.CharacterSyncLog, error) {
+	ret := m.ctrl.Call(m, "LastSuccessfulSync")
+	ret0, _ := ret[0].(*comic.CharacterSyncLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LastSuccessfulSync indicates an expected call of LastSuccessfulSync
+func (mr *MockCharacterServicerMockRecorder) LastSuccessfulSync() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LastSuccessfulSync", reflect.TypeOf((*MockCharacterServicer)(nil).LastSuccessfulSync))
+}
+
 // MockRankedServicer is a mock of RankedServicer interface
 type MockRankedServicer struct {
 	ctrl     *gomock.Controller
@@ -457,56 +545,108 @@ func (mr *MockRankedServicerMockRecorder) AllPopular(cr interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllPopular", reflect.TypeOf((*MockRankedServicer)(nil).AllPopular), cr)
 }
 
-// DCPopular mocks base method
-func (m *MockRankedServicer) DCPopular(cr comic.PopularCriteria) ([]*comic.RankedCharacter, error) {
-	ret := m.ctrl.Call(m, "DCPopular", cr)
+// PublisherPopular mocks base method
+func (m *MockRankedServicer) PublisherPopular(slug comic.PublisherSlug, cr comic.PopularCriteria) ([]*comic.RankedCharacter, error) {
+	ret := m.ctrl.Call(m, "PublisherPopular", slug, cr)
 	ret0, _ := ret[0].([]*comic.RankedCharacter)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// DCPopular indicates an expected call of DCPopular
-func (mr *MockRankedServicerMockRecorder) DCPopular(cr interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DCPopular", reflect.TypeOf((*MockRankedServicer)(nil).DCPopular), cr)
+// PublisherPopular indicates an expected call of PublisherPopular
+func (mr *MockRankedServicerMockRecorder) PublisherPopular(slug, cr interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublisherPopular", reflect.TypeOf((*MockRankedServicer)(nil).PublisherPopular), slug, cr)
 }
 
-// MarvelPopular mocks base method
-func (m *MockRankedServicer) MarvelPopular(cr comic.PopularCriteria) ([]*comic.RankedCharacter, error) {
-	ret := m.ctrl.Call(m, "MarvelPopular", cr)
+// Trending mocks base method
+func (m *MockRankedServicer) Trending(slug comic.PublisherSlug, cr comic.TrendingCriteria) ([]*comic.RankedCharacter, error) {
+	ret := m.ctrl.Call(m, "Trending", slug, cr)
 	ret0, _ := ret[0].([]*comic.RankedCharacter)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// MarvelPopular indicates an expected call of MarvelPopular
-func (mr *MockRankedServicerMockRecorder) MarvelPopular(cr interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarvelPopular", reflect.TypeOf((*MockRankedServicer)(nil).MarvelPopular), cr)
+// Trending indicates an expected call of Trending
+func (mr *MockRankedServicerMockRecorder) Trending(slug, cr interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Trending", reflect.TypeOf((*MockRankedServicer)(nil).Trending), slug, cr)
 }
 
-// MarvelTrending mocks base method
-func (m *MockRankedServicer) MarvelTrending(limit, offset int) ([]*comic.RankedCharacter, error) {
-	ret := m.ctrl.Call(m, "MarvelTrending", limit, offset)
-	ret0, _ := ret[0].([]*comic.RankedCharacter)
+// AllPopularTotal mocks base method
+func (m *MockRankedServicer) AllPopularTotal(cr comic.PopularCriteria) (int, error) {
+	ret := m.ctrl.Call(m, "AllPopularTotal", cr)
+	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// MarvelTrending indicates an expected call of MarvelTrending
-func (mr *MockRankedServicerMockRecorder) MarvelTrending(limit, offset interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarvelTrending", reflect.TypeOf((*MockRankedServicer)(nil).MarvelTrending), limit, offset)
+// AllPopularTotal indicates an expected call of AllPopularTotal
+func (mr *MockRankedServicerMockRecorder) AllPopularTotal(cr interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllPopularTotal", reflect.TypeOf((*MockRankedServicer)(nil).AllPopularTotal), cr)
 }
 
-// DCTrending mocks base method
-func (m *MockRankedServicer) DCTrending(limit, offset int) ([]*comic.RankedCharacter, error) {
-	ret := m.ctrl.Call(m, "DCTrending", limit, offset)
-	ret0, _ := ret[0].([]*comic.RankedCharacter)
+// PublisherPopularTotal mocks base method
+func (m *MockRankedServicer) PublisherPopularTotal(slug comic.PublisherSlug) (int, error) {
+	ret := m.ctrl.Call(m, "PublisherPopularTotal", slug)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublisherPopularTotal indicates an expected call of PublisherPopularTotal
+func (mr *MockRankedServicerMockRecorder) PublisherPopularTotal(slug interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublisherPopularTotal", reflect.TypeOf((*MockRankedServicer)(nil).PublisherPopularTotal), slug)
+}
+
+// LastRefreshed mocks base method
+func (m *MockRankedServicer) LastRefreshed() (time.Time, error) {
+	ret := m.ctrl.Call(m, "LastRefreshed")
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LastRefreshed indicates an expected call of LastRefreshed
+func (mr *MockRankedServicerMockRecorder) LastRefreshed() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LastRefreshed", reflect.TypeOf((*MockRankedServicer)(nil).LastRefreshed))
+}
+
+// RankHistory mocks base method
+func (m *MockRankedServicer) RankHistory(id comic.CharacterID, cat comic.CharacterStatsCategory) ([]*comic.RankSnapshot, error) {
+	ret := m.ctrl.Call(m, "RankHistory", id, cat)
+	ret0, _ := ret[0].([]*comic.RankSnapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RankHistory indicates an expected call of RankHistory
+func (mr *MockRankedServicerMockRecorder) RankHistory(id, cat interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RankHistory", reflect.TypeOf((*MockRankedServicer)(nil).RankHistory), id, cat)
+}
+
+// CoAppearances mocks base method
+func (m *MockRankedServicer) CoAppearances(id comic.CharacterID, cr comic.CoAppearanceCriteria) ([]*comic.CoAppearance, error) {
+	ret := m.ctrl.Call(m, "CoAppearances", id, cr)
+	ret0, _ := ret[0].([]*comic.CoAppearance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CoAppearances indicates an expected call of CoAppearances
+func (mr *MockRankedServicerMockRecorder) CoAppearances(id, cr interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CoAppearances", reflect.TypeOf((*MockRankedServicer)(nil).CoAppearances), id, cr)
+}
+
+// CoAppearanceGraph mocks base method
+func (m *MockRankedServicer) CoAppearanceGraph(cr comic.CoAppearanceGraphCriteria) (*comic.CoAppearanceGraph, error) {
+	ret := m.ctrl.Call(m, "CoAppearanceGraph", cr)
+	ret0, _ := ret[0].(*comic.CoAppearanceGraph)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// DCTrending indicates an expected call of DCTrending
-func (mr *MockRankedServicerMockRecorder) DCTrending(limit, offset interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DCTrending", reflect.TypeOf((*MockRankedServicer)(nil).DCTrending), limit, offset)
+// CoAppearanceGraph indicates an expected call of CoAppearanceGraph
+func (mr *MockRankedServicerMockRecorder) CoAppearanceGraph(cr interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CoAppearanceGraph", reflect.TypeOf((*MockRankedServicer)(nil).CoAppearanceGraph), cr)
 }
 
 // MockExpandedServicer is a mock of ExpandedServicer interface
@@ -545,6 +685,49 @@ func (mr *MockExpandedServicerMockRecorder) Character(slug interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Character", reflect.TypeOf((*MockExpandedServicer)(nil).Character), slug)
 }
 
+// Compare mocks base method
+func (m *MockExpandedServicer) Compare(slugs ...comic.CharacterSlug) (*comic.Comparison, error) {
+	varargs := []interface{}{}
+	for _, a := range slugs {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Compare", varargs...)
+	ret0, _ := ret[0].(*comic.Comparison)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Compare indicates an expected call of Compare
+func (mr *MockExpandedServicerMockRecorder) Compare(slugs ...interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Compare", reflect.TypeOf((*MockExpandedServicer)(nil).Compare), slugs...)
+}
+
+// Series mocks base method
+func (m *MockExpandedServicer) Series(slug comic.CharacterSlug) (*comic.CharacterSeries, error) {
+	ret := m.ctrl.Call(m, "Series", slug)
+	ret0, _ := ret[0].(*comic.CharacterSeries)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Series indicates an expected call of Series
+func (mr *MockExpandedServicerMockRecorder) Series(slug interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Series", reflect.TypeOf((*MockExpandedServicer)(nil).Series), slug)
+}
+
+// MonthlyAppearances mocks base method
+func (m *MockExpandedServicer) MonthlyAppearances(slug comic.CharacterSlug) (*comic.AppearancesByMonths, error) {
+	ret := m.ctrl.Call(m, "MonthlyAppearances", slug)
+	ret0, _ := ret[0].(*comic.AppearancesByMonths)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MonthlyAppearances indicates an expected call of MonthlyAppearances
+func (mr *MockExpandedServicerMockRecorder) MonthlyAppearances(slug interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MonthlyAppearances", reflect.TypeOf((*MockExpandedServicer)(nil).MonthlyAppearances), slug)
+}
+
 // MockCharacterThumbServicer is a mock of CharacterThumbServicer interface
 type MockCharacterThumbServicer struct {
 	ctrl     *gomock.Controller
@@ -95,6 +95,150 @@ func (mr *MockAppearancesByYearsWriterMockRecorder) Delete(slug interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockAppearancesByYearsWriter)(nil).Delete), slug)
 }
 
+// MockAppearancesByMonthsWriter is a mock of AppearancesByMonthsWriter interface
+type MockAppearancesByMonthsWriter struct {
+	ctrl     *gomock.Controller
+	recorder *MockAppearancesByMonthsWriterMockRecorder
+}
+
+// MockAppearancesByMonthsWriterMockRecorder is the mock recorder for MockAppearancesByMonthsWriter
+type MockAppearancesByMonthsWriterMockRecorder struct {
+	mock *MockAppearancesByMonthsWriter
+}
+
+// NewMockAppearancesByMonthsWriter creates a new mock instance
+func NewMockAppearancesByMonthsWriter(ctrl *gomock.Controller) *MockAppearancesByMonthsWriter {
+	mock := &MockAppearancesByMonthsWriter{ctrl: ctrl}
+	mock.recorder = &MockAppearancesByMonthsWriterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockAppearancesByMonthsWriter) EXPECT() *MockAppearancesByMonthsWriterMockRecorder {
+	return m.recorder
+}
+
+// Set mocks base method
+func (m *MockAppearancesByMonthsWriter) Set(apps comic.AppearancesByMonths) error {
+	ret := m.ctrl.Call(m, "Set", apps)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Set indicates an expected call of Set
+func (mr *MockAppearancesByMonthsWriterMockRecorder) Set(apps interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockAppearancesByMonthsWriter)(nil).Set), apps)
+}
+
+// Delete mocks base method
+func (m *MockAppearancesByMonthsWriter) Delete(slug comic.CharacterSlug) (int64, error) {
+	ret := m.ctrl.Call(m, "Delete", slug)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Delete indicates an expected call of Delete
+func (mr *MockAppearancesByMonthsWriterMockRecorder) Delete(slug interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockAppearancesByMonthsWriter)(nil).Delete), slug)
+}
+
+// MockSeriesWriter is a mock of SeriesWriter interface
+type MockSeriesWriter struct {
+	ctrl     *gomock.Controller
+	recorder *MockSeriesWriterMockRecorder
+}
+
+// MockSeriesWriterMockRecorder is the mock recorder for MockSeriesWriter
+type MockSeriesWriterMockRecorder struct {
+	mock *MockSeriesWriter
+}
+
+// NewMockSeriesWriter creates a new mock instance
+func NewMockSeriesWriter(ctrl *gomock.Controller) *MockSeriesWriter {
+	mock := &MockSeriesWriter{ctrl: ctrl}
+	mock.recorder = &MockSeriesWriterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockSeriesWriter) EXPECT() *MockSeriesWriterMockRecorder {
+	return m.recorder
+}
+
+// Set mocks base method
+func (m *MockSeriesWriter) Set(series comic.CharacterSeries) error {
+	ret := m.ctrl.Call(m, "Set", series)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Set indicates an expected call of Set
+func (mr *MockSeriesWriterMockRecorder) Set(series interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockSeriesWriter)(nil).Set), series)
+}
+
+// Delete mocks base method
+func (m *MockSeriesWriter) Delete(slug comic.CharacterSlug) (int64, error) {
+	ret := m.ctrl.Call(m, "Delete", slug)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Delete indicates an expected call of Delete
+func (mr *MockSeriesWriterMockRecorder) Delete(slug interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockSeriesWriter)(nil).Delete), slug)
+}
+
+// MockFormatMixWriter is a mock of FormatMixWriter interface
+type MockFormatMixWriter struct {
+	ctrl     *gomock.Controller
+	recorder *MockFormatMixWriterMockRecorder
+}
+
+// MockFormatMixWriterMockRecorder is the mock recorder for MockFormatMixWriter
+type MockFormatMixWriterMockRecorder struct {
+	mock *MockFormatMixWriter
+}
+
+// NewMockFormatMixWriter creates a new mock instance
+func NewMockFormatMixWriter(ctrl *gomock.Controller) *MockFormatMixWriter {
+	mock := &MockFormatMixWriter{ctrl: ctrl}
+	mock.recorder = &MockFormatMixWriterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockFormatMixWriter) EXPECT() *MockFormatMixWriterMockRecorder {
+	return m.recorder
+}
+
+// Set mocks base method
+func (m *MockFormatMixWriter) Set(slug comic.CharacterSlug, mix []comic.FormatBreakdown) error {
+	ret := m.ctrl.Call(m, "Set", slug, mix)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Set indicates an expected call of Set
+func (mr *MockFormatMixWriterMockRecorder) Set(slug, mix interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockFormatMixWriter)(nil).Set), slug, mix)
+}
+
+// Delete mocks base method
+func (m *MockFormatMixWriter) Delete(slug comic.CharacterSlug) (int64, error) {
+	ret := m.ctrl.Call(m, "Delete", slug)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Delete indicates an expected call of Delete
+func (mr *MockFormatMixWriterMockRecorder) Delete(slug interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockFormatMixWriter)(nil).Delete), slug)
+}
+
 // MockCharacterStatsSyncer is a mock of CharacterStatsSyncer interface
 type MockCharacterStatsSyncer struct {
 	ctrl     *gomock.Controller
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/comiccruncher/comiccruncher/internal/log"
+	"github.com/comiccruncher/comiccruncher/internal/metrics"
 	"github.com/go-pg/pg"
 	"go.uber.org/zap"
 	"os"
@@ -82,6 +83,9 @@ func DB(config *Configuration) (*pg.DB, error) {
 			Database: config.Database,
 		},
 	)
+	db.OnQueryProcessed(func(event *pg.QueryProcessedEvent) {
+		metrics.ObserveQuery(event.Func, time.Since(event.StartTime))
+	})
 	var queryError error
 	if config.LogMode {
 		db.OnQueryProcessed(
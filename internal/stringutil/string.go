@@ -6,6 +6,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 const randCharMap = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ01234567890"
@@ -88,6 +90,34 @@ func AnyFunc(s string, strs []string, f func(s, substr string) bool) bool {
 	return false
 }
 
+// ContainsWord checks if the `word` is in the `s` string as a whole word, so it isn't next to another letter or
+// digit. Case sensitive.
+func ContainsWord(s, word string) bool {
+	if word == "" {
+		return false
+	}
+	for i := 0; i < len(s); {
+		idx := strings.Index(s[i:], word)
+		if idx < 0 {
+			return false
+		}
+		start := i + idx
+		before, _ := utf8.DecodeLastRuneInString(s[:start])
+		after, _ := utf8.DecodeRuneInString(s[start+len(word):])
+		if !isWordRune(before) && !isWordRune(after) {
+			return true
+		}
+		_, size := utf8.DecodeRuneInString(s[start:])
+		i = start + size
+	}
+	return false
+}
+
+// Checks if the rune is part of a word.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
 // MustAtoi converts an `s` string using `strconv.Atoi` but panics if there's an error.
 func MustAtoi(s string) int {
 	res, err := strconv.Atoi(s)
@@ -53,6 +53,19 @@ func TestContains(t *testing.T) {
 	assert.True(t, AnyFunc("", []string{""}, strings.Contains))
 }
 
+func TestContainsWord(t *testing.T) {
+	assert.True(t, ContainsWord("storm", "storm"))
+	assert.True(t, ContainsWord("storm & the x-men", "storm"))
+	assert.True(t, ContainsWord("x-men: storm", "storm"))
+	assert.True(t, ContainsWord("the amazing spider-man", "spider-man"))
+	assert.True(t, ContainsWord("brainstorm vs. storm", "storm"))
+	assert.False(t, ContainsWord("brainstorm", "storm"))
+	assert.False(t, ContainsWord("stormwatch", "storm"))
+	assert.False(t, ContainsWord("x-men 2099", "x-men 20"))
+	assert.False(t, ContainsWord("storm", ""))
+	assert.False(t, ContainsWord("", "storm"))
+}
+
 func TestMustAtoi(t *testing.T) {
 	f := func() {
 		MustAtoi("1:")
@@ -0,0 +1,223 @@
+package web
+
+import (
+	"github.com/comiccruncher/comiccruncher/comic"
+	"github.com/labstack/echo/v4"
+	"strconv"
+	"strings"
+)
+
+// AdminCharacter is a character with the fields only admins can see.
+type AdminCharacter struct {
+	*comic.Character
+	IsDisabled bool                     `json:"is_disabled"`
+	Sources    []*comic.CharacterSource `json:"sources"`
+}
+
+// CharacterPatch is the request body for editing a character. Only the fields that are present get updated.
+type CharacterPatch struct {
+	IsDisabled  *bool   `json:"is_disabled"`
+	Name        *string `json:"name"`
+	OtherName   *string `json:"other_name"`
+	Description *string `json:"description"`
+}
+
+// CharacterSourcePatch is the request body for editing a character source. Only the fields that are present get updated.
+type CharacterSourcePatch struct {
+	IsDisabled *bool `json:"is_disabled"`
+	IsMain     *bool `json:"is_main"`
+}
+
+// CharacterIssuePatch is the request body for overriding a character's importance in an issue. A null importance
+// clears it, so the importer guesses it again on the next import.
+type CharacterIssuePatch struct {
+	Importance *comic.Importance `json:"importance"`
+}
+
+// SyncResult is the result of re-syncing a character's appearances and stats.
+type SyncResult struct {
+	Slug   comic.CharacterSlug `json:"slug"`
+	Issues int                 `json:"issues"`
+}
+
+// AdminController is the controller for curating characters and their sources.
+type AdminController struct {
+	characterSvc comic.CharacterServicer
+	syncer       comic.Syncer
+	statsSyncer  comic.CharacterStatsSyncer
+}
+
+// Character gets a character, even if it's disabled, along with all its sources.
+func (c AdminController) Character(ctx echo.Context) error {
+	character, err := c.findCharacter(ctx)
+	if err != nil {
+		return err
+	}
+	return c.detail(ctx, character)
+}
+
+// UpdateCharacter toggles whether the character is disabled or edits its name, other name, or description.
+func (c AdminController) UpdateCharacter(ctx echo.Context) error {
+	character, err := c.findCharacter(ctx)
+	if err != nil {
+		return err
+	}
+	var patch CharacterPatch
+	if err := ctx.Bind(&patch); err != nil {
+		return NewBadRequestError("Invalid request body")
+	}
+	if patch.Name != nil {
+		name := strings.TrimSpace(*patch.Name)
+		if name == "" {
+			return NewBadRequestError("The name can't be blank.")
+		}
+		character.Name = name
+	}
+	if patch.OtherName != nil {
+		character.OtherName = strings.TrimSpace(*patch.OtherName)
+	}
+	if patch.Description != nil {
+		character.Description = *patch.Description
+	}
+	if patch.IsDisabled != nil {
+		character.IsDisabled = *patch.IsDisabled
+	}
+	if err := c.characterSvc.Update(character); err != nil {
+		return err
+	}
+	return c.detail(ctx, character)
+}
+
+// UpdateSource toggles whether the character's source is disabled or is a main source.
+func (c AdminController) UpdateSource(ctx echo.Context) error {
+	character, err := c.findCharacter(ctx)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return NewBadRequestError("Invalid source ID")
+	}
+	sources, err := c.characterSvc.AllSources(character.ID)
+	if err != nil {
+		return err
+	}
+	var source *comic.CharacterSource
+	for _, s := range sources {
+		if uint64(s.ID) == id {
+			source = s
+		}
+	}
+	if source == nil {
+		return NewNotFoundError("The source could not be found.")
+	}
+	var patch CharacterSourcePatch
+	if err := ctx.Bind(&patch); err != nil {
+		return NewBadRequestError("Invalid request body")
+	}
+	if patch.IsDisabled != nil {
+		source.IsDisabled = *patch.IsDisabled
+	}
+	if patch.IsMain != nil {
+		source.IsMain = *patch.IsMain
+	}
+	if err := c.characterSvc.UpdateSource(source); err != nil {
+		return err
+	}
+	return JSONDetailViewOK(ctx, source)
+}
+
+// UpdateIssue overrides the character's importance in the issue. The issue's ID is the `issue_id` from the
+// character's issues. The weighted rankings don't need the views refreshed to change.
+func (c AdminController) UpdateIssue(ctx echo.Context) error {
+	character, err := c.findCharacter(ctx)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return NewBadRequestError("Invalid issue ID")
+	}
+	issue, err := c.characterSvc.Issue(character.ID, comic.IssueID(id))
+	if err != nil {
+		return err
+	}
+	if issue == nil {
+		return NewNotFoundError("The character's issue could not be found.")
+	}
+	var patch CharacterIssuePatch
+	if err := ctx.Bind(&patch); err != nil {
+		return NewBadRequestError("Invalid request body. The importance must be cameo, minor, major or null.")
+	}
+	if err := c.characterSvc.UpdateIssueImportance(issue, patch.Importance); err != nil {
+		return err
+	}
+	return JSONDetailViewOK(ctx, issue)
+}
+
+// NormalizeSources re-categorizes the character's main and alternate sources and disables any unneeded sources.
+func (c AdminController) NormalizeSources(ctx echo.Context) error {
+	character, err := c.findCharacter(ctx)
+	if err != nil {
+		return err
+	}
+	if err := c.characterSvc.NormalizeSources(character); err != nil {
+		return err
+	}
+	return c.detail(ctx, character)
+}
+
+// Sync re-syncs the character's appearances and ranking stats from Postgres to Redis.
+// The materialized views aren't refreshed, so the stats are as of the last refresh.
+func (c AdminController) Sync(ctx echo.Context) error {
+	character, err := c.findCharacter(ctx)
+	if err != nil {
+		return err
+	}
+	if character.IsDisabled {
+		return NewBadRequestError("A disabled character can't be synced.")
+	}
+	total, err := c.syncer.Sync(character.Slug)
+	if err != nil {
+		return err
+	}
+	if err := c.statsSyncer.Sync(character.Slug); err != nil {
+		return err
+	}
+	return JSONDetailViewOK(ctx, SyncResult{Slug: character.Slug, Issues: total})
+}
+
+// Finds the character from the `slug` parameter, including disabled characters.
+// Returns a not found error if it doesn't exist.
+func (c AdminController) findCharacter(ctx echo.Context) (*comic.Character, error) {
+	character, err := c.characterSvc.CharacterBySlug(comic.CharacterSlug(ctx.Param("slug")), true)
+	if err != nil {
+		return nil, err
+	}
+	if character == nil {
+		return nil, NewNotFoundError("The character could not be found.")
+	}
+	return character, nil
+}
+
+// Responds with the character and all its sources.
+func (c AdminController) detail(ctx echo.Context, character *comic.Character) error {
+	sources, err := c.characterSvc.AllSources(character.ID)
+	if err != nil {
+		return err
+	}
+	return JSONDetailViewOK(ctx, AdminCharacter{
+		Character:  character,
+		IsDisabled: character.IsDisabled,
+		Sources:    sources,
+	})
+}
+
+// NewAdminController creates a new admin controller.
+func NewAdminController(cs comic.CharacterServicer, s comic.Syncer, ss comic.CharacterStatsSyncer) *AdminController {
+	return &AdminController{
+		characterSvc: cs,
+		syncer:       s,
+		statsSyncer:  ss,
+	}
+}
@@ -0,0 +1,268 @@
+package web_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/comiccruncher/comiccruncher/comic"
+	"github.com/comiccruncher/comiccruncher/internal/mocks/comic"
+	"github.com/comiccruncher/comiccruncher/web"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newAdminContext(method, target, body string, params ...string) (echo.Context, *httptest.ResponseRecorder) {
+	e := echo.New()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	var names, values []string
+	for i := 0; i < len(params); i += 2 {
+		names = append(names, params[i])
+		values = append(values, params[i+1])
+	}
+	c.SetParamNames(names...)
+	c.SetParamValues(values...)
+	return c, rec
+}
+
+func TestAdminControllerCharacter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ch := mockCharacter()
+	ch.IsDisabled = true
+	cs := mock_comic.NewMockCharacterServicer(ctrl)
+	cs.EXPECT().CharacterBySlug(comic.CharacterSlug("emma-frost"), true).Return(ch, nil)
+	cs.EXPECT().AllSources(ch.ID).Return([]*comic.CharacterSource{{ID: 2, CharacterID: ch.ID, IsDisabled: true}}, nil)
+
+	c, rec := newAdminContext(http.MethodGet, "/admin/characters/emma-frost", "", "slug", "emma-frost")
+	err := web.NewAdminController(cs, nil, nil).Character(c)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"is_disabled": true`)
+	assert.Contains(t, rec.Body.String(), `"sources": [`)
+}
+
+func TestAdminControllerCharacterNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cs := mock_comic.NewMockCharacterServicer(ctrl)
+	cs.EXPECT().CharacterBySlug(comic.CharacterSlug("nope"), true).Return(nil, nil)
+
+	c, _ := newAdminContext(http.MethodGet, "/admin/characters/nope", "", "slug", "nope")
+	err := web.NewAdminController(cs, nil, nil).Character(c)
+	assert.Equal(t, http.StatusNotFound, err.(*echo.HTTPError).Code)
+}
+
+func TestAdminControllerUpdateCharacter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ch := mockCharacter()
+	cs := mock_comic.NewMockCharacterServicer(ctrl)
+	cs.EXPECT().CharacterBySlug(comic.CharacterSlug("emma-frost"), true).Return(ch, nil)
+	cs.EXPECT().Update(gomock.Any()).Do(func(c *comic.Character) {
+		assert.True(t, c.IsDisabled)
+		assert.Equal(t, "Emma Frost", c.Name)
+		assert.Equal(t, "White Queen", c.OtherName)
+		assert.Equal(t, "Blah", c.Description)
+	}).Return(nil)
+	cs.EXPECT().AllSources(ch.ID).Return(nil, nil)
+
+	c, rec := newAdminContext(http.MethodPatch, "/admin/characters/emma-frost", `{"is_disabled": true, "name": " Emma Frost ", "other_name": "White Queen"}`, "slug", "emma-frost")
+	err := web.NewAdminController(cs, nil, nil).UpdateCharacter(c)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestAdminControllerUpdateCharacterBlankName(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cs := mock_comic.NewMockCharacterServicer(ctrl)
+	cs.EXPECT().CharacterBySlug(comic.CharacterSlug("emma-frost"), true).Return(mockCharacter(), nil)
+	cs.EXPECT().Update(gomock.Any()).Times(0)
+
+	c, _ := newAdminContext(http.MethodPatch, "/admin/characters/emma-frost", `{"name": " "}`, "slug", "emma-frost")
+	err := web.NewAdminController(cs, nil, nil).UpdateCharacter(c)
+	assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+}
+
+func TestAdminControllerUpdateSource(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ch := mockCharacter()
+	cs := mock_comic.NewMockCharacterServicer(ctrl)
+	cs.EXPECT().CharacterBySlug(comic.CharacterSlug("emma-frost"), true).Return(ch, nil)
+	cs.EXPECT().AllSources(ch.ID).Return([]*comic.CharacterSource{
+		{ID: 1, CharacterID: ch.ID},
+		{ID: 2, CharacterID: ch.ID, IsDisabled: true},
+	}, nil)
+	cs.EXPECT().UpdateSource(gomock.Any()).Do(func(s *comic.CharacterSource) {
+		assert.Equal(t, comic.CharacterSourceID(2), s.ID)
+		assert.False(t, s.IsDisabled)
+		assert.True(t, s.IsMain)
+	}).Return(nil)
+
+	c, rec := newAdminContext(http.MethodPatch, "/admin/characters/emma-frost/sources/2", `{"is_disabled": false, "is_main": true}`, "slug", "emma-frost", "id", "2")
+	err := web.NewAdminController(cs, nil, nil).UpdateSource(c)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestAdminControllerUpdateSourceNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ch := mockCharacter()
+	cs := mock_comic.NewMockCharacterServicer(ctrl)
+	cs.EXPECT().CharacterBySlug(comic.CharacterSlug("emma-frost"), true).Return(ch, nil)
+	cs.EXPECT().AllSources(ch.ID).Return([]*comic.CharacterSource{{ID: 1, CharacterID: ch.ID}}, nil)
+	cs.EXPECT().UpdateSource(gomock.Any()).Times(0)
+
+	c, _ := newAdminContext(http.MethodPatch, "/admin/characters/emma-frost/sources/3", `{"is_main": true}`, "slug", "emma-frost", "id", "3")
+	err := web.NewAdminController(cs, nil, nil).UpdateSource(c)
+	assert.Equal(t, http.StatusNotFound, err.(*echo.HTTPError).Code)
+}
+
+func TestAdminControllerUpdateIssue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ch := mockCharacter()
+	cs := mock_comic.NewMockCharacterServicer(ctrl)
+	cs.EXPECT().CharacterBySlug(comic.CharacterSlug("emma-frost"), true).Return(ch, nil)
+	ci := &comic.CharacterIssue{ID: 3, CharacterID: ch.ID, IssueID: 7, AppearanceType: comic.Main}
+	cs.EXPECT().Issue(ch.ID, comic.IssueID(7)).Return(ci, nil)
+	cs.EXPECT().UpdateIssueImportance(ci, gomock.Any()).DoAndReturn(func(ci *comic.CharacterIssue, i *comic.Importance) error {
+		assert.Equal(t, comic.Cameo, *i)
+		ci.Importance = i
+		return nil
+	})
+
+	c, rec := newAdminContext(http.MethodPatch, "/admin/characters/emma-frost/issues/7", `{"importance": "cameo"}`, "slug", "emma-frost", "id", "7")
+	err := web.NewAdminController(cs, nil, nil).UpdateIssue(c)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"importance": "cameo"`)
+}
+
+func TestAdminControllerUpdateIssueFromIssues(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ch := mockCharacter()
+	ci := &comic.CharacterIssue{ID: 3, CharacterID: ch.ID, IssueID: 7, AppearanceType: comic.Main, Issue: &comic.Issue{ID: 7}}
+	cs := mock_comic.NewMockCharacterServicer(ctrl)
+	cs.EXPECT().Character(comic.CharacterSlug("emma-frost")).Return(ch, nil)
+	cs.EXPECT().Issues(gomock.Any()).Return([]*comic.CharacterIssue{ci}, nil)
+	cs.EXPECT().CharacterBySlug(comic.CharacterSlug("emma-frost"), true).Return(ch, nil)
+	cs.EXPECT().Issue(ch.ID, comic.IssueID(7)).Return(ci, nil)
+	cs.EXPECT().UpdateIssueImportance(ci, gomock.Any()).Return(nil)
+
+	// the curator gets the issue's ID from the character's issues.
+	c, rec := newAdminContext(http.MethodGet, "/characters/emma-frost/issues", "", "slug", "emma-frost")
+	characterCtrl := web.NewCharacterController(mock_comic.NewMockExpandedServicer(ctrl), mock_comic.NewMockRankedServicer(ctrl), cs)
+	assert.Nil(t, characterCtrl.Issues(c))
+	var issues struct {
+		Data []struct {
+			IssueID uint64 `json:"issue_id"`
+		} `json:"data"`
+	}
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &issues))
+	assert.Len(t, issues.Data, 1)
+	id := fmt.Sprint(issues.Data[0].IssueID)
+
+	c, rec = newAdminContext(http.MethodPatch, "/admin/characters/emma-frost/issues/"+id, `{"importance": "major"}`, "slug", "emma-frost", "id", id)
+	assert.Nil(t, web.NewAdminController(cs, nil, nil).UpdateIssue(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"issue_id": 7`)
+}
+
+func TestAdminControllerUpdateIssueInvalidImportance(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ch := mockCharacter()
+	cs := mock_comic.NewMockCharacterServicer(ctrl)
+	cs.EXPECT().CharacterBySlug(comic.CharacterSlug("emma-frost"), true).Return(ch, nil)
+	cs.EXPECT().Issue(ch.ID, comic.IssueID(7)).Return(&comic.CharacterIssue{ID: 3}, nil)
+	cs.EXPECT().UpdateIssueImportance(gomock.Any(), gomock.Any()).Times(0)
+
+	c, _ := newAdminContext(http.MethodPatch, "/admin/characters/emma-frost/issues/7", `{"importance": "lead"}`, "slug", "emma-frost", "id", "7")
+	err := web.NewAdminController(cs, nil, nil).UpdateIssue(c)
+	assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+}
+
+func TestAdminControllerUpdateIssueNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ch := mockCharacter()
+	cs := mock_comic.NewMockCharacterServicer(ctrl)
+	cs.EXPECT().CharacterBySlug(comic.CharacterSlug("emma-frost"), true).Return(ch, nil)
+	cs.EXPECT().Issue(ch.ID, comic.IssueID(7)).Return(nil, nil)
+	cs.EXPECT().UpdateIssueImportance(gomock.Any(), gomock.Any()).Times(0)
+
+	c, _ := newAdminContext(http.MethodPatch, "/admin/characters/emma-frost/issues/7", `{"importance": null}`, "slug", "emma-frost", "id", "7")
+	err := web.NewAdminController(cs, nil, nil).UpdateIssue(c)
+	assert.Equal(t, http.StatusNotFound, err.(*echo.HTTPError).Code)
+}
+
+func TestAdminControllerNormalizeSources(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ch := mockCharacter()
+	cs := mock_comic.NewMockCharacterServicer(ctrl)
+	cs.EXPECT().CharacterBySlug(comic.CharacterSlug("emma-frost"), true).Return(ch, nil)
+	cs.EXPECT().NormalizeSources(ch).Return(errors.New("unknown publisher"))
+
+	c, _ := newAdminContext(http.MethodPost, "/admin/characters/emma-frost/sources/normalize", "", "slug", "emma-frost")
+	err := web.NewAdminController(cs, nil, nil).NormalizeSources(c)
+	assert.EqualError(t, err, "unknown publisher")
+}
+
+func TestAdminControllerSync(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ch := mockCharacter()
+	cs := mock_comic.NewMockCharacterServicer(ctrl)
+	cs.EXPECT().CharacterBySlug(comic.CharacterSlug("emma-frost"), true).Return(ch, nil)
+	s := mock_comic.NewMockSyncer(ctrl)
+	s.EXPECT().Sync(ch.Slug).Return(100, nil)
+	ss := mock_comic.NewMockCharacterStatsSyncer(ctrl)
+	ss.EXPECT().Sync(ch.Slug).Return(nil)
+
+	c, rec := newAdminContext(http.MethodPost, "/admin/characters/emma-frost/sync", "", "slug", "emma-frost")
+	err := web.NewAdminController(cs, s, ss).Sync(c)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"issues": 100`)
+}
+
+func TestAdminControllerSyncDisabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ch := mockCharacter()
+	ch.IsDisabled = true
+	cs := mock_comic.NewMockCharacterServicer(ctrl)
+	cs.EXPECT().CharacterBySlug(comic.CharacterSlug("emma-frost"), true).Return(ch, nil)
+	s := mock_comic.NewMockSyncer(ctrl)
+	s.EXPECT().Sync(gomock.Any()).Times(0)
+
+	c, _ := newAdminContext(http.MethodPost, "/admin/characters/emma-frost/sync", "", "slug", "emma-frost")
+	err := web.NewAdminController(cs, s, mock_comic.NewMockCharacterStatsSyncer(ctrl)).Sync(c)
+	assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+}
@@ -3,27 +3,58 @@ package web
 import (
 	"context"
 	"github.com/comiccruncher/comiccruncher/comic"
+	"github.com/comiccruncher/comiccruncher/internal/log"
+	"github.com/comiccruncher/comiccruncher/internal/metrics"
 	"github.com/comiccruncher/comiccruncher/search"
 	"github.com/go-pg/pg"
 	"github.com/go-redis/redis"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"os"
+	"time"
+)
+
+// Cache-Control policies for the routes.
+const (
+	// Rankings and characters only change when the views get refreshed after a sync.
+	cacheRankings = "public, max-age=300"
+	// Search results can go stale quicker with new characters.
+	cacheSearch = "public, max-age=60"
+	// Admin responses should never be cached since they're for editing.
+	cacheAdmin = "no-store"
+	// Health checks have to hit the app every time.
+	cacheHealth = "no-store"
+)
+
+// Rate limits for the routes.
+var (
+	// Rankings are backed by the materialized views, so scrapers walking the pages get slowed down.
+	rateLimitRankings = RateLimit{Name: "rankings", Limit: 120, Per: time.Minute}
+	// Search queries hit Postgres on every request.
+	rateLimitSearch = RateLimit{Name: "search", Limit: 60, Per: time.Minute}
 )
 
 // App is the struct for the web app with echo and the controllers.
 type App struct {
 	echo           *echo.Echo
+	rankedSvc      comic.RankedServicer
 	searchCtrlr    *SearchController
 	characterCtrlr *CharacterController
 	statsCtrlr     *StatsController
 	publisherCtrlr *PublisherController
 	trendingCtrlr  *TrendingController
+	openAPICtrlr   *OpenAPIController
+	adminCtrlr     *AdminController
+	graphQLCtrlr   *GraphQLController
+	healthCtrlr    *HealthController
+	limiter        *RateLimiter
 }
 
 // Run runs the web application from the specified port. Logs and exits if there is an error.
 func (a App) Run(port string) error {
 	e := a.echo
+	// Metrics go first so requests that panic get recorded as 500s.
+	e.Use(MetricsMiddleware())
 	e.Use(middleware.Recover())
 	e.HTTPErrorHandler = ErrorHandler
 
@@ -38,12 +69,19 @@ func (a App) Run(port string) error {
 			echo.HeaderAccept,
 			echo.HeaderAuthorization,
 			echo.HeaderVary,
-			"X-VISITOR-ID",
+			HeaderVisitorID,
+			HeaderAPIKey,
 		},
 		// AllowCredentials: true,
 		AllowOrigins: allowedOrigins,
-		AllowMethods: []string{"HEAD", "GET", "OPTIONS"},
-		MaxAge:       86400,
+		AllowMethods: []string{"HEAD", "GET", "OPTIONS", "POST", "PATCH"},
+		ExposeHeaders: []string{
+			HeaderRateLimitLimit,
+			HeaderRateLimitRemaining,
+			HeaderRateLimitReset,
+			HeaderRetryAfter,
+		},
+		MaxAge: 86400,
 	}))
 
 	e.Use(middleware.SecureWithConfig(middleware.SecureConfig{
@@ -56,27 +94,70 @@ func (a App) Run(port string) error {
 
 	e.Use(ReferrerPolicyMiddleware("origin"))
 
+	rankingsCache := CacheControlMiddleware(cacheRankings)
+	lastRefreshed := LastModifiedMiddleware(a.rankedSvc.LastRefreshed)
+	rankingsLimit := a.limiter.Middleware(rateLimitRankings)
+
+	// Health checks aren't rate limited so the load balancer and deploys can always reach them.
+	healthCache := CacheControlMiddleware(cacheHealth)
+	e.GET("/healthz", a.healthCtrlr.Healthz, healthCache)
+	e.GET("/readyz", a.healthCtrlr.Readyz, healthCache)
+
+	// Metrics for Prometheus to scrape. nginx blocks the route from the public.
+	e.GET("/metrics", echo.WrapHandler(metrics.Handler()), healthCache)
+
+	// OpenAPI
+	e.GET("/openapi.json", a.openAPICtrlr.OpenAPI)
+
 	// Stats
-	e.GET("/stats", a.statsCtrlr.Stats)
+	e.GET("/stats", a.statsCtrlr.Stats, rankingsLimit, rankingsCache, lastRefreshed)
+
+	// Compare
+	e.GET("/compare", a.characterCtrlr.Compare, rankingsLimit, rankingsCache, lastRefreshed)
+
+	// Co-appearance graph
+	e.GET("/co-appearances", a.characterCtrlr.CoAppearanceGraph, rankingsLimit, rankingsCache, lastRefreshed)
+
+	// GraphQL
+	e.GET("/graphql", a.graphQLCtrlr.GraphQL, rankingsLimit)
+	e.POST("/graphql", a.graphQLCtrlr.GraphQL, rankingsLimit)
 
 	// Search
-	s := e.Group("/search")
-	s.GET("/characters", a.searchCtrlr.SearchCharacters)
+	s := e.Group("/search", a.limiter.Middleware(rateLimitSearch))
+	s.GET("/characters", a.searchCtrlr.SearchCharacters, CacheControlMiddleware(cacheSearch))
 
 	// Characters
-	c := e.Group("/characters")
-	c.GET("", a.characterCtrlr.Characters)
+	c := e.Group("/characters", rankingsLimit, rankingsCache)
+	c.GET("", a.characterCtrlr.Characters, lastRefreshed)
 	c.GET("/:slug", a.characterCtrlr.Character)
+	c.GET("/:slug/issues", a.characterCtrlr.Issues)
+	c.GET("/:slug/series", a.characterCtrlr.Series)
+	c.GET("/:slug/appearances/monthly", a.characterCtrlr.MonthlyAppearances)
+	c.GET("/:slug/rank-history", a.characterCtrlr.RankHistory, lastRefreshed)
+	c.GET("/:slug/co-appearances", a.characterCtrlr.CoAppearances, lastRefreshed)
 
 	// Publishers
-	p := e.Group("/publishers")
-	p.GET("/dc", a.publisherCtrlr.DC)
-	p.GET("/marvel", a.publisherCtrlr.Marvel)
+	p := e.Group("/publishers", rankingsLimit, rankingsCache, lastRefreshed)
+	p.GET("/:slug", a.publisherCtrlr.Popular)
 
 	// trending
-	t := e.Group("/trending")
-	t.GET("/marvel", a.trendingCtrlr.Marvel)
-	t.GET("/dc", a.trendingCtrlr.DC)
+	t := e.Group("/trending", rankingsLimit, rankingsCache, lastRefreshed)
+	t.GET("/:slug", a.trendingCtrlr.Trending)
+
+	// Admin. The routes don't get registered without a signing secret so they can't be reached with a token
+	// signed with an empty key.
+	jwtConfig := NewJWTConfigFromEnvironment()
+	if jwtConfig.SecretSigningKey == "" {
+		log.WEB().Warn("the admin routes are disabled since CC_JWT_SIGNING_SECRET isn't set")
+	} else {
+		ad := e.Group("/admin", JWTMiddlewareWithConfig(jwtConfig), CacheControlMiddleware(cacheAdmin))
+		ad.GET("/characters/:slug", a.adminCtrlr.Character)
+		ad.PATCH("/characters/:slug", a.adminCtrlr.UpdateCharacter)
+		ad.PATCH("/characters/:slug/sources/:id", a.adminCtrlr.UpdateSource)
+		ad.PATCH("/characters/:slug/issues/:id", a.adminCtrlr.UpdateIssue)
+		ad.POST("/characters/:slug/sources/normalize", a.adminCtrlr.NormalizeSources)
+		ad.POST("/characters/:slug/sync", a.adminCtrlr.Sync)
+	}
 
 	// Start the server.
 	return e.Start(":" + port)
@@ -98,23 +179,49 @@ func NewApp(
 	searcher search.Searcher,
 	statsRepository comic.StatsRepository,
 	rankedSvc comic.RankedServicer,
-	ctr comic.CharacterThumbRepository) *App {
+	ctr comic.CharacterThumbRepository,
+	characterSvc comic.CharacterServicer,
+	publisherSvc comic.PublisherServicer,
+	syncer comic.Syncer,
+	statsSyncer comic.CharacterStatsSyncer,
+	amr comic.AppearancesByYearsMapRepository,
+	limiter *RateLimiter,
+	healthCtrlr *HealthController) *App {
 	return &App{
 		echo:           echo.New(),
+		rankedSvc:      rankedSvc,
 		statsCtrlr:     NewStatsController(statsRepository),
 		searchCtrlr:    NewSearchController(searcher, ctr),
-		characterCtrlr: NewCharacterController(expandedSvc, rankedSvc),
-		publisherCtrlr: NewPublisherController(rankedSvc),
-		trendingCtrlr:  NewTrendingController(rankedSvc),
+		characterCtrlr: NewCharacterController(expandedSvc, rankedSvc, characterSvc),
+		publisherCtrlr: NewPublisherController(rankedSvc, publisherSvc),
+		trendingCtrlr:  NewTrendingController(rankedSvc, publisherSvc),
+		openAPICtrlr:   NewOpenAPIController(),
+		adminCtrlr:     NewAdminController(characterSvc, syncer, statsSyncer),
+		graphQLCtrlr:   NewGraphQLController(rankedSvc, expandedSvc, publisherSvc, searcher, ctr, amr),
+		healthCtrlr:    healthCtrlr,
+		limiter:        limiter,
 	}
 }
 
 // NewAppFactory creates a new app with minimal dependencies.
 func NewAppFactory(db *pg.DB, redis *redis.Client) *App {
+	rankedSvc := comic.NewRankedServiceFactory(db, redis)
+	characterSvc := comic.NewCharacterServiceFactory(db)
 	return NewApp(
 		comic.NewExpandedServiceFactory(db, redis),
 		search.NewSearchService(db),
-		comic.NewPGStatsRepository(db),
-		comic.NewRankedServiceFactory(db, redis),
-		comic.NewRedisCharacterThumbRepository(redis))
+		comic.NewRedisStatsRepository(redis, comic.NewPGStatsRepository(db)),
+		rankedSvc,
+		comic.NewRedisCharacterThumbRepository(redis),
+		characterSvc,
+		comic.NewPublisherServiceFactory(db),
+		comic.NewAppearancesSyncer(db, redis),
+		comic.NewCharacterStatsSyncer(
+			redis,
+			comic.NewPGCharacterRepository(db),
+			comic.NewPGPopularRepository(db, comic.NewRedisCharacterThumbRepository(redis)),
+			comic.NewPGCareerRepository(db)),
+		comic.NewRedisAppearancesPerYearRepository(redis),
+		NewRateLimiterFromEnvironment(redis),
+		NewHealthController(db, redis, rankedSvc, characterSvc))
 }
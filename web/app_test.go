@@ -18,7 +18,14 @@ func TestNewApp(t *testing.T) {
 	sr := mock_comic.NewMockStatsRepository(ctrl)
 	rs := mock_comic.NewMockRankedServicer(ctrl)
 	ctr := mock_comic.NewMockCharacterThumbRepository(ctrl)
-	a := web.NewApp(es, srchr, sr, rs, ctr)
+	cs := mock_comic.NewMockCharacterServicer(ctrl)
+	ps := mock_comic.NewMockPublisherServicer(ctrl)
+	sy := mock_comic.NewMockSyncer(ctrl)
+	ss := mock_comic.NewMockCharacterStatsSyncer(ctrl)
+	l := web.NewRateLimiter(mock_comic.NewMockRedisClient(ctrl))
+	amr := mock_comic.NewMockAppearancesByYearsMapRepository(ctrl)
+	h := web.NewHealthController(mock_comic.NewMockORM(ctrl), mock_comic.NewMockRedisClient(ctrl), rs, cs)
+	a := web.NewApp(es, srchr, sr, rs, ctr, cs, ps, sy, ss, amr, l, h)
 	assert.NotNil(t, a)
 }
 
@@ -29,7 +36,14 @@ func TestAppRun(t *testing.T) {
 	sr := mock_comic.NewMockStatsRepository(ctrl)
 	rs := mock_comic.NewMockRankedServicer(ctrl)
 	ctr := mock_comic.NewMockCharacterThumbRepository(ctrl)
-	a := web.NewApp(es, srchr, sr, rs, ctr)
+	cs := mock_comic.NewMockCharacterServicer(ctrl)
+	ps := mock_comic.NewMockPublisherServicer(ctrl)
+	sy := mock_comic.NewMockSyncer(ctrl)
+	ss := mock_comic.NewMockCharacterStatsSyncer(ctrl)
+	l := web.NewRateLimiter(mock_comic.NewMockRedisClient(ctrl))
+	amr := mock_comic.NewMockAppearancesByYearsMapRepository(ctrl)
+	h := web.NewHealthController(mock_comic.NewMockORM(ctrl), mock_comic.NewMockRedisClient(ctrl), rs, cs)
+	a := web.NewApp(es, srchr, sr, rs, ctr, cs, ps, sy, ss, amr, l, h)
 	go func() {
 		err := a.Run("0")
 		assert.Nil(t, err)
@@ -44,7 +58,14 @@ func TestAppClose(t *testing.T) {
 	sr := mock_comic.NewMockStatsRepository(ctrl)
 	rs := mock_comic.NewMockRankedServicer(ctrl)
 	ctr := mock_comic.NewMockCharacterThumbRepository(ctrl)
-	a := web.NewApp(es, srchr, sr, rs, ctr)
+	cs := mock_comic.NewMockCharacterServicer(ctrl)
+	ps := mock_comic.NewMockPublisherServicer(ctrl)
+	sy := mock_comic.NewMockSyncer(ctrl)
+	ss := mock_comic.NewMockCharacterStatsSyncer(ctrl)
+	l := web.NewRateLimiter(mock_comic.NewMockRedisClient(ctrl))
+	amr := mock_comic.NewMockAppearancesByYearsMapRepository(ctrl)
+	h := web.NewHealthController(mock_comic.NewMockORM(ctrl), mock_comic.NewMockRedisClient(ctrl), rs, cs)
+	a := web.NewApp(es, srchr, sr, rs, ctr, cs, ps, sy, ss, amr, l, h)
 	assert.Nil(t, a.Close())
 }
 
@@ -55,7 +76,14 @@ func TestAppShutdown(t *testing.T) {
 	sr := mock_comic.NewMockStatsRepository(ctrl)
 	rs := mock_comic.NewMockRankedServicer(ctrl)
 	ctr := mock_comic.NewMockCharacterThumbRepository(ctrl)
-	a := web.NewApp(es, srchr, sr, rs, ctr)
+	cs := mock_comic.NewMockCharacterServicer(ctrl)
+	ps := mock_comic.NewMockPublisherServicer(ctrl)
+	sy := mock_comic.NewMockSyncer(ctrl)
+	ss := mock_comic.NewMockCharacterStatsSyncer(ctrl)
+	l := web.NewRateLimiter(mock_comic.NewMockRedisClient(ctrl))
+	amr := mock_comic.NewMockAppearancesByYearsMapRepository(ctrl)
+	h := web.NewHealthController(mock_comic.NewMockORM(ctrl), mock_comic.NewMockRedisClient(ctrl), rs, cs)
+	a := web.NewApp(es, srchr, sr, rs, ctr, cs, ps, sy, ss, amr, l, h)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -1,15 +1,70 @@
 package web
 
 import (
+	"fmt"
 	"github.com/comiccruncher/comiccruncher/comic"
 	"github.com/comiccruncher/comiccruncher/search"
 	"github.com/labstack/echo/v4"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Pagination limit.
 const pageLimit = 24
 
+// The max number of characters that can be compared at once.
+const compareLimit = 5
+
+const (
+	// The default minimum number of shared issues for a pair of characters to be in the co-appearance graph.
+	graphMinIssues = 10
+	// The default number of pairs of characters in the co-appearance graph.
+	graphLimit = 1000
+	// The max number of pairs of characters in the co-appearance graph.
+	graphMaxLimit = 10000
+)
+
+// The max number of years for the half-life when ranking by relevance.
+const maxHalfLife = 100
+
+var (
+	// The allowed values for the `sort` parameter for rankings. The first is the default.
+	rankingSorts = []string{"issues", "average", "relevance"}
+	// The allowed values for the `category` parameter. The first is the default.
+	categories = []string{"all", "main", "alternate"}
+	// The allowed values for the `category` parameter for rankings, which can also weigh the appearances by
+	// the character's importance. The first is the default.
+	rankingCategories = []string{"all", "main", "alternate", "weighted"}
+	// The allowed values for the `sort` parameter for issues. The first is the default.
+	issueSorts = []string{"oldest", "newest"}
+	// The allowed values for the `months` parameter for trending characters. The first is the default.
+	trendingWindows = []string{"12", "6", "3"}
+	// The allowed values for the `sort` parameter for co-appearances. The first is the default.
+	coAppearanceSorts = []string{"issues", "affinity"}
+	// The allowed values for the `era` parameter.
+	eras = []string{string(comic.GoldenAge), string(comic.SilverAge), string(comic.BronzeAge), string(comic.ModernAge)}
+	// The allowed values for each of the comma-separated `format` values for issues.
+	issueFormats = []string{
+		string(comic.FormatUnknown),
+		string(comic.FormatStandard),
+		string(comic.FormatTPB),
+		string(comic.FormatManga),
+		string(comic.FormatHC),
+		string(comic.FormatOGN),
+		string(comic.FormatWeb),
+		string(comic.FormatAnthology),
+		string(comic.FormatMagazine),
+		string(comic.FormatDigitalMedia),
+		string(comic.FormatMiniComic),
+		string(comic.FormatFlipbook),
+		string(comic.FormatPrestige),
+		string(comic.FormatOther),
+	}
+)
+
 // StatsController is the controller for stats about comic cruncher.
 type StatsController struct {
 	statsRepository comic.StatsRepository
@@ -60,39 +115,46 @@ func (c SearchController) SearchCharacters(ctx echo.Context) error {
 
 // PublisherController is the controller for publishers.
 type PublisherController struct {
-	rankedSvc comic.RankedServicer
+	rankedSvc    comic.RankedServicer
+	publisherSvc comic.PublisherServicer
 }
 
-// DC gets the publisher's characters with their appearances.
-func (c PublisherController) DC(ctx echo.Context) error {
+// Popular gets the publisher's characters with their appearances.
+func (c PublisherController) Popular(ctx echo.Context) error {
 	cr, err := decodeCriteria(ctx)
 	if err != nil {
 		return err
 	}
-	results, err := c.rankedSvc.DCPopular(cr)
+	format, err := exportFormat(ctx)
 	if err != nil {
 		return err
 	}
-	return JSONListViewOK(ctx, listRanked(results), pageLimit)
-}
-
-// Marvel gets the publisher's characters with their appearances.
-func (c PublisherController) Marvel(ctx echo.Context) error {
-	cr, err := decodeCriteria(ctx)
+	p, err := findPublisher(ctx, c.publisherSvc)
 	if err != nil {
 		return err
 	}
-	results, err := c.rankedSvc.MarvelPopular(cr)
+	if format != "" {
+		return exportRanked(ctx, format, func(limit, offset int) ([]*comic.RankedCharacter, error) {
+			cr.Limit, cr.Offset, cr.After, cr.Before = limit, offset, nil, nil
+			return c.rankedSvc.PublisherPopular(p.Slug, cr)
+		})
+	}
+	total, err := c.rankedSvc.PublisherPopularTotal(p.Slug)
 	if err != nil {
 		return err
 	}
-	return JSONListViewOK(ctx, listRanked(results), pageLimit)
+	results, err := c.rankedSvc.PublisherPopular(p.Slug, lastPageCriteria(cr, total))
+	if err != nil {
+		return err
+	}
+	return JSONRankedListViewOK(ctx, results, cr, total, pageLimit)
 }
 
 // CharacterController is the character controller.
 type CharacterController struct {
-	rankedSvc   comic.RankedServicer
-	expandedSvc comic.ExpandedServicer
+	rankedSvc    comic.RankedServicer
+	expandedSvc  comic.ExpandedServicer
+	characterSvc comic.CharacterServicer
 }
 
 // Character gets a character by its slug.
@@ -105,66 +167,386 @@ func (c CharacterController) Character(ctx echo.Context) error {
 	if character == nil {
 		return NewNotFoundError("The character could not be found.")
 	}
+	var lastSynced time.Time
+	for _, s := range character.LastSyncs {
+		if s.SyncedAt.After(lastSynced) {
+			lastSynced = s.SyncedAt
+		}
+	}
+	setLastModified(ctx, lastSynced)
 	return JSONDetailViewOK(ctx, character)
 }
 
-// Characters lists the characters.
+// Characters lists the characters. The characters can be ranked for an `era` or for the years `from` and `to`.
 func (c CharacterController) Characters(ctx echo.Context) error {
 	cr, err := decodeCriteria(ctx)
 	if err != nil {
 		return err
 	}
-	results, err := c.rankedSvc.AllPopular(cr)
+	if cr.Years, err = decodeYears(ctx); err != nil {
+		return err
+	}
+	format, err := exportFormat(ctx)
 	if err != nil {
 		return err
 	}
-	return JSONListViewOK(ctx, listRanked(results), pageLimit)
+	if format != "" {
+		return exportRanked(ctx, format, func(limit, offset int) ([]*comic.RankedCharacter, error) {
+			cr.Limit, cr.Offset, cr.After, cr.Before = limit, offset, nil, nil
+			return c.rankedSvc.AllPopular(cr)
+		})
+	}
+	total, err := c.rankedSvc.AllPopularTotal(cr)
+	if err != nil {
+		return err
+	}
+	results, err := c.rankedSvc.AllPopular(lastPageCriteria(cr, total))
+	if err != nil {
+		return err
+	}
+	return JSONRankedListViewOK(ctx, results, cr, total, pageLimit)
 }
 
-// TrendingController is the controller for trending characters.
-type TrendingController struct {
-	svc comic.RankedServicer
+// Issues lists the issues a character appears in.
+func (c CharacterController) Issues(ctx echo.Context) error {
+	cr, err := decodeIssueCriteria(ctx)
+	if err != nil {
+		return err
+	}
+	slug := comic.CharacterSlug(ctx.Param("slug"))
+	character, err := c.characterSvc.Character(slug)
+	if err != nil {
+		return err
+	}
+	if character == nil {
+		return NewNotFoundError("The character could not be found.")
+	}
+	cr.CharacterIDs = []comic.CharacterID{character.ID}
+	results, err := c.characterSvc.Issues(cr)
+	if err != nil {
+		return err
+	}
+	var data = make([]interface{}, len(results))
+	for i, v := range results {
+		data[i] = v
+	}
+	return JSONListViewOK(ctx, data, pageLimit)
+}
+
+// Series gets the series the character appears in the most with their main and alternate appearances in each.
+func (c CharacterController) Series(ctx echo.Context) error {
+	series, err := c.expandedSvc.Series(comic.CharacterSlug(ctx.Param("slug")))
+	if err != nil {
+		return err
+	}
+	if series == nil {
+		return NewNotFoundError("The character could not be found.")
+	}
+	return JSONDetailViewOK(ctx, series)
+}
+
+// MonthlyAppearances gets the character's main and alternate appearances for each month of the recent years.
+// Issues whose month isn't known are in a month 0 aggregate at the start of their year.
+func (c CharacterController) MonthlyAppearances(ctx echo.Context) error {
+	apps, err := c.expandedSvc.MonthlyAppearances(comic.CharacterSlug(ctx.Param("slug")))
+	if err != nil {
+		return err
+	}
+	if apps == nil {
+		return NewNotFoundError("The character could not be found.")
+	}
+	return JSONDetailViewOK(ctx, apps)
+}
+
+// RankHistory gets the character's rank snapshots for the `category` to chart how they moved over time.
+// The main ranks are within the character's publisher, like their main stats.
+func (c CharacterController) RankHistory(ctx echo.Context) error {
+	typeReq, err := oneOf(ctx, "category", rankingCategories)
+	if err != nil {
+		return err
+	}
+	cat := comic.AllTimeStats
+	switch typeReq {
+	case "main":
+		cat = comic.MainStats
+		break
+	case "alternate":
+		cat = comic.AlternateStats
+		break
+	case "weighted":
+		cat = comic.WeightedStats
+		break
+	}
+	slug := comic.CharacterSlug(ctx.Param("slug"))
+	character, err := c.characterSvc.Character(slug)
+	if err != nil {
+		return err
+	}
+	if character == nil {
+		return NewNotFoundError("The character could not be found.")
+	}
+	snapshots, err := c.rankedSvc.RankHistory(character.ID, cat)
+	if err != nil {
+		return err
+	}
+	if snapshots == nil {
+		snapshots = []*comic.RankSnapshot{}
+	}
+	return JSONDetailViewOK(ctx, comic.RankHistory{Slug: character.Slug, Category: cat, Snapshots: snapshots})
 }
 
-// Marvel gets the trending characters for Marvel.
-func (c *TrendingController) Marvel(ctx echo.Context) error {
+// CoAppearances gets the characters who appear in the same issues as the character. The `sort` parameter
+// sorts them by the most shared issues or the highest affinity.
+func (c CharacterController) CoAppearances(ctx echo.Context) error {
 	page, err := parsePageNumber(ctx)
 	if err != nil {
 		return err
 	}
-	results, err := c.svc.MarvelTrending(pageLimit+1, (page-1)*pageLimit)
+	sortReq, err := oneOf(ctx, "sort", coAppearanceSorts)
 	if err != nil {
 		return err
 	}
-	return JSONListViewOK(ctx, listRanked(results), pageLimit)
+	cr := comic.CoAppearanceCriteria{
+		SortBy: comic.MostSharedIssues,
+		Limit:  pageLimit + 1,
+		Offset: (page - 1) * pageLimit,
+	}
+	if sortReq == "affinity" {
+		cr.SortBy = comic.HighestAffinity
+	}
+	slug := comic.CharacterSlug(ctx.Param("slug"))
+	character, err := c.characterSvc.Character(slug)
+	if err != nil {
+		return err
+	}
+	if character == nil {
+		return NewNotFoundError("The character could not be found.")
+	}
+	results, err := c.rankedSvc.CoAppearances(character.ID, cr)
+	if err != nil {
+		return err
+	}
+	var data = make([]interface{}, len(results))
+	for i, v := range results {
+		data[i] = v
+	}
+	return JSONListViewOK(ctx, data, pageLimit)
 }
 
-// DC gets the trending characters for DC.
-func (c *TrendingController) DC(ctx echo.Context) error {
+// CoAppearanceGraph exports the graph of the characters who appear together the most as JSON or GraphML
+// for visualizing. The `min` parameter leaves out the pairs with fewer shared issues and `limit` caps the
+// number of pairs.
+func (c CharacterController) CoAppearanceGraph(ctx echo.Context) error {
+	format, err := oneOf(ctx, "format", graphFormats)
+	if err != nil {
+		return err
+	}
+	cr := comic.CoAppearanceGraphCriteria{}
+	if cr.MinIssueCount, err = parseIntParam(ctx, "min", graphMinIssues, 1, math.MaxInt32); err != nil {
+		return err
+	}
+	if cr.Limit, err = parseIntParam(ctx, "limit", graphLimit, 1, graphMaxLimit); err != nil {
+		return err
+	}
+	g, err := c.rankedSvc.CoAppearanceGraph(cr)
+	if err != nil {
+		return err
+	}
+	if ExportFormat(format) == GraphML {
+		return exportGraphML(ctx, g)
+	}
+	return JSONDetailViewOK(ctx, g)
+}
+
+// Compare compares the characters from the comma-separated `slugs` parameter.
+func (c CharacterController) Compare(ctx echo.Context) error {
+	var slugs []comic.CharacterSlug
+	seen := make(map[comic.CharacterSlug]bool)
+	for _, s := range strings.Split(ctx.QueryParam("slugs"), ",") {
+		slug := comic.CharacterSlug(strings.TrimSpace(s))
+		if slug != "" && !seen[slug] {
+			seen[slug] = true
+			slugs = append(slugs, slug)
+		}
+	}
+	if len(slugs) < 2 {
+		return NewBadRequestError("At least two characters are required to compare.")
+	}
+	if len(slugs) > compareLimit {
+		return NewBadRequestError("Only up to " + strconv.Itoa(compareLimit) + " characters can be compared.")
+	}
+	cmp, err := c.expandedSvc.Compare(slugs...)
+	if err != nil {
+		return err
+	}
+	if len(cmp.Characters) != len(slugs) {
+		return NewNotFoundError("One or more of the characters could not be found.")
+	}
+	return JSONDetailViewOK(ctx, cmp)
+}
+
+// TrendingController is the controller for trending characters.
+type TrendingController struct {
+	svc          comic.RankedServicer
+	publisherSvc comic.PublisherServicer
+}
+
+// Trending gets the trending characters for the publisher over the last `months`.
+func (c *TrendingController) Trending(ctx echo.Context) error {
 	page, err := parsePageNumber(ctx)
 	if err != nil {
 		return err
 	}
-	results, err := c.svc.DCTrending(pageLimit+1, (page-1)*pageLimit)
+	months, err := oneOf(ctx, "months", trendingWindows)
+	if err != nil {
+		return err
+	}
+	window, _ := strconv.Atoi(months)
+	cr := comic.TrendingCriteria{Window: comic.TrendingWindow(window)}
+	format, err := exportFormat(ctx)
+	if err != nil {
+		return err
+	}
+	p, err := findPublisher(ctx, c.publisherSvc)
+	if err != nil {
+		return err
+	}
+	if format != "" {
+		return exportRanked(ctx, format, func(limit, offset int) ([]*comic.RankedCharacter, error) {
+			cr.Limit, cr.Offset = limit, offset
+			return c.svc.Trending(p.Slug, cr)
+		})
+	}
+	cr.Limit, cr.Offset = pageLimit+1, (page-1)*pageLimit
+	results, err := c.svc.Trending(p.Slug, cr)
 	if err != nil {
 		return err
 	}
 	return JSONListViewOK(ctx, listRanked(results), pageLimit)
 }
 
+// Finds the publisher from the `slug` parameter. Returns a not found error if it doesn't exist.
+func findPublisher(ctx echo.Context, svc comic.PublisherServicer) (*comic.Publisher, error) {
+	p, err := svc.Publisher(comic.PublisherSlug(ctx.Param("slug")))
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, NewNotFoundError("The publisher could not be found.")
+	}
+	return p, nil
+}
+
 // Gets a popular criteria struct based on the context.
 func decodeCriteria(ctx echo.Context) (comic.PopularCriteria, error) {
 	page, err := parsePageNumber(ctx)
 	if err != nil {
 		return comic.PopularCriteria{}, err
 	}
+	sortReq, err := oneOf(ctx, "sort", rankingSorts)
+	if err != nil {
+		return comic.PopularCriteria{}, err
+	}
+	typeReq, err := oneOf(ctx, "category", rankingCategories)
+	if err != nil {
+		return comic.PopularCriteria{}, err
+	}
+	cr := newPopularCriteria(sortReq, typeReq, page)
+	if cr.SortBy == comic.MostRelevant {
+		if cr.HalfLife, err = parseIntParam(ctx, "half_life", comic.DefaultHalfLife, 1, maxHalfLife); err != nil {
+			return cr, err
+		}
+	}
+	if err := decodeCursor(ctx, &cr); err != nil {
+		return cr, err
+	}
+	// seeking backwards doesn't need the extra character since the previous page always has a next page.
+	if cr.Before != nil {
+		cr.Limit = pageLimit
+	}
+	return cr, nil
+}
+
+// Gets the range of years from the `era` parameter or the `from` and `to` parameters.
+// Returns nil if none of them are present.
+func decodeYears(ctx echo.Context) (*comic.YearRange, error) {
+	era := ctx.QueryParam("era")
+	from, err := parseYearParam(ctx, "from")
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseYearParam(ctx, "to")
+	if err != nil {
+		return nil, err
+	}
+	if era != "" {
+		if from != 0 || to != 0 {
+			return nil, NewBadRequestError("The era parameter can't be used with the from and to parameters.")
+		}
+		years := comic.Era(era).Years()
+		if years == nil {
+			return nil, NewParamError("era", era, eras...)
+		}
+		return years, nil
+	}
+	if from == 0 && to == 0 {
+		return nil, nil
+	}
+	if from == 0 {
+		return nil, NewBadRequestError("The from parameter is required with the to parameter.")
+	}
+	if to != 0 && to < from {
+		return nil, NewBadRequestError("The to parameter can't be before the from parameter.")
+	}
+	return &comic.YearRange{From: from, To: to}, nil
+}
+
+// Parses an optional year query parameter. Returns 0 if the parameter isn't present.
+func parseYearParam(ctx echo.Context, name string) (int, error) {
+	val := ctx.QueryParam(name)
+	if val == "" {
+		return 0, nil
+	}
+	year, err := strconv.Atoi(val)
+	if err != nil || year < 1 || year > 9999 {
+		return 0, NewBadRequestError("Invalid " + name + " parameter")
+	}
+	return year, nil
+}
+
+// Parses an optional integer query parameter from min to max. Returns the default if it's not present.
+func parseIntParam(ctx echo.Context, name string, def, min, max int) (int, error) {
+	val := ctx.QueryParam(name)
+	if val == "" {
+		return def, nil
+	}
+	i, err := strconv.Atoi(val)
+	if err != nil || i < min || i > max {
+		return 0, NewBadRequestError(fmt.Sprintf("Invalid %s parameter. It must be from %d to %d", name, min, max))
+	}
+	return i, nil
+}
+
+// Limits the criteria for seeking to the last page to the number of characters on it, so the last page
+// lines up with the pages before it.
+func lastPageCriteria(cr comic.PopularCriteria, total int) comic.PopularCriteria {
+	if cr.Before != nil && *cr.Before == endCursor && total%pageLimit != 0 {
+		cr.Limit = total % pageLimit
+	}
+	return cr
+}
+
+// Creates the popular criteria for the page from the already validated `sort` and `category` values.
+// The limit is one more than the page limit for pagination.
+func newPopularCriteria(sortReq, typeReq string, page int) comic.PopularCriteria {
 	sortBy := comic.MostIssues
-	sortReq := ctx.QueryParam("sort")
 	if sortReq == "average" {
 		sortBy = comic.AverageIssuesPerYear
 	}
+	if sortReq == "relevance" {
+		sortBy = comic.MostRelevant
+	}
 	appearanceType := comic.Main | comic.Alternate
-	typeReq := ctx.QueryParam("category")
 	switch typeReq {
 	case "main":
 		appearanceType = comic.Main
@@ -176,9 +558,101 @@ func decodeCriteria(ctx echo.Context) (comic.PopularCriteria, error) {
 	return comic.PopularCriteria{
 		SortBy:         sortBy,
 		AppearanceType: appearanceType,
+		Weighted:       typeReq == "weighted",
 		Limit:          pageLimit + 1,
 		Offset:         (page - 1) * pageLimit,
-	}, nil
+	}
+}
+
+// Gets a character issue criteria struct based on the context.
+func decodeIssueCriteria(ctx echo.Context) (comic.CharacterIssueCriteria, error) {
+	page, err := parsePageNumber(ctx)
+	if err != nil {
+		return comic.CharacterIssueCriteria{}, err
+	}
+	cr := comic.CharacterIssueCriteria{
+		SortBy: comic.SaleDateAsc,
+		Limit:  pageLimit + 1,
+		Offset: (page - 1) * pageLimit,
+	}
+	sortReq, err := oneOf(ctx, "sort", issueSorts)
+	if err != nil {
+		return cr, err
+	}
+	if sortReq == "newest" {
+		cr.SortBy = comic.SaleDateDesc
+	}
+	typeReq, err := oneOf(ctx, "category", categories)
+	if err != nil {
+		return cr, err
+	}
+	switch typeReq {
+	case "main":
+		cr.AppearanceType = comic.Main
+		break
+	case "alternate":
+		cr.AppearanceType = comic.Alternate
+		break
+	}
+	if year := ctx.QueryParam("year"); year != "" {
+		y, err := strconv.Atoi(year)
+		if err != nil {
+			return cr, NewBadRequestError("Invalid year parameter")
+		}
+		cr.Years = []int{y}
+	}
+	if formats := ctx.QueryParam("format"); formats != "" {
+		for _, f := range strings.Split(formats, ",") {
+			f = strings.TrimSpace(f)
+			if !isAllowed(f, issueFormats) {
+				return cr, NewParamError("format", f, issueFormats...)
+			}
+			cr.Formats = append(cr.Formats, comic.Format(f))
+		}
+	}
+	if cr.IsVariant, err = parseBoolParam(ctx, "variant"); err != nil {
+		return cr, err
+	}
+	if cr.IsReprint, err = parseBoolParam(ctx, "reprint"); err != nil {
+		return cr, err
+	}
+	return cr, nil
+}
+
+// Gets the query parameter's value if it's one of the allowed values. Returns the default value,
+// the first allowed value, if it's not present.
+func oneOf(ctx echo.Context, param string, allowed []string) (string, error) {
+	val := ctx.QueryParam(param)
+	if val == "" {
+		return allowed[0], nil
+	}
+	if isAllowed(val, allowed) {
+		return val, nil
+	}
+	return "", NewParamError(param, val, allowed...)
+}
+
+// Checks if the value is one of the allowed values.
+func isAllowed(val string, allowed []string) bool {
+	for _, a := range allowed {
+		if val == a {
+			return true
+		}
+	}
+	return false
+}
+
+// Parses an optional boolean query parameter. Returns nil if the parameter isn't present.
+func parseBoolParam(ctx echo.Context, name string) (*bool, error) {
+	val := ctx.QueryParam(name)
+	if val == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		return nil, NewBadRequestError("Invalid " + name + " parameter")
+	}
+	return &b, nil
 }
 
 // Transforms ranked characters into an interface for pagination.
@@ -191,10 +665,11 @@ func listRanked(results []*comic.RankedCharacter) []interface{} {
 }
 
 // NewCharacterController creates a new character controller.
-func NewCharacterController(eSvc comic.ExpandedServicer, rSvc comic.RankedServicer) *CharacterController {
+func NewCharacterController(eSvc comic.ExpandedServicer, rSvc comic.RankedServicer, cSvc comic.CharacterServicer) *CharacterController {
 	return &CharacterController{
-		expandedSvc: eSvc,
-		rankedSvc:   rSvc,
+		expandedSvc:  eSvc,
+		rankedSvc:    rSvc,
+		characterSvc: cSvc,
 	}
 }
 
@@ -214,16 +689,18 @@ func NewStatsController(repository comic.StatsRepository) *StatsController {
 }
 
 // NewPublisherController creates a new publisher controller.
-func NewPublisherController(s comic.RankedServicer) *PublisherController {
+func NewPublisherController(s comic.RankedServicer, ps comic.PublisherServicer) *PublisherController {
 	return &PublisherController{
-		rankedSvc: s,
+		rankedSvc:    s,
+		publisherSvc: ps,
 	}
 }
 
 // NewTrendingController creates a new trending controller.
-func NewTrendingController(s comic.RankedServicer) *TrendingController {
+func NewTrendingController(s comic.RankedServicer, ps comic.PublisherServicer) *TrendingController {
 	return &TrendingController{
-		svc: s,
+		svc:          s,
+		publisherSvc: ps,
 	}
 }
 
@@ -236,3 +713,23 @@ func NewNotFoundError(message string) *echo.HTTPError {
 func NewBadRequestError(message string) *echo.HTTPError {
 	return echo.NewHTTPError(http.StatusBadRequest, message)
 }
+
+// ParamError describes an invalid query parameter and the values it allows.
+type ParamError struct {
+	Param   string   `json:"param"`
+	Value   string   `json:"value"`
+	Allowed []string `json:"allowed"`
+}
+
+// Error returns the error message.
+func (e *ParamError) Error() string {
+	return "Invalid " + e.Param + " parameter"
+}
+
+// NewParamError creates a new HTTP error for a 400 status with the details of the invalid parameter attached.
+func NewParamError(param, value string, allowed ...string) *echo.HTTPError {
+	pe := &ParamError{Param: param, Value: value, Allowed: allowed}
+	err := NewBadRequestError(pe.Error())
+	err.Internal = pe
+	return err
+}
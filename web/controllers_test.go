@@ -12,6 +12,8 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -146,7 +148,8 @@ func TestCharacterControllerCharacter(t *testing.T) {
 	header := c.Response().Header()
 
 	rankedSvc := mock_comic.NewMockRankedServicer(ctrl)
-	characterCtrl := web.NewCharacterController(expandedSvc, rankedSvc)
+	characterSvc := mock_comic.NewMockCharacterServicer(ctrl)
+	characterCtrl := web.NewCharacterController(expandedSvc, rankedSvc, characterSvc)
 	err = characterCtrl.Character(c)
 
 	assert.Nil(t, err)
@@ -157,6 +160,8 @@ func TestCharacterControllerCharacter(t *testing.T) {
 	assert.Equal(t, http.StatusOK, c.Response().Status)
 	assert.True(t, c.Response().Committed)
 	assert.Equal(t, "application/json; charset=UTF-8", header.Get("Content-Type"))
+	assert.Equal(t, "Tue, 02 Jan 2018 00:00:00 GMT", header.Get("Last-Modified"))
+	assert.NotEmpty(t, header.Get("ETag"))
 	assert.Nil(t, err)
 	assert.Equal(t, file, read)
 }
@@ -174,7 +179,8 @@ func TestCharacterControllerCharacterNotFound(t *testing.T) {
 	c := e.NewContext(req, rec)
 
 	rankedSvc := mock_comic.NewMockRankedServicer(ctrl)
-	characterCtrl := web.NewCharacterController(expandedSvc, rankedSvc)
+	characterSvc := mock_comic.NewMockCharacterServicer(ctrl)
+	characterCtrl := web.NewCharacterController(expandedSvc, rankedSvc, characterSvc)
 	err := characterCtrl.Character(c).(*echo.HTTPError)
 	assert.Equal(t, http.StatusNotFound, err.Code)
 }
@@ -205,8 +211,10 @@ func TestCharacterControllerCharacters(t *testing.T) {
 	header := c.Response().Header()
 
 	rankedSvc := mock_comic.NewMockRankedServicer(ctrl)
+	rankedSvc.EXPECT().AllPopularTotal(gomock.Any()).Return(2, nil)
 	rankedSvc.EXPECT().AllPopular(gomock.Any()).Return(rankedChrs, nil)
-	characterCtrl := web.NewCharacterController(expandedSvc, rankedSvc)
+	characterSvc := mock_comic.NewMockCharacterServicer(ctrl)
+	characterCtrl := web.NewCharacterController(expandedSvc, rankedSvc, characterSvc)
 	// make the call
 	err = characterCtrl.Characters(c)
 	assert.Nil(t, err)
@@ -221,87 +229,727 @@ func TestCharacterControllerCharacters(t *testing.T) {
 	assert.Equal(t, file, read)
 }
 
-func TestPublisherControllerDC(t *testing.T) {
+func TestCharacterControllerCharactersEra(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
+	cr := comic.PopularCriteria{
+		SortBy:         comic.AverageIssuesPerYear,
+		AppearanceType: comic.Main,
+		Years:          &comic.YearRange{From: 1956, To: 1969},
+		Limit:          25,
+		Offset:         0,
+	}
+	rankedSvc := mock_comic.NewMockRankedServicer(ctrl)
+	rankedSvc.EXPECT().AllPopularTotal(cr).Return(1, nil)
+	rankedSvc.EXPECT().AllPopular(cr).Return([]*comic.RankedCharacter{{ID: 1, Slug: "emma-frost", Name: "Emma Frost"}}, nil)
 
 	e := echo.New()
-	req := httptest.NewRequest(http.MethodGet, "/characters?page=1", nil)
+	req := httptest.NewRequest(http.MethodGet, "/characters?era=silver&sort=average&category=main", nil)
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
-	header := c.Response().Header()
-	stats1 := comic.CharacterStats{
-		Average: 2, AverageRank: 1, IssueCount: 10, IssueCountRank: 1,
+	characterCtrl := web.NewCharacterController(mock_comic.NewMockExpandedServicer(ctrl), rankedSvc, mock_comic.NewMockCharacterServicer(ctrl))
+	assert.Nil(t, characterCtrl.Characters(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"slug": "emma-frost"`)
+}
+
+func TestCharacterControllerCharactersWeighted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	cr := comic.PopularCriteria{
+		SortBy:         comic.MostIssues,
+		AppearanceType: comic.Main | comic.Alternate,
+		Weighted:       true,
+		Limit:          25,
+		Offset:         0,
 	}
-	stats2 := comic.CharacterStats{
-		Average: 2, AverageRank: 2, IssueCountRank: 2, IssueCount: 5,
+	rankedSvc := mock_comic.NewMockRankedServicer(ctrl)
+	rankedSvc.EXPECT().AllPopularTotal(cr).Return(1, nil)
+	rankedSvc.EXPECT().AllPopular(cr).Return([]*comic.RankedCharacter{{ID: 1, Slug: "emma-frost", Stats: comic.CharacterStats{Category: comic.WeightedStats}}}, nil)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/characters?category=weighted", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	characterCtrl := web.NewCharacterController(mock_comic.NewMockExpandedServicer(ctrl), rankedSvc, mock_comic.NewMockCharacterServicer(ctrl))
+	assert.Nil(t, characterCtrl.Characters(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"category": "weighted"`)
+}
+
+func TestCharacterControllerCharactersYears(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	rankedSvc := mock_comic.NewMockRankedServicer(ctrl)
+	rankedSvc.EXPECT().AllPopularTotal(gomock.Any()).Return(0, nil)
+	rankedSvc.EXPECT().AllPopular(comic.PopularCriteria{
+		SortBy:         comic.MostIssues,
+		AppearanceType: comic.Main | comic.Alternate,
+		Years:          &comic.YearRange{From: 1961, To: 1985},
+		Limit:          25,
+		Offset:         0,
+	}).Return(nil, nil)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/characters?from=1961&to=1985", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	characterCtrl := web.NewCharacterController(mock_comic.NewMockExpandedServicer(ctrl), rankedSvc, mock_comic.NewMockCharacterServicer(ctrl))
+	assert.Nil(t, characterCtrl.Characters(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestCharacterControllerCharactersInvalidYears(t *testing.T) {
+	for _, query := range []string{"era=dark", "era=silver&from=1961", "from=abc", "to=1985", "from=1985&to=1961", "from=0"} {
+		ctrl := gomock.NewController(t)
+		rankedSvc := mock_comic.NewMockRankedServicer(ctrl)
+		rankedSvc.EXPECT().AllPopular(gomock.Any()).Times(0)
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/characters?"+query, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		characterCtrl := web.NewCharacterController(mock_comic.NewMockExpandedServicer(ctrl), rankedSvc, mock_comic.NewMockCharacterServicer(ctrl))
+		err := characterCtrl.Characters(c)
+		assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code, query)
+		ctrl.Finish()
+	}
+}
+
+func TestCharacterControllerCharactersCSV(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p := comic.Publisher{ID: 1, Slug: "marvel", Name: "Marvel"}
+	stats := comic.CharacterStats{
+		Category: comic.AllTimeStats, Average: 2.5, AverageRank: 1, IssueCount: 10, IssueCountRank: 1,
 	}
 	rankedChrs := []*comic.RankedCharacter{
-		{ID: 1, PublisherID: 1, Stats: stats1, Name: "Test", Slug: "test"},
-		{ID: 2, PublisherID: 1, Stats: stats2, Name: "Test2", Slug: "test2"},
+		{ID: 1, PublisherID: 1, Publisher: p, Stats: stats, Name: "Emma Frost", Slug: "emma-frost", Image: "test.jpg"},
 	}
 	cr := comic.PopularCriteria{
 		SortBy:         comic.MostIssues,
 		AppearanceType: comic.Main | comic.Alternate,
-		Limit:          25,
+		Limit:          1000,
 		Offset:         0,
 	}
 	rankedSvc := mock_comic.NewMockRankedServicer(ctrl)
-	rankedSvc.EXPECT().DCPopular(cr).Return(rankedChrs, nil)
+	rankedSvc.EXPECT().AllPopular(cr).Return(rankedChrs, nil)
 
-	publisherCtrlr := web.NewPublisherController(rankedSvc)
-	err := publisherCtrlr.DC(c)
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/characters?page=2", nil)
+	req.Header.Set("Accept", "text/csv")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
 
+	characterCtrl := web.NewCharacterController(mock_comic.NewMockExpandedServicer(ctrl), rankedSvc, mock_comic.NewMockCharacterServicer(ctrl))
+	err := characterCtrl.Characters(c)
 	assert.Nil(t, err)
 	assert.Equal(t, http.StatusOK, rec.Code)
-	assert.Equal(t, "application/json; charset=UTF-8", header.Get("Content-Type"))
+	assert.Equal(t, "text/csv; charset=UTF-8", rec.Header().Get("Content-Type"))
+	expected := "slug,name,other_name,publisher,category,issue_count_rank,issue_count,average_issues_per_year_rank,average_issues_per_year,image,vendor_image,vendor_url\n" +
+		"emma-frost,Emma Frost,,marvel,all_time,1,10,1,2.5," + os.Getenv("CC_CDN_URL") + "/test.jpg,,\n"
+	assert.Equal(t, expected, rec.Body.String())
 }
 
-func TestPublisherControllerMarvel(t *testing.T) {
+func TestPublisherControllerPopularNDJSON(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
+	// a full batch means there might be more, so it fetches the next one.
+	batch := make([]*comic.RankedCharacter, 1000)
+	for i := range batch {
+		batch[i] = &comic.RankedCharacter{Slug: "test"}
+	}
+	publisherSvc := mock_comic.NewMockPublisherServicer(ctrl)
+	publisherSvc.EXPECT().Publisher(comic.PublisherSlug("dc")).Return(&comic.Publisher{ID: 2, Slug: "dc"}, nil)
+	rankedSvc := mock_comic.NewMockRankedServicer(ctrl)
+	gomock.InOrder(
+		rankedSvc.EXPECT().PublisherPopular(comic.PublisherSlug("dc"), gomock.Any()).Return(batch, nil),
+		rankedSvc.EXPECT().PublisherPopular(comic.PublisherSlug("dc"), gomock.Any()).Return(batch[:1], nil),
+	)
+
 	e := echo.New()
-	req := httptest.NewRequest(http.MethodGet, "/characters?page=1", nil)
+	req := httptest.NewRequest(http.MethodGet, "/publishers/dc?format=ndjson", nil)
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
-	header := c.Response().Header()
-	stats1 := comic.CharacterStats{
-		Average: 2, AverageRank: 1, IssueCount: 10, IssueCountRank: 1,
+	c.SetParamNames("slug")
+	c.SetParamValues("dc")
+
+	err := web.NewPublisherController(rankedSvc, publisherSvc).Popular(c)
+	assert.Nil(t, err)
+	assert.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	assert.Len(t, lines, 1001)
+	assert.Contains(t, lines[0], `"slug":"test"`)
+}
+
+func TestTrendingControllerTrendingInvalidFormat(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rankedSvc := mock_comic.NewMockRankedServicer(ctrl)
+	rankedSvc.EXPECT().Trending(gomock.Any(), gomock.Any()).Times(0)
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/trending/marvel?format=xml", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	err := web.NewTrendingController(rankedSvc, mock_comic.NewMockPublisherServicer(ctrl)).Trending(c).(*echo.HTTPError)
+	assert.Equal(t, http.StatusBadRequest, err.Code)
+}
+
+func TestCharacterControllerIssues(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ch := mockCharacter()
+	date := time.Date(1979, time.November, 1, 0, 0, 0, 0, time.UTC)
+	cis := []*comic.CharacterIssue{
+		{
+			CharacterID:    ch.ID,
+			IssueID:        1,
+			AppearanceType: comic.Main,
+			Issue: &comic.Issue{
+				ID:                 1,
+				SaleDate:           date,
+				PublicationDate:    date,
+				Format:             comic.FormatStandard,
+				VendorSeriesName:   "Uncanny X-Men",
+				VendorSeriesNumber: "129",
+			},
+		},
 	}
-	stats2 := comic.CharacterStats{
-		Average: 2, AverageRank: 2, IssueCountRank: 2, IssueCount: 5,
+	characterSvc := mock_comic.NewMockCharacterServicer(ctrl)
+	characterSvc.EXPECT().Character(comic.CharacterSlug("emma-frost")).Return(ch, nil)
+	characterSvc.EXPECT().Issues(comic.CharacterIssueCriteria{
+		CharacterIDs:   []comic.CharacterID{ch.ID},
+		AppearanceType: comic.Main,
+		Formats:        []comic.Format{comic.FormatStandard, comic.FormatOGN},
+		Years:          []int{1979},
+		SortBy:         comic.SaleDateDesc,
+		Limit:          25,
+	}).Return(cis, nil)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/characters/emma-frost/issues?year=1979&format=standard,ogn&category=main&sort=newest", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues("emma-frost")
+
+	characterCtrl := web.NewCharacterController(mock_comic.NewMockExpandedServicer(ctrl), mock_comic.NewMockRankedServicer(ctrl), characterSvc)
+	err := characterCtrl.Issues(c)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, c.Response().Status)
+	assert.Contains(t, rec.Body.String(), `"vendor_series_name": "Uncanny X-Men"`)
+	assert.Contains(t, rec.Body.String(), `"appearance_type": "main"`)
+}
+
+func TestCharacterControllerSeries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	expandedSvc := mock_comic.NewMockExpandedServicer(ctrl)
+	expandedSvc.EXPECT().Series(comic.CharacterSlug("emma-frost")).Return(&comic.CharacterSeries{
+		CharacterSlug: "emma-frost",
+		IssueCount:    40,
+		SeriesCount:   2,
+		Series: []comic.SeriesAppearances{
+			{Name: "Uncanny X-Men", IssueCount: 30, Main: 28, Alternate: 2, FirstYear: 1980, LastYear: 2015, Share: 0.75},
+		},
+	}, nil)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/characters/emma-frost/series", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues("emma-frost")
+
+	err := web.NewCharacterController(expandedSvc, mock_comic.NewMockRankedServicer(ctrl), mock_comic.NewMockCharacterServicer(ctrl)).Series(c)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, `"series_count": 2`)
+	assert.Contains(t, body, `"name": "Uncanny X-Men"`)
+	assert.Contains(t, body, `"share": 0.75`)
+}
+
+func TestCharacterControllerSeriesNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	expandedSvc := mock_comic.NewMockExpandedServicer(ctrl)
+	expandedSvc.EXPECT().Series(gomock.Any()).Return(nil, nil)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/characters/nope/series", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues("nope")
+
+	err := web.NewCharacterController(expandedSvc, mock_comic.NewMockRankedServicer(ctrl), mock_comic.NewMockCharacterServicer(ctrl)).Series(c).(*echo.HTTPError)
+	assert.Equal(t, http.StatusNotFound, err.Code)
+}
+
+func TestCharacterControllerMonthlyAppearances(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	expandedSvc := mock_comic.NewMockExpandedServicer(ctrl)
+	expandedSvc.EXPECT().MonthlyAppearances(comic.CharacterSlug("emma-frost")).Return(&comic.AppearancesByMonths{
+		CharacterSlug: "emma-frost",
+		Aggregates: []comic.MonthlyAggregate{
+			{Year: 2018, Month: 0, Alternate: 1},
+			{Year: 2018, Month: 1, Main: 4},
+		},
+	}, nil)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/characters/emma-frost/appearances/monthly", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues("emma-frost")
+
+	err := web.NewCharacterController(expandedSvc, mock_comic.NewMockRankedServicer(ctrl), mock_comic.NewMockCharacterServicer(ctrl)).MonthlyAppearances(c)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, `"month": 0`)
+	assert.Contains(t, body, `"main": 4`)
+}
+
+func TestCharacterControllerMonthlyAppearancesNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	expandedSvc := mock_comic.NewMockExpandedServicer(ctrl)
+	expandedSvc.EXPECT().MonthlyAppearances(gomock.Any()).Return(nil, nil)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/characters/nope/appearances/monthly", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues("nope")
+
+	err := web.NewCharacterController(expandedSvc, mock_comic.NewMockRankedServicer(ctrl), mock_comic.NewMockCharacterServicer(ctrl)).MonthlyAppearances(c).(*echo.HTTPError)
+	assert.Equal(t, http.StatusNotFound, err.Code)
+}
+
+func TestCharacterControllerRankHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ch := mockCharacter()
+	characterSvc := mock_comic.NewMockCharacterServicer(ctrl)
+	characterSvc.EXPECT().Character(comic.CharacterSlug("emma-frost")).Return(ch, nil)
+	rankedSvc := mock_comic.NewMockRankedServicer(ctrl)
+	rankedSvc.EXPECT().RankHistory(ch.ID, comic.MainStats).Return([]*comic.RankSnapshot{
+		{CharacterID: ch.ID, Category: comic.MainStats, IssueCountRank: 12, IssueCount: 400, SnapshotDate: time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{CharacterID: ch.ID, Category: comic.MainStats, IssueCountRank: 10, IssueCount: 420, SnapshotDate: time.Date(2019, time.February, 1, 0, 0, 0, 0, time.UTC)},
+	}, nil)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/characters/emma-frost/rank-history?category=main", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues("emma-frost")
+
+	err := web.NewCharacterController(mock_comic.NewMockExpandedServicer(ctrl), rankedSvc, characterSvc).RankHistory(c)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, `"category": "main"`)
+	assert.Contains(t, body, `"issue_count_rank": 10`)
+	assert.Contains(t, body, `"date": "2019-01-01T00:00:00Z"`)
+}
+
+func TestCharacterControllerRankHistoryNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	characterSvc := mock_comic.NewMockCharacterServicer(ctrl)
+	characterSvc.EXPECT().Character(gomock.Any()).Return(nil, nil)
+	rankedSvc := mock_comic.NewMockRankedServicer(ctrl)
+	rankedSvc.EXPECT().RankHistory(gomock.Any(), gomock.Any()).Times(0)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/characters/nope/rank-history", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues("nope")
+
+	err := web.NewCharacterController(mock_comic.NewMockExpandedServicer(ctrl), rankedSvc, characterSvc).RankHistory(c).(*echo.HTTPError)
+	assert.Equal(t, http.StatusNotFound, err.Code)
+}
+
+func TestCharacterControllerCoAppearances(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ch := mockCharacter()
+	characterSvc := mock_comic.NewMockCharacterServicer(ctrl)
+	characterSvc.EXPECT().Character(comic.CharacterSlug("emma-frost")).Return(ch, nil)
+	rankedSvc := mock_comic.NewMockRankedServicer(ctrl)
+	rankedSvc.EXPECT().CoAppearances(ch.ID, comic.CoAppearanceCriteria{SortBy: comic.HighestAffinity, Limit: 25, Offset: 24}).Return([]*comic.CoAppearance{
+		{Slug: "cyclops", Name: "Cyclops", IssueCount: 300, Affinity: 0.25},
+	}, nil)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/characters/emma-frost/co-appearances?sort=affinity&page=2", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues("emma-frost")
+
+	err := web.NewCharacterController(mock_comic.NewMockExpandedServicer(ctrl), rankedSvc, characterSvc).CoAppearances(c)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, `"slug": "cyclops"`)
+	assert.Contains(t, body, `"issue_count": 300`)
+	assert.Contains(t, body, `"affinity": 0.25`)
+}
+
+func TestCharacterControllerCoAppearancesNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	characterSvc := mock_comic.NewMockCharacterServicer(ctrl)
+	characterSvc.EXPECT().Character(gomock.Any()).Return(nil, nil)
+	rankedSvc := mock_comic.NewMockRankedServicer(ctrl)
+	rankedSvc.EXPECT().CoAppearances(gomock.Any(), gomock.Any()).Times(0)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/characters/nope/co-appearances", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues("nope")
+
+	err := web.NewCharacterController(mock_comic.NewMockExpandedServicer(ctrl), rankedSvc, characterSvc).CoAppearances(c).(*echo.HTTPError)
+	assert.Equal(t, http.StatusNotFound, err.Code)
+}
+
+func mockCoAppearanceGraph() *comic.CoAppearanceGraph {
+	return &comic.CoAppearanceGraph{
+		Nodes: []*comic.CoAppearanceNode{
+			{ID: 1, Slug: "emma-frost", Name: "Emma Frost", Publisher: "marvel", IssueCount: 1000},
+			{ID: 2, Slug: "cyclops", Name: "Cyclops", Publisher: "marvel", IssueCount: 1500},
+		},
+		Edges: []*comic.CoAppearanceEdge{{Source: 1, Target: 2, IssueCount: 300, Affinity: 0.1364}},
 	}
-	rankedChrs := []*comic.RankedCharacter{
-		{ID: 1, PublisherID: 1, Stats: stats1, Name: "Test", Slug: "test"},
-		{ID: 2, PublisherID: 1, Stats: stats2, Name: "Test2", Slug: "test2"},
+}
+
+func TestCharacterControllerCoAppearanceGraph(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rankedSvc := mock_comic.NewMockRankedServicer(ctrl)
+	rankedSvc.EXPECT().CoAppearanceGraph(comic.CoAppearanceGraphCriteria{MinIssueCount: 10, Limit: 1000}).Return(mockCoAppearanceGraph(), nil)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/co-appearances", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	err := web.NewCharacterController(mock_comic.NewMockExpandedServicer(ctrl), rankedSvc, mock_comic.NewMockCharacterServicer(ctrl)).CoAppearanceGraph(c)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, `"nodes": [`)
+	assert.Contains(t, body, `"source": 1`)
+	assert.Contains(t, body, `"affinity": 0.1364`)
+}
+
+func TestCharacterControllerCoAppearanceGraphML(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rankedSvc := mock_comic.NewMockRankedServicer(ctrl)
+	rankedSvc.EXPECT().CoAppearanceGraph(comic.CoAppearanceGraphCriteria{MinIssueCount: 50, Limit: 200}).Return(mockCoAppearanceGraph(), nil)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/co-appearances?format=graphml&min=50&limit=200", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	err := web.NewCharacterController(mock_comic.NewMockExpandedServicer(ctrl), rankedSvc, mock_comic.NewMockCharacterServicer(ctrl)).CoAppearanceGraph(c)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, web.MIMEApplicationGraphML, rec.Header().Get(echo.HeaderContentType))
+	body := rec.Body.String()
+	assert.Contains(t, body, `<graphml xmlns="http://graphml.graphdrawing.org/xmlns">`)
+	assert.Contains(t, body, `<key id="weight" for="edge" attr.name="weight" attr.type="int"></key>`)
+	assert.Contains(t, body, `<graph id="co-appearances" edgedefault="undirected">`)
+	assert.Contains(t, body, `<node id="c2">`)
+	assert.Contains(t, body, `<data key="slug">cyclops</data>`)
+	assert.Contains(t, body, `<edge source="c1" target="c2">`)
+	assert.Contains(t, body, `<data key="weight">300</data>`)
+}
+
+func TestCharacterControllerCoAppearanceGraphInvalidParams(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rankedSvc := mock_comic.NewMockRankedServicer(ctrl)
+	rankedSvc.EXPECT().CoAppearanceGraph(gomock.Any()).Times(0)
+	for _, query := range []string{"format=csv", "min=0", "limit=10001", "limit=abc"} {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/co-appearances?"+query, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := web.NewCharacterController(mock_comic.NewMockExpandedServicer(ctrl), rankedSvc, mock_comic.NewMockCharacterServicer(ctrl)).CoAppearanceGraph(c).(*echo.HTTPError)
+		assert.Equal(t, http.StatusBadRequest, err.Code, query)
 	}
+}
+
+func TestCharacterControllerIssuesNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	characterSvc := mock_comic.NewMockCharacterServicer(ctrl)
+	characterSvc.EXPECT().Character(gomock.Any()).Return(nil, nil)
+	characterSvc.EXPECT().Issues(gomock.Any()).Times(0)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/characters/emma-frost/issues", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	characterCtrl := web.NewCharacterController(mock_comic.NewMockExpandedServicer(ctrl), mock_comic.NewMockRankedServicer(ctrl), characterSvc)
+	err := characterCtrl.Issues(c).(*echo.HTTPError)
+	assert.Equal(t, http.StatusNotFound, err.Code)
+}
+
+func TestCharacterControllerIssuesBadRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
 
+	characterSvc := mock_comic.NewMockCharacterServicer(ctrl)
+	characterSvc.EXPECT().Character(gomock.Any()).Times(0)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/characters/emma-frost/issues?variant=maybe", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	characterCtrl := web.NewCharacterController(mock_comic.NewMockExpandedServicer(ctrl), mock_comic.NewMockRankedServicer(ctrl), characterSvc)
+	err := characterCtrl.Issues(c).(*echo.HTTPError)
+	assert.Equal(t, http.StatusBadRequest, err.Code)
+}
+
+func TestCharacterControllerIssuesInvalidFormat(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	characterSvc := mock_comic.NewMockCharacterServicer(ctrl)
+	characterSvc.EXPECT().Character(gomock.Any()).Times(0)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/characters/emma-frost/issues?format=standard,%20comic", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	characterCtrl := web.NewCharacterController(mock_comic.NewMockExpandedServicer(ctrl), mock_comic.NewMockRankedServicer(ctrl), characterSvc)
+	err := characterCtrl.Issues(c).(*echo.HTTPError)
+	assert.Equal(t, http.StatusBadRequest, err.Code)
+	pe, ok := err.Internal.(*web.ParamError)
+	assert.True(t, ok)
+	assert.Equal(t, "format", pe.Param)
+	assert.Equal(t, "comic", pe.Value)
+	assert.Contains(t, pe.Allowed, "standard")
+}
+
+func TestCharacterControllerCompare(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ch := mockCharacter()
+	ch2 := &comic.Character{ID: 2, Slug: "jean-grey", Name: "Jean Grey"}
+	expandedSvc := mock_comic.NewMockExpandedServicer(ctrl)
+	expandedSvc.EXPECT().Compare(ch.Slug, ch2.Slug).Return(&comic.Comparison{
+		Years: []int{1979},
+		Characters: []*comic.ExpandedCharacter{
+			{Character: ch, Appearances: comic.AppearancesByYears{CharacterSlug: ch.Slug}},
+			{Character: ch2, Appearances: comic.AppearancesByYears{CharacterSlug: ch2.Slug}},
+		},
+	}, nil)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/compare?slugs=emma-frost,jean-grey,emma-frost", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	characterCtrl := web.NewCharacterController(expandedSvc, mock_comic.NewMockRankedServicer(ctrl), mock_comic.NewMockCharacterServicer(ctrl))
+	err := characterCtrl.Compare(c)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, c.Response().Status)
+	assert.Contains(t, rec.Body.String(), `"slug": "jean-grey"`)
+}
+
+func TestCharacterControllerCompareNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	expandedSvc := mock_comic.NewMockExpandedServicer(ctrl)
+	expandedSvc.EXPECT().Compare(gomock.Any()).Return(&comic.Comparison{
+		Years:      []int{1979},
+		Characters: []*comic.ExpandedCharacter{{Character: mockCharacter()}},
+	}, nil)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/compare?slugs=emma-frost,not-found", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	characterCtrl := web.NewCharacterController(expandedSvc, mock_comic.NewMockRankedServicer(ctrl), mock_comic.NewMockCharacterServicer(ctrl))
+	err := characterCtrl.Compare(c).(*echo.HTTPError)
+	assert.Equal(t, http.StatusNotFound, err.Code)
+}
+
+func TestCharacterControllerCompareBadRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	expandedSvc := mock_comic.NewMockExpandedServicer(ctrl)
+	expandedSvc.EXPECT().Compare(gomock.Any()).Times(0)
+	characterCtrl := web.NewCharacterController(expandedSvc, mock_comic.NewMockRankedServicer(ctrl), mock_comic.NewMockCharacterServicer(ctrl))
+
+	for _, q := range []string{"", "emma-frost", "a,b,c,d,e,f"} {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/compare?slugs="+q, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		err := characterCtrl.Compare(c).(*echo.HTTPError)
+		assert.Equal(t, http.StatusBadRequest, err.Code)
+	}
+}
+
+func TestCharacterControllerCharactersInvalidParams(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rankedSvc := mock_comic.NewMockRankedServicer(ctrl)
+	rankedSvc.EXPECT().AllPopular(gomock.Any()).Times(0)
+	characterCtrl := web.NewCharacterController(mock_comic.NewMockExpandedServicer(ctrl), rankedSvc, mock_comic.NewMockCharacterServicer(ctrl))
+
+	for q, param := range map[string]string{"sort=nope": "sort", "category=nope": "category", "format=xml": "format"} {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/characters?"+q, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		err := characterCtrl.Characters(c).(*echo.HTTPError)
+		assert.Equal(t, http.StatusBadRequest, err.Code)
+		pe, ok := err.Internal.(*web.ParamError)
+		assert.True(t, ok)
+		assert.Equal(t, param, pe.Param)
+		assert.Equal(t, c.QueryParam(param), pe.Value)
+		assert.NotEmpty(t, pe.Allowed)
+	}
+}
+
+func TestCharacterControllerCharactersRelevance(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
 	cr := comic.PopularCriteria{
-		SortBy:         comic.MostIssues,
-		AppearanceType: comic.Main | comic.Alternate,
+		SortBy:         comic.MostRelevant,
+		AppearanceType: comic.Main,
+		HalfLife:       5,
 		Limit:          25,
 		Offset:         0,
 	}
 	rankedSvc := mock_comic.NewMockRankedServicer(ctrl)
-	rankedSvc.EXPECT().MarvelPopular(cr).Return(rankedChrs, nil)
+	rankedSvc.EXPECT().AllPopularTotal(cr).Return(1, nil)
+	rankedSvc.EXPECT().AllPopular(cr).Return([]*comic.RankedCharacter{{ID: 1, Slug: "emma-frost", Stats: comic.CharacterStats{Relevance: 12.5, RelevanceRank: 1}}}, nil)
 
-	publisherCtrlr := web.NewPublisherController(rankedSvc)
-	err := publisherCtrlr.Marvel(c)
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/characters?sort=relevance&category=main&half_life=5", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	characterCtrl := web.NewCharacterController(mock_comic.NewMockExpandedServicer(ctrl), rankedSvc, mock_comic.NewMockCharacterServicer(ctrl))
+	assert.Nil(t, characterCtrl.Characters(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"relevance_rank": 1`)
+}
 
+func TestCharacterControllerCharactersInvalidHalfLife(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rankedSvc := mock_comic.NewMockRankedServicer(ctrl)
+	rankedSvc.EXPECT().AllPopular(gomock.Any()).Times(0)
+	characterCtrl := web.NewCharacterController(mock_comic.NewMockExpandedServicer(ctrl), rankedSvc, mock_comic.NewMockCharacterServicer(ctrl))
+
+	for _, q := range []string{"sort=relevance&half_life=0", "sort=relevance&half_life=101", "sort=relevance&half_life=ten"} {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/characters?"+q, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		err := characterCtrl.Characters(c).(*echo.HTTPError)
+		assert.Equal(t, http.StatusBadRequest, err.Code)
+	}
+}
+
+func TestCharacterControllerCharactersInvalidCursor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rankedSvc := mock_comic.NewMockRankedServicer(ctrl)
+	rankedSvc.EXPECT().AllPopular(gomock.Any()).Times(0)
+	characterCtrl := web.NewCharacterController(mock_comic.NewMockExpandedServicer(ctrl), rankedSvc, mock_comic.NewMockCharacterServicer(ctrl))
+
+	for _, q := range []string{"after=nope", "before=1-x", "after=1-2&before=3-4"} {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/characters?"+q, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		err := characterCtrl.Characters(c).(*echo.HTTPError)
+		assert.Equal(t, http.StatusBadRequest, err.Code)
+	}
+}
+
+func TestCharacterControllerCharactersLastPage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rankedSvc := mock_comic.NewMockRankedServicer(ctrl)
+	rankedSvc.EXPECT().AllPopularTotal(gomock.Any()).Return(50, nil)
+	rankedSvc.EXPECT().AllPopular(gomock.Any()).Do(func(cr comic.PopularCriteria) {
+		assert.Equal(t, 2, cr.Limit)
+		assert.NotNil(t, cr.Before)
+		assert.Nil(t, cr.After)
+	}).Return([]*comic.RankedCharacter{{ID: 49, Stats: comic.CharacterStats{IssueCountRank: 40}}, {ID: 50, Stats: comic.CharacterStats{IssueCountRank: 41}}}, nil)
+	characterCtrl := web.NewCharacterController(mock_comic.NewMockExpandedServicer(ctrl), rankedSvc, mock_comic.NewMockCharacterServicer(ctrl))
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/characters?before=last&page=3", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	err := characterCtrl.Characters(c)
 	assert.Nil(t, err)
-	assert.Equal(t, http.StatusOK, rec.Code)
-	assert.Equal(t, "application/json; charset=UTF-8", header.Get("Content-Type"))
+	body := rec.Body.String()
+	assert.Contains(t, body, `"total_pages": 3`)
+	assert.Contains(t, body, `"previous_page": "/characters?before=40-49\u0026page=2"`)
+	assert.Contains(t, body, `"next_page": ""`)
 }
 
-func TestTrendingControllerMarvel(t *testing.T) {
+func TestPublisherControllerPopular(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	e := echo.New()
-	req := httptest.NewRequest(http.MethodGet, "/characters?page=1", nil)
+	req := httptest.NewRequest(http.MethodGet, "/publishers/dc?page=1", nil)
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues("dc")
 	header := c.Response().Header()
 	stats1 := comic.CharacterStats{
 		Average: 2, AverageRank: 1, IssueCount: 10, IssueCountRank: 1,
@@ -310,28 +958,59 @@ func TestTrendingControllerMarvel(t *testing.T) {
 		Average: 2, AverageRank: 2, IssueCountRank: 2, IssueCount: 5,
 	}
 	rankedChrs := []*comic.RankedCharacter{
-		{ID: 1, PublisherID: 1, Stats: stats1, Name: "Test", Slug: "test"},
-		{ID: 2, PublisherID: 1, Stats: stats2, Name: "Test2", Slug: "test2"},
+		{ID: 1, PublisherID: 2, Stats: stats1, Name: "Test", Slug: "test"},
+		{ID: 2, PublisherID: 2, Stats: stats2, Name: "Test2", Slug: "test2"},
 	}
+	cr := comic.PopularCriteria{
+		SortBy:         comic.MostIssues,
+		AppearanceType: comic.Main | comic.Alternate,
+		Limit:          25,
+		Offset:         0,
+	}
+	publisherSvc := mock_comic.NewMockPublisherServicer(ctrl)
+	publisherSvc.EXPECT().Publisher(comic.PublisherSlug("dc")).Return(&comic.Publisher{ID: 2, Slug: "dc"}, nil)
 	rankedSvc := mock_comic.NewMockRankedServicer(ctrl)
-	rankedSvc.EXPECT().MarvelTrending(25, 0).Return(rankedChrs, nil)
+	rankedSvc.EXPECT().PublisherPopularTotal(comic.PublisherSlug("dc")).Return(2, nil)
+	rankedSvc.EXPECT().PublisherPopular(comic.PublisherSlug("dc"), cr).Return(rankedChrs, nil)
 
-	trendingCtrl := web.NewTrendingController(rankedSvc)
-	err := trendingCtrl.Marvel(c)
+	publisherCtrlr := web.NewPublisherController(rankedSvc, publisherSvc)
+	err := publisherCtrlr.Popular(c)
 
 	assert.Nil(t, err)
 	assert.Equal(t, http.StatusOK, rec.Code)
 	assert.Equal(t, "application/json; charset=UTF-8", header.Get("Content-Type"))
 }
 
-func TestTrendingControllerDC(t *testing.T) {
+func TestPublisherControllerPopularNotFound(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	e := echo.New()
-	req := httptest.NewRequest(http.MethodGet, "/characters?page=1", nil)
+	req := httptest.NewRequest(http.MethodGet, "/publishers/nope", nil)
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues("nope")
+	publisherSvc := mock_comic.NewMockPublisherServicer(ctrl)
+	publisherSvc.EXPECT().Publisher(comic.PublisherSlug("nope")).Return(nil, nil)
+	rankedSvc := mock_comic.NewMockRankedServicer(ctrl)
+	rankedSvc.EXPECT().PublisherPopular(gomock.Any(), gomock.Any()).Times(0)
+
+	publisherCtrlr := web.NewPublisherController(rankedSvc, publisherSvc)
+	err := publisherCtrlr.Popular(c).(*echo.HTTPError)
+	assert.Equal(t, http.StatusNotFound, err.Code)
+}
+
+func TestTrendingControllerTrending(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/trending/marvel?page=1", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues("marvel")
 	header := c.Response().Header()
 	stats1 := comic.CharacterStats{
 		Average: 2, AverageRank: 1, IssueCount: 10, IssueCountRank: 1,
@@ -343,17 +1022,81 @@ func TestTrendingControllerDC(t *testing.T) {
 		{ID: 1, PublisherID: 1, Stats: stats1, Name: "Test", Slug: "test"},
 		{ID: 2, PublisherID: 1, Stats: stats2, Name: "Test2", Slug: "test2"},
 	}
+	publisherSvc := mock_comic.NewMockPublisherServicer(ctrl)
+	publisherSvc.EXPECT().Publisher(comic.PublisherSlug("marvel")).Return(&comic.Publisher{ID: 1, Slug: "marvel"}, nil)
 	rankedSvc := mock_comic.NewMockRankedServicer(ctrl)
-	rankedSvc.EXPECT().DCTrending(25, 0).Return(rankedChrs, nil)
+	rankedSvc.EXPECT().Trending(comic.PublisherSlug("marvel"), comic.TrendingCriteria{Window: comic.TwelveMonths, Limit: 25}).Return(rankedChrs, nil)
 
-	trendingCtrl := web.NewTrendingController(rankedSvc)
-	err := trendingCtrl.DC(c)
+	trendingCtrl := web.NewTrendingController(rankedSvc, publisherSvc)
+	err := trendingCtrl.Trending(c)
 
 	assert.Nil(t, err)
 	assert.Equal(t, http.StatusOK, rec.Code)
 	assert.Equal(t, "application/json; charset=UTF-8", header.Get("Content-Type"))
 }
 
+func TestTrendingControllerTrendingWindow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/trending/marvel?months=6&page=2", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues("marvel")
+	previous := uint(3)
+	rankedChrs := []*comic.RankedCharacter{
+		{ID: 1, Stats: comic.CharacterStats{IssueCountRank: 25}, Name: "Test", Slug: "test", Trend: comic.NewRankTrend(25, &previous)},
+	}
+	publisherSvc := mock_comic.NewMockPublisherServicer(ctrl)
+	publisherSvc.EXPECT().Publisher(comic.PublisherSlug("marvel")).Return(&comic.Publisher{ID: 1, Slug: "marvel"}, nil)
+	rankedSvc := mock_comic.NewMockRankedServicer(ctrl)
+	rankedSvc.EXPECT().Trending(comic.PublisherSlug("marvel"), comic.TrendingCriteria{Window: comic.SixMonths, Limit: 25, Offset: 24}).Return(rankedChrs, nil)
+
+	err := web.NewTrendingController(rankedSvc, publisherSvc).Trending(c)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"previous_rank": 3`)
+	assert.Contains(t, rec.Body.String(), `"change": -22`)
+	assert.Contains(t, rec.Body.String(), `"direction": "down"`)
+}
+
+func TestTrendingControllerTrendingInvalidWindow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rankedSvc := mock_comic.NewMockRankedServicer(ctrl)
+	rankedSvc.EXPECT().Trending(gomock.Any(), gomock.Any()).Times(0)
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/trending/marvel?months=1", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	err := web.NewTrendingController(rankedSvc, mock_comic.NewMockPublisherServicer(ctrl)).Trending(c).(*echo.HTTPError)
+	assert.Equal(t, http.StatusBadRequest, err.Code)
+}
+
+func TestTrendingControllerTrendingNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/trending/nope", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues("nope")
+	publisherSvc := mock_comic.NewMockPublisherServicer(ctrl)
+	publisherSvc.EXPECT().Publisher(comic.PublisherSlug("nope")).Return(nil, nil)
+	rankedSvc := mock_comic.NewMockRankedServicer(ctrl)
+	rankedSvc.EXPECT().Trending(gomock.Any(), gomock.Any()).Times(0)
+
+	trendingCtrl := web.NewTrendingController(rankedSvc, publisherSvc)
+	err := trendingCtrl.Trending(c).(*echo.HTTPError)
+	assert.Equal(t, http.StatusNotFound, err.Code)
+}
+
 func mockCharacter() *comic.Character {
 	publisher := comic.Publisher{Name: "Marvel", Slug: "marvel", ID: 1}
 	return &comic.Character{
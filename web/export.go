@@ -0,0 +1,280 @@
+package web
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"github.com/comiccruncher/comiccruncher/comic"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// The number of ranked characters to fetch at a time while streaming an export.
+	exportBatchSize = 1000
+	// MIMETextCSV is the content type for CSV exports.
+	MIMETextCSV = "text/csv; charset=UTF-8"
+	// MIMEApplicationNDJSON is the content type for newline-delimited JSON exports.
+	MIMEApplicationNDJSON = "application/x-ndjson"
+	// MIMEApplicationGraphML is the content type for GraphML exports.
+	MIMEApplicationGraphML = "application/graphml+xml; charset=UTF-8"
+)
+
+// ExportFormat is the format for exporting a list.
+type ExportFormat string
+
+const (
+	// CSV exports the list as comma-separated values with a header row.
+	CSV ExportFormat = "csv"
+	// NDJSON exports the list as one JSON object per line.
+	NDJSON ExportFormat = "ndjson"
+	// GraphML exports a graph as GraphML for tools like Gephi and Cytoscape.
+	GraphML ExportFormat = "graphml"
+)
+
+// The allowed values for the `format` parameter for lists that can be exported.
+var exportFormats = []string{"json", string(CSV), string(NDJSON)}
+
+// The allowed values for the `format` parameter for graphs. The first is the default.
+var graphFormats = []string{"json", string(GraphML)}
+
+// The attributes for the co-appearance graph's nodes and edges in GraphML.
+var graphMLKeys = []graphMLKey{
+	{ID: "slug", For: "node", Name: "slug", Type: "string"},
+	{ID: "name", For: "node", Name: "name", Type: "string"},
+	{ID: "publisher", For: "node", Name: "publisher", Type: "string"},
+	{ID: "issue_count", For: "node", Name: "issue_count", Type: "int"},
+	{ID: "weight", For: "edge", Name: "weight", Type: "int"},
+	{ID: "affinity", For: "edge", Name: "affinity", Type: "double"},
+}
+
+// The root element of a GraphML document.
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+// An attribute for the nodes or edges in GraphML.
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+// The graph with its nodes and edges in GraphML.
+type graphMLGraph struct {
+	ID          string           `xml:"id,attr"`
+	EdgeDefault string           `xml:"edgedefault,attr"`
+	Nodes       []graphMLElement `xml:"node"`
+	Edges       []graphMLElement `xml:"edge"`
+}
+
+// A node or an edge in GraphML. Edges have a source and a target instead of an ID.
+type graphMLElement struct {
+	ID     string        `xml:"id,attr,omitempty"`
+	Source string        `xml:"source,attr,omitempty"`
+	Target string        `xml:"target,attr,omitempty"`
+	Data   []graphMLData `xml:"data"`
+}
+
+// The value of a node's or an edge's attribute in GraphML.
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// The columns for exported ranked characters.
+var rankedColumns = []string{
+	"slug",
+	"name",
+	"other_name",
+	"publisher",
+	"category",
+	"issue_count_rank",
+	"issue_count",
+	"average_issues_per_year_rank",
+	"average_issues_per_year",
+	"image",
+	"vendor_image",
+	"vendor_url",
+}
+
+// RankedRow is a ranked character with its stats flattened into columns for exporting.
+type RankedRow struct {
+	Slug        string  `json:"slug"`
+	Name        string  `json:"name"`
+	OtherName   string  `json:"other_name"`
+	Publisher   string  `json:"publisher"`
+	Category    string  `json:"category"`
+	IssueRank   uint    `json:"issue_count_rank"`
+	IssueCount  uint    `json:"issue_count"`
+	AverageRank uint    `json:"average_issues_per_year_rank"`
+	Average     float64 `json:"average_issues_per_year"`
+	Image       string  `json:"image"`
+	VendorImage string  `json:"vendor_image"`
+	VendorURL   string  `json:"vendor_url"`
+}
+
+// Values returns the row's values in the same order as the columns.
+func (r RankedRow) Values() []string {
+	return []string{
+		r.Slug,
+		r.Name,
+		r.OtherName,
+		r.Publisher,
+		r.Category,
+		strconv.FormatUint(uint64(r.IssueRank), 10),
+		strconv.FormatUint(uint64(r.IssueCount), 10),
+		strconv.FormatUint(uint64(r.AverageRank), 10),
+		strconv.FormatFloat(r.Average, 'f', -1, 64),
+		r.Image,
+		r.VendorImage,
+		r.VendorURL,
+	}
+}
+
+// NewRankedRow flattens the ranked character for exporting.
+func NewRankedRow(c *comic.RankedCharacter) RankedRow {
+	r := RankedRow{
+		Slug:        c.Slug.Value(),
+		Name:        c.Name,
+		OtherName:   c.OtherName,
+		Publisher:   c.Publisher.Slug.Value(),
+		Category:    string(c.Stats.Category),
+		IssueRank:   c.Stats.IssueCountRank,
+		IssueCount:  c.Stats.IssueCount,
+		AverageRank: c.Stats.AverageRank,
+		Average:     c.Stats.Average,
+		VendorURL:   c.VendorURL,
+	}
+	if c.Image != "" {
+		r.Image = cdnURL + "/" + c.Image
+	}
+	if c.VendorImage != "" {
+		r.VendorImage = cdnURL + "/" + c.VendorImage
+	}
+	return r
+}
+
+// Fetches a batch of ranked characters to export.
+type rankedFetcher func(limit, offset int) ([]*comic.RankedCharacter, error)
+
+// Gets the export format from the `format` parameter or else the `Accept` header.
+// Returns an empty format if the client wants the regular JSON view.
+func exportFormat(ctx echo.Context) (ExportFormat, error) {
+	if ctx.QueryParam("format") != "" {
+		f, err := oneOf(ctx, "format", exportFormats)
+		if f == "json" {
+			return "", err
+		}
+		return ExportFormat(f), err
+	}
+	accept := ctx.Request().Header.Get(echo.HeaderAccept)
+	if strings.Contains(accept, "text/csv") {
+		return CSV, nil
+	}
+	if strings.Contains(accept, MIMEApplicationNDJSON) {
+		return NDJSON, nil
+	}
+	return "", nil
+}
+
+// Streams all the ranked characters in the export format, a batch at a time, without the page limit.
+func exportRanked(ctx echo.Context, format ExportFormat, fetch rankedFetcher) error {
+	res := ctx.Response()
+	var write func(r RankedRow) error
+	var flush func() error
+	if format == CSV {
+		w := csv.NewWriter(res)
+		write = func(r RankedRow) error {
+			return w.Write(r.Values())
+		}
+		flush = func() error {
+			w.Flush()
+			return w.Error()
+		}
+		res.Header().Set(echo.HeaderContentType, MIMETextCSV)
+		res.WriteHeader(http.StatusOK)
+		if err := w.Write(rankedColumns); err != nil {
+			return err
+		}
+	} else {
+		enc := json.NewEncoder(res)
+		write = func(r RankedRow) error {
+			return enc.Encode(r)
+		}
+		flush = func() error {
+			return nil
+		}
+		res.Header().Set(echo.HeaderContentType, MIMEApplicationNDJSON)
+		res.WriteHeader(http.StatusOK)
+	}
+	for offset := 0; ; offset += exportBatchSize {
+		results, err := fetch(exportBatchSize, offset)
+		if err != nil {
+			return err
+		}
+		for _, c := range results {
+			if err := write(NewRankedRow(c)); err != nil {
+				return err
+			}
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+		res.Flush()
+		if len(results) < exportBatchSize {
+			return nil
+		}
+	}
+}
+
+// Writes the co-appearance graph as an undirected GraphML document. The edges are weighted by the number of
+// shared issues.
+func exportGraphML(ctx echo.Context, g *comic.CoAppearanceGraph) error {
+	doc := graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys:  graphMLKeys,
+		Graph: graphMLGraph{ID: "co-appearances", EdgeDefault: "undirected"},
+	}
+	for _, n := range g.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLElement{
+			ID: graphMLNodeID(n.ID),
+			Data: []graphMLData{
+				{Key: "slug", Value: n.Slug.Value()},
+				{Key: "name", Value: n.Name},
+				{Key: "publisher", Value: n.Publisher.Value()},
+				{Key: "issue_count", Value: strconv.FormatUint(uint64(n.IssueCount), 10)},
+			},
+		})
+	}
+	for _, e := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLElement{
+			Source: graphMLNodeID(e.Source),
+			Target: graphMLNodeID(e.Target),
+			Data: []graphMLData{
+				{Key: "weight", Value: strconv.FormatUint(uint64(e.IssueCount), 10)},
+				{Key: "affinity", Value: strconv.FormatFloat(e.Affinity, 'f', -1, 64)},
+			},
+		})
+	}
+	res := ctx.Response()
+	res.Header().Set(echo.HeaderContentType, MIMEApplicationGraphML)
+	res.WriteHeader(http.StatusOK)
+	if _, err := res.Write([]byte(xml.Header)); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(res)
+	enc.Indent("", "  ")
+	return enc.Encode(doc)
+}
+
+// The ID for the character's node in GraphML, like `c1`.
+func graphMLNodeID(id comic.CharacterID) string {
+	return "c" + strconv.FormatUint(uint64(id), 10)
+}
@@ -0,0 +1,561 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/comiccruncher/comiccruncher/comic"
+	"github.com/comiccruncher/comiccruncher/search"
+	"github.com/graphql-go/graphql"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"sync"
+)
+
+// The number of characters the search field returns.
+const graphSearchLimit = 5
+
+// GraphQLRequest is the body for a GraphQL request.
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// GraphQLController is the controller for querying the comic domain with GraphQL.
+type GraphQLController struct {
+	schema       graphql.Schema
+	rankedSvc    comic.RankedServicer
+	expandedSvc  comic.ExpandedServicer
+	publisherSvc comic.PublisherServicer
+	searcher     search.Searcher
+	ctr          comic.CharacterThumbRepository
+	amr          comic.AppearancesByYearsMapRepository
+}
+
+// GraphQL executes the query from the `query` parameter or from the JSON body.
+func (c *GraphQLController) GraphQL(ctx echo.Context) error {
+	var req GraphQLRequest
+	if ctx.Request().Method == http.MethodGet {
+		req.Query = ctx.QueryParam("query")
+		req.OperationName = ctx.QueryParam("operationName")
+	} else if err := ctx.Bind(&req); err != nil {
+		return NewBadRequestError("Invalid request body")
+	}
+	if req.Query == "" {
+		return NewBadRequestError("The query is required.")
+	}
+	loaders := &graphLoaders{
+		thumbnails:  &thumbnailsLoader{ctr: c.ctr},
+		appearances: &appearancesLoader{amr: c.amr},
+	}
+	res := graphql.Do(graphql.Params{
+		Schema:         c.schema,
+		RequestString:  req.Query,
+		OperationName:  req.OperationName,
+		VariableValues: req.Variables,
+		Context:        context.WithValue(ctx.Request().Context(), graphLoadersKey{}, loaders),
+	})
+	return ctx.JSON(http.StatusOK, res)
+}
+
+// A character for the GraphQL schema from any of the character models.
+// Thumbnails and appearances the model doesn't have get batch-loaded when they're asked for.
+type graphCharacter struct {
+	Slug              comic.CharacterSlug
+	Name              string
+	OtherName         string
+	Description       string
+	Image             string
+	VendorImage       string
+	VendorURL         string
+	VendorDescription string
+	Publisher         comic.Publisher
+	Thumbnails        *comic.CharacterThumbnails
+	Stats             []comic.CharacterStats
+	Appearances       *comic.AppearancesByYears
+	Trend             *comic.RankTrend
+	lazyThumbnails    bool
+	lazyAppearances   bool
+}
+
+// Creates the GraphQL character from a ranked character and queues its appearances to be loaded.
+func newGraphRankedCharacter(ctx context.Context, c *comic.RankedCharacter) *graphCharacter {
+	loadersFrom(ctx).appearances.queue(c.Slug)
+	cdnURLForThumbnails(c.Thumbnails)
+	return &graphCharacter{
+		Slug:              c.Slug,
+		Name:              c.Name,
+		OtherName:         c.OtherName,
+		Description:       c.Description,
+		Image:             cdnImage(c.Image),
+		VendorImage:       cdnImage(c.VendorImage),
+		VendorURL:         c.VendorURL,
+		VendorDescription: c.VendorDescription,
+		Publisher:         c.Publisher,
+		Thumbnails:        c.Thumbnails,
+		Stats:             []comic.CharacterStats{c.Stats},
+		Trend:             c.Trend,
+		lazyAppearances:   true,
+	}
+}
+
+// Creates the GraphQL character from an expanded character, which already has everything.
+func newGraphExpandedCharacter(c *comic.ExpandedCharacter) *graphCharacter {
+	cdnURLForThumbnails(c.Thumbnails)
+	return &graphCharacter{
+		Slug:              c.Slug,
+		Name:              c.Name,
+		OtherName:         c.OtherName,
+		Description:       c.Description,
+		Image:             cdnImage(c.Image),
+		VendorImage:       cdnImage(c.VendorImage),
+		VendorURL:         c.VendorURL,
+		VendorDescription: c.VendorDescription,
+		Publisher:         c.Publisher,
+		Thumbnails:        c.Thumbnails,
+		Stats:             c.Stats,
+		Appearances:       &c.Appearances,
+	}
+}
+
+// Creates the GraphQL character from a character and queues its thumbnails and appearances to be loaded.
+func newGraphCharacter(ctx context.Context, c *comic.Character) *graphCharacter {
+	l := loadersFrom(ctx)
+	l.thumbnails.queue(c.Slug)
+	l.appearances.queue(c.Slug)
+	return &graphCharacter{
+		Slug:              c.Slug,
+		Name:              c.Name,
+		OtherName:         c.OtherName,
+		Description:       c.Description,
+		Image:             cdnImage(c.Image),
+		VendorImage:       cdnImage(c.VendorImage),
+		VendorURL:         c.VendorURL,
+		VendorDescription: c.VendorDescription,
+		Publisher:         c.Publisher,
+		Stats:             []comic.CharacterStats{},
+		lazyThumbnails:    true,
+		lazyAppearances:   true,
+	}
+}
+
+// Prefixes the image with the CDN url if there is an image.
+func cdnImage(image string) string {
+	if image == "" {
+		return ""
+	}
+	return cdnURL + "/" + image
+}
+
+type graphLoadersKey struct{}
+
+// The batch loaders for a single GraphQL request.
+type graphLoaders struct {
+	thumbnails  *thumbnailsLoader
+	appearances *appearancesLoader
+}
+
+func loadersFrom(ctx context.Context) *graphLoaders {
+	return ctx.Value(graphLoadersKey{}).(*graphLoaders)
+}
+
+// Loads the thumbnails for all the queued characters with one call the first time any of them is asked for.
+type thumbnailsLoader struct {
+	ctr    comic.CharacterThumbRepository
+	mu     sync.Mutex
+	queued []comic.CharacterSlug
+	loaded map[comic.CharacterSlug]*comic.CharacterThumbnails
+}
+
+func (l *thumbnailsLoader) queue(slug comic.CharacterSlug) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.queued = appendSlug(l.queued, slug)
+}
+
+func (l *thumbnailsLoader) load(slug comic.CharacterSlug) (*comic.CharacterThumbnails, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if th, ok := l.loaded[slug]; ok {
+		return th, nil
+	}
+	slugs := appendSlug(l.queued, slug)
+	thumbs, err := l.ctr.AllThumbnails(slugs...)
+	if err != nil {
+		return nil, err
+	}
+	l.queued = nil
+	if l.loaded == nil {
+		l.loaded = make(map[comic.CharacterSlug]*comic.CharacterThumbnails, len(slugs))
+	}
+	for _, s := range slugs {
+		th := thumbs[s]
+		cdnURLForThumbnails(th)
+		l.loaded[s] = th
+	}
+	return l.loaded[slug], nil
+}
+
+// Loads the appearances for all the queued characters with one call the first time any of them is asked for.
+type appearancesLoader struct {
+	amr    comic.AppearancesByYearsMapRepository
+	mu     sync.Mutex
+	queued []comic.CharacterSlug
+	loaded map[comic.CharacterSlug]comic.AppearancesByYears
+}
+
+func (l *appearancesLoader) queue(slug comic.CharacterSlug) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.queued = appendSlug(l.queued, slug)
+}
+
+func (l *appearancesLoader) load(slug comic.CharacterSlug) (comic.AppearancesByYears, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if apps, ok := l.loaded[slug]; ok {
+		return apps, nil
+	}
+	slugs := appendSlug(l.queued, slug)
+	apps, err := l.amr.ListMap(slugs...)
+	if err != nil {
+		return comic.AppearancesByYears{}, err
+	}
+	l.queued = nil
+	if l.loaded == nil {
+		l.loaded = make(map[comic.CharacterSlug]comic.AppearancesByYears, len(slugs))
+	}
+	for _, s := range slugs {
+		a, ok := apps[s]
+		if !ok || a.Aggregates == nil {
+			a = comic.AppearancesByYears{CharacterSlug: s, Aggregates: []comic.YearlyAggregate{}}
+		}
+		l.loaded[s] = a
+	}
+	return l.loaded[slug], nil
+}
+
+// Appends the slug if it's not in the slugs already.
+func appendSlug(slugs []comic.CharacterSlug, slug comic.CharacterSlug) []comic.CharacterSlug {
+	for _, s := range slugs {
+		if s == slug {
+			return slugs
+		}
+	}
+	return append(slugs, slug)
+}
+
+// Creates the schema for querying characters, their stats, and the rankings.
+func (c *GraphQLController) newSchema() (graphql.Schema, error) {
+	publisherType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Publisher",
+		Fields: graphql.Fields{
+			"name": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"slug": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+	sizesType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ThumbnailSizes",
+		Fields: graphql.Fields{
+			"small":  &graphql.Field{Type: graphql.String},
+			"medium": &graphql.Field{Type: graphql.String},
+			"large":  &graphql.Field{Type: graphql.String},
+		},
+	})
+	thumbnailsType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "CharacterThumbnails",
+		Fields: graphql.Fields{
+			"image":       &graphql.Field{Type: sizesType},
+			"vendorImage": &graphql.Field{Type: sizesType},
+		},
+	})
+	statsType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "CharacterStats",
+		Fields: graphql.Fields{
+			"category":       &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"issueCountRank": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"issueCount":     &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"average":        &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+			"averageRank":    &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"issueCountPercentile": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Float),
+				Description: "The percent of the other ranked characters with fewer issues, so 99 is the top 1%.",
+			},
+			"averagePercentile": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Float),
+				Description: "The percent of the other ranked characters with a lower average issues per year.",
+			},
+			"normalizedPopularity": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Float),
+				Description: "The standard deviations of the character's logged issue count from the mean of the ranked characters.",
+			},
+			"relevance":     &graphql.Field{Type: graphql.Float, Description: "Only set when ranked by relevance."},
+			"relevanceRank": &graphql.Field{Type: graphql.Int, Description: "Only set when ranked by relevance."},
+		},
+	})
+	trendType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "RankTrend",
+		Fields: graphql.Fields{
+			"previousRank": &graphql.Field{Type: graphql.Int},
+			"change":       &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"direction":    &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+	aggregateType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "YearlyAggregate",
+		Fields: graphql.Fields{
+			"year":      &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"main":      &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"alternate": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+	appearancesType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "AppearancesByYears",
+		Fields: graphql.Fields{
+			"slug":       &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"aggregates": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(aggregateType)))},
+		},
+	})
+	characterType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Character",
+		Fields: graphql.Fields{
+			"slug":              &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"name":              &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"otherName":         &graphql.Field{Type: graphql.String},
+			"description":       &graphql.Field{Type: graphql.String},
+			"image":             &graphql.Field{Type: graphql.String},
+			"vendorImage":       &graphql.Field{Type: graphql.String},
+			"vendorUrl":         &graphql.Field{Type: graphql.String},
+			"vendorDescription": &graphql.Field{Type: graphql.String},
+			"publisher":         &graphql.Field{Type: graphql.NewNonNull(publisherType)},
+			"stats": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(statsType))),
+				Description: "The character's stats. Ranked lists only have the stats for the list's category.",
+			},
+			"trend": &graphql.Field{
+				Type:        trendType,
+				Description: "How the character's rank moved since the previous window. Only trending characters have it.",
+			},
+			"thumbnails": &graphql.Field{
+				Type: thumbnailsType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					ch := p.Source.(*graphCharacter)
+					if !ch.lazyThumbnails {
+						return ch.Thumbnails, nil
+					}
+					return loadersFrom(p.Context).thumbnails.load(ch.Slug)
+				},
+			},
+			"appearances": &graphql.Field{
+				Type:        graphql.NewNonNull(appearancesType),
+				Description: "The character's appearances per year.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					ch := p.Source.(*graphCharacter)
+					if !ch.lazyAppearances {
+						return ch.Appearances, nil
+					}
+					return loadersFrom(p.Context).appearances.load(ch.Slug)
+				},
+			},
+		},
+	})
+	sortEnum := graphql.NewEnum(graphql.EnumConfig{
+		Name: "RankingSort",
+		Values: graphql.EnumValueConfigMap{
+			"ISSUES":    {Value: "issues", Description: "Rank by the number of issues."},
+			"AVERAGE":   {Value: "average", Description: "Rank by the average issues per year."},
+			"RELEVANCE": {Value: "relevance", Description: "Rank by the number of issues weighted by how recent they are."},
+		},
+	})
+	categoryEnum := graphql.NewEnum(graphql.EnumConfig{
+		Name: "Category",
+		Values: graphql.EnumValueConfigMap{
+			"ALL":       {Value: "all"},
+			"MAIN":      {Value: "main"},
+			"ALTERNATE": {Value: "alternate"},
+			"WEIGHTED":  {Value: "weighted", Description: "All appearances weighted by the character's importance in the issues."},
+		},
+	})
+	pageArg := &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 1}
+	characters := graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(characterType)))
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"character": &graphql.Field{
+				Type:        characterType,
+				Description: "Gets a character with their stats and appearances per year.",
+				Args: graphql.FieldConfigArgument{
+					"slug": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: c.character,
+			},
+			"rankings": &graphql.Field{
+				Type:        characters,
+				Description: "Lists the most popular characters for all publishers or just the publisher.",
+				Args: graphql.FieldConfigArgument{
+					"publisher": &graphql.ArgumentConfig{Type: graphql.String},
+					"sort":      &graphql.ArgumentConfig{Type: sortEnum, DefaultValue: rankingSorts[0]},
+					"category":  &graphql.ArgumentConfig{Type: categoryEnum, DefaultValue: rankingCategories[0]},
+					"halfLife": &graphql.ArgumentConfig{
+						Type:         graphql.Int,
+						DefaultValue: comic.DefaultHalfLife,
+						Description:  "The number of years it takes an issue to count half as much when ranked by relevance.",
+					},
+					"page": pageArg,
+				},
+				Resolve: c.rankings,
+			},
+			"trending": &graphql.Field{
+				Type:        characters,
+				Description: "Lists the publisher's trending characters over the last 3, 6 or 12 months.",
+				Args: graphql.FieldConfigArgument{
+					"publisher": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"months":    &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: int(comic.TwelveMonths)},
+					"page":      pageArg,
+				},
+				Resolve: c.trending,
+			},
+			"search": &graphql.Field{
+				Type:        characters,
+				Description: "Searches characters by name.",
+				Args: graphql.FieldConfigArgument{
+					"query": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: c.search,
+			},
+		},
+	})
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
+
+func (c *GraphQLController) character(p graphql.ResolveParams) (interface{}, error) {
+	ch, err := c.expandedSvc.Character(comic.CharacterSlug(p.Args["slug"].(string)))
+	if err != nil || ch == nil {
+		return nil, err
+	}
+	return newGraphExpandedCharacter(ch), nil
+}
+
+func (c *GraphQLController) rankings(p graphql.ResolveParams) (interface{}, error) {
+	page, err := graphPage(p)
+	if err != nil {
+		return nil, err
+	}
+	cr := newPopularCriteria(p.Args["sort"].(string), p.Args["category"].(string), page)
+	cr.Limit = pageLimit
+	if cr.SortBy == comic.MostRelevant {
+		cr.HalfLife = p.Args["halfLife"].(int)
+		if cr.HalfLife < 1 || cr.HalfLife > maxHalfLife {
+			return nil, fmt.Errorf("the half-life must be from 1 to %d years", maxHalfLife)
+		}
+	}
+	var results []*comic.RankedCharacter
+	if slug, ok := p.Args["publisher"].(string); ok {
+		var pub *comic.Publisher
+		pub, err = c.graphPublisher(slug)
+		if err != nil {
+			return nil, err
+		}
+		results, err = c.rankedSvc.PublisherPopular(pub.Slug, cr)
+	} else {
+		results, err = c.rankedSvc.AllPopular(cr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return graphRanked(p.Context, results), nil
+}
+
+func (c *GraphQLController) trending(p graphql.ResolveParams) (interface{}, error) {
+	page, err := graphPage(p)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := c.graphPublisher(p.Args["publisher"].(string))
+	if err != nil {
+		return nil, err
+	}
+	cr := comic.TrendingCriteria{Window: comic.TrendingWindow(p.Args["months"].(int)), Limit: pageLimit, Offset: (page - 1) * pageLimit}
+	if !validTrendingWindow(cr.Window) {
+		return nil, errors.New("the months must be 3, 6 or 12")
+	}
+	results, err := c.rankedSvc.Trending(pub.Slug, cr)
+	if err != nil {
+		return nil, err
+	}
+	return graphRanked(p.Context, results), nil
+}
+
+func (c *GraphQLController) search(p graphql.ResolveParams) (interface{}, error) {
+	results, err := c.searcher.Characters(p.Args["query"].(string), graphSearchLimit, 0)
+	if err != nil {
+		return nil, err
+	}
+	chs := make([]*graphCharacter, len(results))
+	for i, r := range results {
+		chs[i] = newGraphCharacter(p.Context, r)
+	}
+	return chs, nil
+}
+
+// Gets the publisher or returns an error if it doesn't exist.
+func (c *GraphQLController) graphPublisher(slug string) (*comic.Publisher, error) {
+	pub, err := c.publisherSvc.Publisher(comic.PublisherSlug(slug))
+	if err != nil {
+		return nil, err
+	}
+	if pub == nil {
+		return nil, errors.New("the publisher could not be found")
+	}
+	return pub, nil
+}
+
+func graphPage(p graphql.ResolveParams) (int, error) {
+	page := p.Args["page"].(int)
+	if page < 1 {
+		return 0, errors.New("the page must be at least 1")
+	}
+	return page, nil
+}
+
+func validTrendingWindow(w comic.TrendingWindow) bool {
+	for _, tw := range comic.TrendingWindows {
+		if w == tw {
+			return true
+		}
+	}
+	return false
+}
+
+func graphRanked(ctx context.Context, results []*comic.RankedCharacter) []*graphCharacter {
+	chs := make([]*graphCharacter, len(results))
+	for i, r := range results {
+		chs[i] = newGraphRankedCharacter(ctx, r)
+	}
+	return chs
+}
+
+// NewGraphQLController creates a new GraphQL controller. Panics if the schema is invalid.
+func NewGraphQLController(
+	rankedSvc comic.RankedServicer,
+	expandedSvc comic.ExpandedServicer,
+	publisherSvc comic.PublisherServicer,
+	searcher search.Searcher,
+	ctr comic.CharacterThumbRepository,
+	amr comic.AppearancesByYearsMapRepository) *GraphQLController {
+	c := &GraphQLController{
+		rankedSvc:    rankedSvc,
+		expandedSvc:  expandedSvc,
+		publisherSvc: publisherSvc,
+		searcher:     searcher,
+		ctr:          ctr,
+		amr:          amr,
+	}
+	schema, err := c.newSchema()
+	if err != nil {
+		panic(err)
+	}
+	c.schema = schema
+	return c
+}
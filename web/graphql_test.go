@@ -0,0 +1,227 @@
+package web_test
+
+import (
+	"errors"
+	"github.com/comiccruncher/comiccruncher/comic"
+	"github.com/comiccruncher/comiccruncher/internal/mocks/comic"
+	"github.com/comiccruncher/comiccruncher/internal/mocks/search"
+	"github.com/comiccruncher/comiccruncher/web"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+type graphQLMocks struct {
+	rs   *mock_comic.MockRankedServicer
+	es   *mock_comic.MockExpandedServicer
+	ps   *mock_comic.MockPublisherServicer
+	srch *mock_search.MockSearcher
+	ctr  *mock_comic.MockCharacterThumbRepository
+	amr  *mock_comic.MockAppearancesByYearsMapRepository
+}
+
+func newGraphQLMocks(ctrl *gomock.Controller) graphQLMocks {
+	return graphQLMocks{
+		rs:   mock_comic.NewMockRankedServicer(ctrl),
+		es:   mock_comic.NewMockExpandedServicer(ctrl),
+		ps:   mock_comic.NewMockPublisherServicer(ctrl),
+		srch: mock_search.NewMockSearcher(ctrl),
+		ctr:  mock_comic.NewMockCharacterThumbRepository(ctrl),
+		amr:  mock_comic.NewMockAppearancesByYearsMapRepository(ctrl),
+	}
+}
+
+func (m graphQLMocks) query(t *testing.T, body string) *httptest.ResponseRecorder {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	err := web.NewGraphQLController(m.rs, m.es, m.ps, m.srch, m.ctr, m.amr).GraphQL(c)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	return rec
+}
+
+func TestGraphQLControllerRankingsBatchesAppearances(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := newGraphQLMocks(ctrl)
+	m.rs.EXPECT().AllPopular(comic.PopularCriteria{
+		SortBy:         comic.AverageIssuesPerYear,
+		AppearanceType: comic.Main,
+		Limit:          24,
+		Offset:         24,
+	}).Return([]*comic.RankedCharacter{
+		{Slug: "emma-frost", Name: "Emma Frost", Thumbnails: &comic.CharacterThumbnails{Image: &comic.ThumbnailSizes{Small: "small.jpg"}}, Stats: comic.CharacterStats{IssueCountRank: 1}},
+		{Slug: "jean-grey", Name: "Jean Grey", Stats: comic.CharacterStats{IssueCountRank: 2}},
+	}, nil)
+	m.amr.EXPECT().ListMap(comic.CharacterSlug("emma-frost"), comic.CharacterSlug("jean-grey")).Times(1).Return(map[comic.CharacterSlug]comic.AppearancesByYears{
+		"emma-frost": {CharacterSlug: "emma-frost", Aggregates: []comic.YearlyAggregate{{Year: 1979, Main: 10}}},
+	}, nil)
+
+	rec := m.query(t, `{"query": "{ rankings(sort: AVERAGE, category: MAIN, page: 2) { slug stats { issueCountRank } thumbnails { image { small } } appearances { aggregates { year main } } } }"}`)
+	body := rec.Body.String()
+	assert.NotContains(t, body, `"errors"`)
+	assert.Contains(t, body, `"slug":"emma-frost"`)
+	assert.Contains(t, body, `"issueCountRank":2`)
+	assert.Contains(t, body, `small.jpg"`)
+	assert.Contains(t, body, `{"main":10,"year":1979}`)
+	assert.Contains(t, body, `"aggregates":[]`)
+}
+
+func TestGraphQLControllerRankingsRelevance(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := newGraphQLMocks(ctrl)
+	m.rs.EXPECT().AllPopular(comic.PopularCriteria{
+		SortBy:         comic.MostRelevant,
+		AppearanceType: comic.Main | comic.Alternate,
+		HalfLife:       comic.DefaultHalfLife,
+		Limit:          24,
+	}).Return([]*comic.RankedCharacter{{Slug: "emma-frost", Stats: comic.CharacterStats{Relevance: 12.5, RelevanceRank: 1}}}, nil)
+	m.amr.EXPECT().ListMap(gomock.Any()).AnyTimes().Return(map[comic.CharacterSlug]comic.AppearancesByYears{}, nil)
+
+	rec := m.query(t, `{"query": "{ rankings(sort: RELEVANCE) { slug stats { relevance relevanceRank } } }"}`)
+	body := rec.Body.String()
+	assert.NotContains(t, body, `"errors"`)
+	assert.Contains(t, body, `"stats":[{"relevance":12.5,"relevanceRank":1}]`)
+}
+
+func TestGraphQLControllerRankingsInvalidHalfLife(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := newGraphQLMocks(ctrl)
+	m.rs.EXPECT().AllPopular(gomock.Any()).Times(0)
+
+	rec := m.query(t, `{"query": "{ rankings(sort: RELEVANCE, halfLife: 0) { slug } }"}`)
+	assert.Contains(t, rec.Body.String(), "the half-life must be from 1 to 100 years")
+}
+
+func TestGraphQLControllerRankingsPublisherNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := newGraphQLMocks(ctrl)
+	m.ps.EXPECT().Publisher(comic.PublisherSlug("image")).Return(nil, nil)
+
+	rec := m.query(t, `{"query": "{ rankings(publisher: \"image\") { slug } }"}`)
+	assert.Contains(t, rec.Body.String(), "the publisher could not be found")
+}
+
+func TestGraphQLControllerRankingsPublisherError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := newGraphQLMocks(ctrl)
+	m.ps.EXPECT().Publisher(comic.PublisherSlug("marvel")).Return(&comic.Publisher{Slug: "marvel"}, nil)
+	m.rs.EXPECT().PublisherPopular(comic.PublisherSlug("marvel"), gomock.Any()).Return(nil, errors.New("the view is missing"))
+
+	rec := m.query(t, `{"query": "{ rankings(publisher: \"marvel\") { slug } }"}`)
+	assert.Contains(t, rec.Body.String(), "the view is missing")
+}
+
+func TestGraphQLControllerTrending(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := newGraphQLMocks(ctrl)
+	m.ps.EXPECT().Publisher(comic.PublisherSlug("marvel")).Return(&comic.Publisher{Slug: "marvel"}, nil)
+	m.rs.EXPECT().Trending(comic.PublisherSlug("marvel"), comic.TrendingCriteria{Window: comic.TwelveMonths, Limit: 24}).Return([]*comic.RankedCharacter{{Slug: "emma-frost"}}, nil)
+
+	rec := m.query(t, `{"query": "query($p: String!) { trending(publisher: $p) { slug } }", "variables": {"p": "marvel"}}`)
+	assert.Contains(t, rec.Body.String(), `"trending":[{"slug":"emma-frost"}]`)
+}
+
+func TestGraphQLControllerTrendingWindow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := newGraphQLMocks(ctrl)
+	m.ps.EXPECT().Publisher(comic.PublisherSlug("marvel")).Return(&comic.Publisher{Slug: "marvel"}, nil)
+	previous := uint(9)
+	m.rs.EXPECT().Trending(comic.PublisherSlug("marvel"), comic.TrendingCriteria{Window: comic.ThreeMonths, Limit: 24}).Return([]*comic.RankedCharacter{
+		{Slug: "emma-frost", Trend: comic.NewRankTrend(1, &previous)},
+		{Slug: "jean-grey", Trend: comic.NewRankTrend(2, nil)},
+	}, nil)
+
+	rec := m.query(t, `{"query": "{ trending(publisher: \"marvel\", months: 3) { slug trend { previousRank change direction } } }"}`)
+	body := rec.Body.String()
+	assert.NotContains(t, body, `"errors"`)
+	assert.Contains(t, body, `"trend":{"change":8,"direction":"up","previousRank":9}`)
+	assert.Contains(t, body, `"trend":{"change":0,"direction":"new","previousRank":null}`)
+}
+
+func TestGraphQLControllerTrendingInvalidWindow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := newGraphQLMocks(ctrl)
+	m.ps.EXPECT().Publisher(comic.PublisherSlug("marvel")).Return(&comic.Publisher{Slug: "marvel"}, nil)
+	m.rs.EXPECT().Trending(gomock.Any(), gomock.Any()).Times(0)
+
+	rec := m.query(t, `{"query": "{ trending(publisher: \"marvel\", months: 2) { slug } }"}`)
+	assert.Contains(t, rec.Body.String(), "the months must be 3, 6 or 12")
+}
+
+func TestGraphQLControllerSearchBatchesThumbnails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := newGraphQLMocks(ctrl)
+	m.srch.EXPECT().Characters("emma", 5, 0).Return([]*comic.Character{
+		{Slug: "emma-frost", Name: "Emma Frost"},
+		{Slug: "emma-frost-2", Name: "Emma Frost"},
+	}, nil)
+	m.ctr.EXPECT().AllThumbnails(comic.CharacterSlug("emma-frost"), comic.CharacterSlug("emma-frost-2")).Times(1).Return(map[comic.CharacterSlug]*comic.CharacterThumbnails{
+		"emma-frost": {Slug: "emma-frost", Image: &comic.ThumbnailSizes{Small: "small.jpg"}},
+	}, nil)
+
+	rec := m.query(t, `{"query": "{ search(query: \"emma\") { slug thumbnails { image { small } } } }"}`)
+	body := rec.Body.String()
+	assert.NotContains(t, body, `"errors"`)
+	assert.Contains(t, body, `small.jpg"`)
+	assert.Contains(t, body, `{"slug":"emma-frost-2","thumbnails":null}`)
+}
+
+func TestGraphQLControllerCharacter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := newGraphQLMocks(ctrl)
+	m.es.EXPECT().Character(comic.CharacterSlug("emma-frost")).Return(&comic.ExpandedCharacter{
+		Character:   mockCharacter(),
+		Stats:       []comic.CharacterStats{{Category: comic.AllTimeStats, IssueCount: 100, IssueCountPercentile: 99.5, NormalizedPopularity: 2.31}},
+		Appearances: comic.AppearancesByYears{CharacterSlug: "emma-frost", Aggregates: []comic.YearlyAggregate{{Year: 1979, Alternate: 1}}},
+	}, nil)
+
+	rec := m.query(t, `{"query": "{ character(slug: \"emma-frost\") { name publisher { slug } stats { category issueCount issueCountPercentile normalizedPopularity } appearances { aggregates { alternate } } } }"}`)
+	body := rec.Body.String()
+	assert.NotContains(t, body, `"errors"`)
+	assert.Contains(t, body, `"publisher":{"slug":"marvel"}`)
+	assert.Contains(t, body, `"issueCount":100`)
+	assert.Contains(t, body, `"issueCountPercentile":99.5`)
+	assert.Contains(t, body, `"normalizedPopularity":2.31`)
+	assert.Contains(t, body, `"aggregates":[{"alternate":1}]`)
+}
+
+func TestGraphQLControllerGetWithoutQuery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := newGraphQLMocks(ctrl)
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/graphql?query="+url.QueryEscape(""), nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	err := web.NewGraphQLController(m.rs, m.es, m.ps, m.srch, m.ctr, m.amr).GraphQL(c)
+	assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+}
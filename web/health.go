@@ -0,0 +1,142 @@
+package web
+
+import (
+	"context"
+	"github.com/comiccruncher/comiccruncher/comic"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"time"
+)
+
+// How long Postgres and Redis get to answer the readiness pings before they count as down.
+const pingTimeout = 2 * time.Second
+
+// DependencyStatus is whether a dependency the API needs can be reached.
+type DependencyStatus struct {
+	OK bool `json:"ok"`
+	// Latency is how long the ping took in milliseconds.
+	Latency int64 `json:"latency_ms"`
+}
+
+// ViewsStatus is how old the ranking materialized views are.
+type ViewsStatus struct {
+	RefreshedAt *time.Time `json:"refreshed_at"`
+	// Age is the number of seconds since the views were refreshed.
+	Age *int64 `json:"age_seconds"`
+}
+
+// SyncStatus is the latest successful character sync.
+type SyncStatus struct {
+	CharacterID comic.CharacterID `json:"character_id"`
+	SyncedAt    time.Time         `json:"synced_at"`
+	// Age is the number of seconds since the sync.
+	Age int64 `json:"age_seconds"`
+}
+
+// Readiness is the view for whether the API can serve requests.
+type Readiness struct {
+	Ready    bool             `json:"ready"`
+	Postgres DependencyStatus `json:"postgres"`
+	Redis    DependencyStatus `json:"redis"`
+	Views    ViewsStatus      `json:"views"`
+	LastSync *SyncStatus      `json:"last_successful_sync"`
+}
+
+// HealthController is the controller for the liveness and readiness checks.
+type HealthController struct {
+	db           comic.ORM
+	redis        comic.RedisClient
+	rankedSvc    comic.RankedServicer
+	characterSvc comic.CharacterServicer
+	now          func() time.Time
+}
+
+// Healthz responds that the process is up without touching any dependencies.
+func (c HealthController) Healthz(ctx echo.Context) error {
+	return JSONDetailViewOK(ctx, map[string]string{"status": "ok"})
+}
+
+// Readyz pings Postgres and Redis and reports how fresh the rankings and syncs are.
+// Responds with a 503 if Postgres or Redis can't be reached.
+func (c HealthController) Readyz(ctx echo.Context) error {
+	r := &Readiness{
+		Postgres: c.ping(ctx, func() error {
+			_, err := c.db.Exec("SELECT 1")
+			return err
+		}),
+		Redis: c.ping(ctx, func() error {
+			return c.redis.Ping().Err()
+		}),
+	}
+	r.Ready = r.Postgres.OK && r.Redis.OK
+	if r.Postgres.OK {
+		c.freshness(ctx, r)
+	}
+	if !r.Ready {
+		return JSONDetailView(ctx, r, http.StatusServiceUnavailable)
+	}
+	return JSONDetailViewOK(ctx, r)
+}
+
+// Times the ping and gives up on it after the ping timeout. The error is logged instead of shown since the
+// endpoint is public.
+func (c HealthController) ping(ctx echo.Context, ping func() error) DependencyStatus {
+	start := c.now()
+	err := withTimeout(ctx.Request().Context(), pingTimeout, ping)
+	status := DependencyStatus{OK: err == nil, Latency: c.now().Sub(start).Nanoseconds() / int64(time.Millisecond)}
+	if err != nil {
+		logContext(err, ctx)
+	}
+	return status
+}
+
+// Waits for `f` until it returns or the timeout passes. Postgres and Redis don't take a context here, so `f` keeps
+// running in the background until its connection gives up, but the response doesn't wait for it.
+func withTimeout(parent context.Context, timeout time.Duration, f func() error) error {
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- f()
+	}()
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Sets how old the views and the last successful sync are. Errors are only logged since stale data doesn't stop
+// the API from serving requests.
+func (c HealthController) freshness(ctx echo.Context, r *Readiness) {
+	now := c.now()
+	refreshed, err := c.rankedSvc.LastRefreshed()
+	if err != nil {
+		logContext(err, ctx)
+	} else if !refreshed.IsZero() {
+		age := int64(now.Sub(refreshed) / time.Second)
+		r.Views = ViewsStatus{RefreshedAt: &refreshed, Age: &age}
+	}
+	syncLog, err := c.characterSvc.LastSuccessfulSync()
+	if err != nil {
+		logContext(err, ctx)
+	} else if syncLog != nil && syncLog.SyncedAt != nil {
+		r.LastSync = &SyncStatus{
+			CharacterID: syncLog.CharacterID,
+			SyncedAt:    *syncLog.SyncedAt,
+			Age:         int64(now.Sub(*syncLog.SyncedAt) / time.Second),
+		}
+	}
+}
+
+// NewHealthController creates a new health controller that checks the database and Redis.
+func NewHealthController(db comic.ORM, redis comic.RedisClient, rankedSvc comic.RankedServicer, characterSvc comic.CharacterServicer) *HealthController {
+	return &HealthController{
+		db:           db,
+		redis:        redis,
+		rankedSvc:    rankedSvc,
+		characterSvc: characterSvc,
+		now:          time.Now,
+	}
+}
@@ -0,0 +1,135 @@
+package web_test
+
+import (
+	"context"
+	"errors"
+	"github.com/comiccruncher/comiccruncher/comic"
+	"github.com/comiccruncher/comiccruncher/internal/mocks/comic"
+	"github.com/comiccruncher/comiccruncher/web"
+	"github.com/go-pg/pg/orm"
+	"github.com/go-redis/redis"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHealthControllerHealthz(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	h := web.NewHealthController(mock_comic.NewMockORM(ctrl), mock_comic.NewMockRedisClient(ctrl), mock_comic.NewMockRankedServicer(ctrl), mock_comic.NewMockCharacterServicer(ctrl))
+	assert.Nil(t, h.Healthz(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"status": "ok"`)
+}
+
+func TestHealthControllerReadyz(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	db := mock_comic.NewMockORM(ctrl)
+	db.EXPECT().Exec("SELECT 1").Return(nil, nil)
+	r := mock_comic.NewMockRedisClient(ctrl)
+	r.EXPECT().Ping().Return(redis.NewStatusResult("PONG", nil))
+	rs := mock_comic.NewMockRankedServicer(ctrl)
+	rs.EXPECT().LastRefreshed().Return(time.Now().Add(-time.Hour), nil)
+	syncedAt := time.Now().Add(-2 * time.Hour)
+	cs := mock_comic.NewMockCharacterServicer(ctrl)
+	cs.EXPECT().LastSuccessfulSync().Return(&comic.CharacterSyncLog{CharacterID: 7, SyncStatus: comic.Success, SyncedAt: &syncedAt}, nil)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	assert.Nil(t, web.NewHealthController(db, r, rs, cs).Readyz(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, `"ready": true`)
+	assert.Contains(t, body, `"age_seconds": 3600`)
+	assert.Contains(t, body, `"character_id": 7`)
+	assert.Contains(t, body, `"age_seconds": 7200`)
+}
+
+func TestHealthControllerReadyzRedisDown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	db := mock_comic.NewMockORM(ctrl)
+	db.EXPECT().Exec("SELECT 1").Return(nil, nil)
+	r := mock_comic.NewMockRedisClient(ctrl)
+	r.EXPECT().Ping().Return(redis.NewStatusResult("", errors.New("connection refused")))
+	rs := mock_comic.NewMockRankedServicer(ctrl)
+	rs.EXPECT().LastRefreshed().Return(time.Time{}, nil)
+	cs := mock_comic.NewMockCharacterServicer(ctrl)
+	cs.EXPECT().LastSuccessfulSync().Return(nil, nil)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	assert.Nil(t, web.NewHealthController(db, r, rs, cs).Readyz(c))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, `"ready": false`)
+	assert.Contains(t, body, `"last_successful_sync": null`)
+	assert.NotContains(t, body, "connection refused")
+}
+
+func TestHealthControllerReadyzPostgresDown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	db := mock_comic.NewMockORM(ctrl)
+	db.EXPECT().Exec("SELECT 1").Return(nil, errors.New("connection refused"))
+	r := mock_comic.NewMockRedisClient(ctrl)
+	r.EXPECT().Ping().Return(redis.NewStatusResult("PONG", nil))
+	rs := mock_comic.NewMockRankedServicer(ctrl)
+	rs.EXPECT().LastRefreshed().Times(0)
+	cs := mock_comic.NewMockCharacterServicer(ctrl)
+	cs.EXPECT().LastSuccessfulSync().Times(0)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	assert.Nil(t, web.NewHealthController(db, r, rs, cs).Readyz(c))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestHealthControllerReadyzPostgresTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	db := mock_comic.NewMockORM(ctrl)
+	// the pings might not get called before the request's deadline passes.
+	db.EXPECT().Exec("SELECT 1").MaxTimes(1).DoAndReturn(func(query interface{}, params ...interface{}) (orm.Result, error) {
+		time.Sleep(time.Second)
+		return nil, nil
+	})
+	r := mock_comic.NewMockRedisClient(ctrl)
+	r.EXPECT().Ping().MaxTimes(1).Return(redis.NewStatusResult("PONG", nil))
+	rs := mock_comic.NewMockRankedServicer(ctrl)
+	rs.EXPECT().LastRefreshed().Times(0)
+	cs := mock_comic.NewMockCharacterServicer(ctrl)
+	cs.EXPECT().LastSuccessfulSync().Times(0)
+
+	e := echo.New()
+	reqCtx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil).WithContext(reqCtx)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	start := time.Now()
+	assert.Nil(t, web.NewHealthController(db, r, rs, cs).Readyz(c))
+	assert.True(t, time.Since(start) < time.Second)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"ready": false`)
+}
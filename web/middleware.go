@@ -3,11 +3,13 @@ package web
 import (
 	"errors"
 	"github.com/comiccruncher/comiccruncher/internal/log"
+	"github.com/comiccruncher/comiccruncher/internal/metrics"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -23,9 +25,13 @@ type JWTConfig struct {
 }
 
 // JWTMiddlewareWithConfig creates a new middleware func from the specified configuration.
+// Every token gets rejected if the signing key is empty since anyone could sign a token with an empty key.
 func JWTMiddlewareWithConfig(config JWTConfig) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
+			if config.SecretSigningKey == "" {
+				return echo.ErrUnauthorized
+			}
 			_, err := validateToken(c.Request(), config.SecretSigningKey)
 			if err != nil {
 				return err
@@ -59,6 +65,57 @@ func ReferrerPolicyMiddleware(policy string) echo.MiddlewareFunc {
 	}
 }
 
+// CacheControlMiddleware sets the `Cache-Control` header for the specified policy.
+func CacheControlMiddleware(policy string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Response().Header().Set("Cache-Control", policy)
+			return next(c)
+		}
+	}
+}
+
+// MetricsMiddleware records the request count and latency for the route and status code. The status is taken
+// from the error since the error handler hasn't written the response yet.
+func MetricsMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+			status := c.Response().Status
+			if err != nil {
+				status = http.StatusInternalServerError
+				if he, ok := err.(*echo.HTTPError); ok {
+					status = he.Code
+				}
+			}
+			route := c.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+			labels := []string{c.Request().Method, route, strconv.Itoa(status)}
+			metrics.HTTPRequests.WithLabelValues(labels...).Inc()
+			metrics.HTTPDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+			return err
+		}
+	}
+}
+
+// LastModifiedMiddleware sets the `Last-Modified` header from the time returned by `lastModified`, like
+// when the rankings were last refreshed.
+func LastModifiedMiddleware(lastModified func() (time.Time, error)) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			t, err := lastModified()
+			if err != nil {
+				return err
+			}
+			setLastModified(c, t)
+			return next(c)
+		}
+	}
+}
+
 // ErrorHandler logs errors to the logger if there are any and sends the appropriate response back.
 func ErrorHandler(err error, ctx echo.Context) {
 	if err == nil {
@@ -70,9 +127,13 @@ func ErrorHandler(err error, ctx echo.Context) {
 		NewJSONErrorView(ctx, ErrInternalServerError.Error(), http.StatusInternalServerError)
 		return
 	}
-	if echoErr.Code != http.StatusNotFound && echoErr.Code != http.StatusBadRequest {
+	if echoErr.Code != http.StatusNotFound && echoErr.Code != http.StatusBadRequest && echoErr.Code != http.StatusTooManyRequests {
 		logContext(err, ctx)
 	}
+	if pe, ok := echoErr.Internal.(*ParamError); ok {
+		NewJSONParamErrorView(ctx, echoErr.Message.(string), echoErr.Code, pe)
+		return
+	}
 	NewJSONErrorView(ctx, echoErr.Message.(string), echoErr.Code)
 }
 
@@ -101,7 +162,7 @@ func logContext(err error, ctx echo.Context) {
 		zap.String("RealIP", ctx.RealIP()),
 		zap.String("User Agent", req.UserAgent()),
 		zap.String("Referer", req.Referer()),
-		zap.String("X-VISITOR-ID", req.Header.Get("X-VISITOR-ID")),
+		zap.String("X-VISITOR-ID", req.Header.Get(HeaderVisitorID)),
 	)
 }
 
@@ -0,0 +1,821 @@
+package web
+
+import (
+	"github.com/comiccruncher/comiccruncher/comic"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// OpenAPI is an OpenAPI 3 document describing the API.
+type OpenAPI struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       OpenAPIInfo          `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components OpenAPIComponents    `json:"components"`
+}
+
+// OpenAPIInfo is the metadata about the API.
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// OpenAPIComponents are the reusable schemas referenced by the operations.
+type OpenAPIComponents struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme describes how operations get authenticated.
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// PathItem describes the operations available on a path.
+type PathItem struct {
+	Get   *Operation `json:"get,omitempty"`
+	Post  *Operation `json:"post,omitempty"`
+	Patch *Operation `json:"patch,omitempty"`
+}
+
+// Operation describes a single API operation on a path.
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// RequestBody describes the body an operation accepts.
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Parameter describes a single operation parameter.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// Response describes a single response from an operation.
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType is the schema for a response's content type.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema describes a data type.
+type Schema struct {
+	Ref        string             `json:"$ref,omitempty"`
+	Type       string             `json:"type,omitempty"`
+	Format     string             `json:"format,omitempty"`
+	Enum       []string           `json:"enum,omitempty"`
+	Default    interface{}        `json:"default,omitempty"`
+	Nullable   bool               `json:"nullable,omitempty"`
+	Minimum    *int               `json:"minimum,omitempty"`
+	Maximum    *int               `json:"maximum,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+}
+
+// OpenAPIController is the controller for the API's OpenAPI document.
+type OpenAPIController struct {
+	doc *OpenAPI
+}
+
+// OpenAPI shows the OpenAPI document.
+func (c OpenAPIController) OpenAPI(ctx echo.Context) error {
+	return ctx.JSONPretty(http.StatusOK, c.doc, "  ")
+}
+
+// NewOpenAPIController creates a new controller for the OpenAPI document.
+func NewOpenAPIController() *OpenAPIController {
+	return &OpenAPIController{doc: NewOpenAPI()}
+}
+
+// NewOpenAPI creates the OpenAPI document for all the routes registered in `App.Run`.
+func NewOpenAPI() *OpenAPI {
+	return &OpenAPI{
+		OpenAPI: "3.0.2",
+		Info: OpenAPIInfo{
+			Title:   "Comic Cruncher API",
+			Version: "1.0.0",
+		},
+		Paths: map[string]*PathItem{
+			"/openapi.json": get(&Operation{
+				OperationID: "openapi",
+				Summary:     "This OpenAPI document.",
+				Responses: map[string]*Response{
+					"200": {Description: "The OpenAPI document.", Content: jsonContent(&Schema{Type: "object"})},
+				},
+			}),
+			"/healthz": get(&Operation{
+				OperationID: "healthz",
+				Summary:     "Liveness check that doesn't touch any dependencies.",
+				Tags:        []string{"health"},
+				Responses: map[string]*Response{
+					"200": {Description: "The API is up.", Content: jsonContent(detailOf(&Schema{Type: "object"}))},
+				},
+			}),
+			"/readyz": get(&Operation{
+				OperationID: "readyz",
+				Summary:     "Readiness check that pings Postgres and Redis and reports how fresh the rankings are.",
+				Tags:        []string{"health"},
+				Responses: map[string]*Response{
+					"200": {Description: "The API can serve requests.", Content: jsonContent(detailOf(ref("Readiness")))},
+					"503": {Description: "Postgres or Redis can't be reached.", Content: jsonContent(detailOf(ref("Readiness")))},
+				},
+			}),
+			"/stats": get(&Operation{
+				OperationID: "stats",
+				Summary:     "General stats about all the characters and issues.",
+				Tags:        []string{"stats"},
+				Responses:   responses(detailOf(ref("Stats"))),
+			}),
+			"/compare": get(&Operation{
+				OperationID: "compareCharacters",
+				Summary:     "Compares characters' stats and appearances over the same span of years.",
+				Tags:        []string{"characters"},
+				Parameters: []*Parameter{
+					{
+						Name:        "slugs",
+						In:          "query",
+						Description: "Comma-separated character slugs. At least 2.",
+						Required:    true,
+						Schema:      &Schema{Type: "string"},
+					},
+				},
+				Responses: responses(detailOf(ref("Comparison")), http.StatusNotFound),
+			}),
+			"/co-appearances": get(&Operation{
+				OperationID: "getCoAppearanceGraph",
+				Summary:     "Exports the graph of the characters who appear together the most for visualizing.",
+				Tags:        []string{"characters"},
+				Parameters:  graphParams(),
+				Responses:   graphResponses(),
+			}),
+			"/search/characters": get(&Operation{
+				OperationID: "searchCharacters",
+				Summary:     "Searches characters by name.",
+				Tags:        []string{"search"},
+				Parameters: []*Parameter{
+					{Name: "query", In: "query", Description: "The name to search for.", Schema: &Schema{Type: "string"}},
+				},
+				Responses: responses(listOf(ref("Character"))),
+			}),
+			"/characters": get(&Operation{
+				OperationID: "listCharacters",
+				Summary:     "Lists the most popular characters for all publishers.",
+				Tags:        []string{"characters", "rankings"},
+				Parameters:  append(append(rankingParams(), yearsParams()...), formatParam()),
+				Responses:   exportResponses(),
+			}),
+			"/characters/{slug}": get(&Operation{
+				OperationID: "getCharacter",
+				Summary:     "Gets a character with their stats and appearances per year.",
+				Tags:        []string{"characters"},
+				Parameters:  []*Parameter{slugParam("The character's slug.")},
+				Responses:   responses(detailOf(ref("ExpandedCharacter")), http.StatusNotFound),
+			}),
+			"/characters/{slug}/issues": get(&Operation{
+				OperationID: "listCharacterIssues",
+				Summary:     "Lists the issues a character appears in.",
+				Tags:        []string{"characters", "issues"},
+				Parameters: []*Parameter{
+					slugParam("The character's slug."),
+					pageParam(),
+					enumParam("sort", "How to order the issues by their sale date.", issueSorts),
+					enumParam("category", "The type of appearances.", categories),
+					{Name: "year", In: "query", Description: "Only issues on sale in the year.", Schema: &Schema{Type: "integer"}},
+					{Name: "format", In: "query", Description: "Comma-separated issue formats: " + strings.Join(issueFormats, ", ") + ".", Schema: &Schema{Type: "string"}},
+					{Name: "variant", In: "query", Description: "Only variants or only non-variants.", Schema: &Schema{Type: "boolean"}},
+					{Name: "reprint", In: "query", Description: "Only reprints or only non-reprints.", Schema: &Schema{Type: "boolean"}},
+				},
+				Responses: responses(listOf(ref("CharacterIssue")), http.StatusNotFound),
+			}),
+			"/characters/{slug}/series": get(&Operation{
+				OperationID: "getCharacterSeries",
+				Summary:     "Gets the series a character appears in the most with their share of the character's issues.",
+				Tags:        []string{"characters"},
+				Parameters:  []*Parameter{slugParam("The character's slug.")},
+				Responses:   responses(detailOf(ref("CharacterSeries")), http.StatusNotFound),
+			}),
+			"/characters/{slug}/appearances/monthly": get(&Operation{
+				OperationID: "getCharacterMonthlyAppearances",
+				Summary:     "Gets a character's appearances per month for the last ten years. Month 0 is for issues with an uncertain month.",
+				Tags:        []string{"characters"},
+				Parameters:  []*Parameter{slugParam("The character's slug.")},
+				Responses:   responses(detailOf(ref("AppearancesByMonths")), http.StatusNotFound),
+			}),
+			"/characters/{slug}/rank-history": get(&Operation{
+				OperationID: "getCharacterRankHistory",
+				Summary:     "Gets a character's ranks from each time the rankings were refreshed. Older ranks are kept monthly.",
+				Tags:        []string{"characters", "rankings"},
+				Parameters: []*Parameter{
+					slugParam("The character's slug."),
+					enumParam("category", "The type of appearances. Main ranks are within the character's publisher and the others are within every publisher. Weighted counts all appearances by the character's importance in the issues.", rankingCategories),
+				},
+				Responses: responses(detailOf(ref("RankHistory")), http.StatusNotFound),
+			}),
+			"/characters/{slug}/co-appearances": get(&Operation{
+				OperationID: "listCharacterCoAppearances",
+				Summary:     "Lists the characters who appear in the same issues as the character.",
+				Tags:        []string{"characters"},
+				Parameters: []*Parameter{
+					slugParam("The character's slug."),
+					pageParam(),
+					enumParam("sort", "Sort by the most shared issues or the highest affinity.", coAppearanceSorts),
+				},
+				Responses: responses(listOf(ref("CoAppearance")), http.StatusNotFound),
+			}),
+			"/publishers/{slug}": get(&Operation{
+				OperationID: "listPublisherCharacters",
+				Summary:     "Lists the most popular characters for the publisher by their main appearances.",
+				Tags:        []string{"publishers", "rankings"},
+				Parameters:  append(rankingParams(), slugParam("The publisher's slug, like `marvel` or `dc`."), formatParam()),
+				Responses:   exportResponses(http.StatusNotFound),
+			}),
+			"/trending/{slug}": get(&Operation{
+				OperationID: "listTrendingCharacters",
+				Summary:     "Lists the publisher's trending characters over the last months with how their rank moved since the months before.",
+				Tags:        []string{"publishers", "rankings"},
+				Parameters: []*Parameter{
+					slugParam("The publisher's slug, like `marvel` or `dc`."),
+					enumParam("months", "The number of months to rank the appearances for.", trendingWindows),
+					pageParam(),
+					formatParam(),
+				},
+				Responses: exportResponses(http.StatusNotFound),
+			}),
+			"/graphql": {
+				Get:  graphQLOperation("graphqlGet", &Parameter{Name: "query", In: "query", Description: "The GraphQL query.", Required: true, Schema: &Schema{Type: "string"}}),
+				Post: graphQLOperation("graphqlPost"),
+			},
+			"/admin/characters/{slug}": {
+				Get: admin(&Operation{
+					OperationID: "adminGetCharacter",
+					Summary:     "Gets a character, even if it's disabled, with all its sources.",
+					Parameters:  []*Parameter{slugParam("The character's slug.")},
+					Responses:   responses(detailOf(ref("AdminCharacter")), http.StatusNotFound),
+				}),
+				Patch: admin(&Operation{
+					OperationID: "adminUpdateCharacter",
+					Summary:     "Disables or enables a character or edits its name, other name, or description.",
+					Parameters:  []*Parameter{slugParam("The character's slug.")},
+					RequestBody: &RequestBody{Required: true, Content: jsonContent(ref("CharacterPatch"))},
+					Responses:   responses(detailOf(ref("AdminCharacter")), http.StatusNotFound),
+				}),
+			},
+			"/admin/characters/{slug}/sources/{id}": {
+				Patch: admin(&Operation{
+					OperationID: "adminUpdateCharacterSource",
+					Summary:     "Disables or enables a character source or marks it as a main source.",
+					Parameters: []*Parameter{
+						slugParam("The character's slug."),
+						{Name: "id", In: "path", Description: "The source's ID.", Required: true, Schema: &Schema{Type: "integer"}},
+					},
+					RequestBody: &RequestBody{Required: true, Content: jsonContent(ref("CharacterSourcePatch"))},
+					Responses:   responses(detailOf(ref("CharacterSource")), http.StatusNotFound),
+				}),
+			},
+			"/admin/characters/{slug}/issues/{id}": {
+				Patch: admin(&Operation{
+					OperationID: "adminUpdateCharacterIssue",
+					Summary:     "Overrides the character's importance in the issue for the weighted rankings.",
+					Parameters: []*Parameter{
+						slugParam("The character's slug."),
+						{Name: "id", In: "path", Description: "The issue's ID from `issue_id` in the character's issues.", Required: true, Schema: &Schema{Type: "integer"}},
+					},
+					RequestBody: &RequestBody{Required: true, Content: jsonContent(ref("CharacterIssuePatch"))},
+					Responses:   responses(detailOf(ref("CharacterIssue")), http.StatusNotFound),
+				}),
+			},
+			"/admin/characters/{slug}/sources/normalize": {
+				Post: admin(&Operation{
+					OperationID: "adminNormalizeCharacterSources",
+					Summary:     "Re-categorizes the character's main and alternate sources and disables any unneeded sources.",
+					Parameters:  []*Parameter{slugParam("The character's slug.")},
+					Responses:   responses(detailOf(ref("AdminCharacter")), http.StatusNotFound),
+				}),
+			},
+			"/admin/characters/{slug}/sync": {
+				Post: admin(&Operation{
+					OperationID: "adminSyncCharacter",
+					Summary:     "Re-syncs the character's appearances and stats to the cache.",
+					Parameters:  []*Parameter{slugParam("The character's slug.")},
+					Responses:   responses(detailOf(ref("SyncResult")), http.StatusNotFound),
+				}),
+			},
+		},
+		Components: OpenAPIComponents{
+			Schemas: schemas(),
+			SecuritySchemes: map[string]*SecurityScheme{
+				"bearer": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+	}
+}
+
+// The reusable schemas for the views and models.
+func schemas() map[string]*Schema {
+	str := &Schema{Type: "string"}
+	nullableStr := &Schema{Type: "string", Nullable: true}
+	integer := &Schema{Type: "integer"}
+	number := &Schema{Type: "number"}
+	boolean := &Schema{Type: "boolean"}
+	dateTime := &Schema{Type: "string", Format: "date-time"}
+	nullableDateTime := &Schema{Type: "string", Format: "date-time", Nullable: true}
+	importance := &Schema{Type: "string", Enum: []string{"cameo", "minor", "major"}, Nullable: true}
+	character := map[string]*Schema{
+		"publisher":          ref("Publisher"),
+		"name":               str,
+		"other_name":         str,
+		"description":        str,
+		"image":              str,
+		"slug":               str,
+		"vendor_image":       str,
+		"vendor_url":         str,
+		"vendor_description": str,
+	}
+	return map[string]*Schema{
+		"Meta": object(map[string]*Schema{
+			"status_code": integer,
+			"error":       nullableStr,
+			"errors":      arrayOf(ref("ParamError")),
+			"pagination":  ref("Pagination"),
+		}),
+		"Pagination": object(map[string]*Schema{
+			"per_page":      integer,
+			"total_items":   integer,
+			"total_pages":   integer,
+			"first_page":    str,
+			"previous_page": str,
+			"current_page":  str,
+			"next_page":     str,
+			"last_page":     str,
+		}),
+		"ParamError": object(map[string]*Schema{
+			"param":   str,
+			"value":   str,
+			"allowed": arrayOf(str),
+		}),
+		"ErrorView": object(map[string]*Schema{
+			"meta": ref("Meta"),
+			"data": {Type: "object", Nullable: true},
+		}),
+		"Stats": object(map[string]*Schema{
+			"total_characters":      integer,
+			"total_appearances":     integer,
+			"main_appearances":      integer,
+			"alternate_appearances": integer,
+			"min_year":              integer,
+			"max_year":              integer,
+			"total_issues":          integer,
+			"variant_issues":        integer,
+			"reprint_issues":        integer,
+			"publishers":            arrayOf(ref("PublisherStats")),
+			"formats":               arrayOf(ref("FormatStats")),
+			"format_mix":            arrayOf(ref("FormatBreakdown")),
+			"decades":               arrayOf(ref("DecadeStats")),
+			"last_refreshed_at":     nullableDateTime,
+			"last_synced_at":        nullableDateTime,
+		}),
+		"PublisherStats": object(map[string]*Schema{
+			"slug":              str,
+			"name":              str,
+			"total_characters":  integer,
+			"total_appearances": integer,
+		}),
+		"FormatStats": object(map[string]*Schema{
+			"format":       str,
+			"total_issues": integer,
+		}),
+		"FormatBreakdown": object(map[string]*Schema{
+			"format":  str,
+			"counted": integer,
+			"excluded": object(map[string]*Schema{
+				"variant":      integer,
+				"reprint":      integer,
+				"format":       integer,
+				"no_sale_date": integer,
+				"publisher":    integer,
+			}),
+		}),
+		"DecadeStats": object(map[string]*Schema{
+			"decade":                integer,
+			"main_appearances":      integer,
+			"alternate_appearances": integer,
+		}),
+		"Publisher": object(map[string]*Schema{
+			"name": str,
+			"slug": str,
+		}),
+		"ThumbnailSizes": object(map[string]*Schema{
+			"small":  str,
+			"medium": str,
+			"large":  str,
+		}),
+		"CharacterThumbnails": object(map[string]*Schema{
+			"slug":         str,
+			"image":        ref("ThumbnailSizes"),
+			"vendor_image": ref("ThumbnailSizes"),
+		}),
+		"CharacterStats": object(map[string]*Schema{
+			"category":                           {Type: "string", Enum: []string{"all_time", "main", "alternate", "weighted"}},
+			"issue_count_rank":                   integer,
+			"issue_count":                        integer,
+			"average_issues_per_year":            number,
+			"average_issues_per_year_rank":       integer,
+			"issue_count_percentile":             number,
+			"average_issues_per_year_percentile": number,
+			"normalized_popularity":              number,
+			"relevance":                          number,
+			"relevance_rank":                     integer,
+		}),
+		"Character": object(with(character, map[string]*Schema{"thumbnails": ref("CharacterThumbnails")})),
+		"RankedCharacter": object(with(character, map[string]*Schema{
+			"thumbnails": ref("CharacterThumbnails"),
+			"stats":      ref("CharacterStats"),
+			"trend":      ref("RankTrend"),
+		})),
+		"CharacterSeries": object(map[string]*Schema{
+			"slug":         str,
+			"issue_count":  integer,
+			"series_count": integer,
+			"series":       arrayOf(ref("SeriesAppearances")),
+		}),
+		"SeriesAppearances": object(map[string]*Schema{
+			"name":        str,
+			"issue_count": integer,
+			"main":        integer,
+			"alternate":   integer,
+			"first_year":  integer,
+			"last_year":   integer,
+			"share":       number,
+		}),
+		"CoAppearance": object(map[string]*Schema{
+			"publisher":    ref("Publisher"),
+			"name":         str,
+			"other_name":   str,
+			"image":        str,
+			"slug":         str,
+			"vendor_image": str,
+			"thumbnails":   ref("CharacterThumbnails"),
+			"issue_count":  integer,
+			"affinity":     number,
+		}),
+		"CoAppearanceGraph": object(map[string]*Schema{
+			"nodes": arrayOf(ref("CoAppearanceNode")),
+			"edges": arrayOf(ref("CoAppearanceEdge")),
+		}),
+		"CoAppearanceNode": object(map[string]*Schema{
+			"id":          integer,
+			"slug":        str,
+			"name":        str,
+			"publisher":   str,
+			"issue_count": integer,
+		}),
+		"CoAppearanceEdge": object(map[string]*Schema{
+			"source":      integer,
+			"target":      integer,
+			"issue_count": integer,
+			"affinity":    number,
+		}),
+		"RankHistory": object(map[string]*Schema{
+			"slug":      str,
+			"category":  str,
+			"snapshots": arrayOf(ref("RankSnapshot")),
+		}),
+		"RankSnapshot": object(map[string]*Schema{
+			"category":                     str,
+			"issue_count_rank":             integer,
+			"issue_count":                  integer,
+			"average_issues_per_year_rank": integer,
+			"average_issues_per_year":      number,
+			"date":                         dateTime,
+		}),
+		"RankTrend": object(map[string]*Schema{
+			"previous_rank": {Type: "integer", Nullable: true},
+			"change":        integer,
+			"direction":     {Type: "string", Enum: []string{"up", "down", "same", "new"}},
+		}),
+		"ExpandedCharacter": object(with(character, map[string]*Schema{
+			"thumbnails":  ref("CharacterThumbnails"),
+			"stats":       arrayOf(ref("CharacterStats")),
+			"last_syncs":  arrayOf(ref("LastSync")),
+			"appearances": ref("AppearancesByYears"),
+			"career":      ref("CareerStats"),
+			"format_mix":  arrayOf(ref("FormatBreakdown")),
+		})),
+		"CareerIssue": object(map[string]*Schema{
+			"sale_date":            dateTime,
+			"vendor_series_name":   str,
+			"vendor_series_number": str,
+		}),
+		"CareerStats": object(map[string]*Schema{
+			"first_appearance":              ref("CareerIssue"),
+			"latest_appearance":             ref("CareerIssue"),
+			"peak_year":                     integer,
+			"peak_year_count":               integer,
+			"longest_streak":                integer,
+			"longest_streak_start":          integer,
+			"active_years":                  integer,
+			"years_since_latest_appearance": integer,
+		}),
+		"LastSync": object(map[string]*Schema{
+			"synced_at":  dateTime,
+			"num_issues": integer,
+		}),
+		"YearlyAggregate": object(map[string]*Schema{
+			"year":      integer,
+			"main":      integer,
+			"alternate": integer,
+		}),
+		"AppearancesByYears": object(map[string]*Schema{
+			"slug":       str,
+			"aggregates": arrayOf(ref("YearlyAggregate")),
+		}),
+		"MonthlyAggregate": object(map[string]*Schema{
+			"year":      integer,
+			"month":     integer,
+			"main":      integer,
+			"alternate": integer,
+		}),
+		"AppearancesByMonths": object(map[string]*Schema{
+			"slug":       str,
+			"aggregates": arrayOf(ref("MonthlyAggregate")),
+		}),
+		"Comparison": object(map[string]*Schema{
+			"years":      arrayOf(integer),
+			"characters": arrayOf(ref("ExpandedCharacter")),
+		}),
+		"Issue": object(map[string]*Schema{
+			"publication_date":     dateTime,
+			"sale_date":            dateTime,
+			"is_variant":           boolean,
+			"month_uncertain":      boolean,
+			"format":               str,
+			"vendor_publisher":     str,
+			"vendor_series_name":   str,
+			"vendor_series_number": str,
+			"is_reprint":           boolean,
+			"vendor_id":            str,
+		}),
+		"CharacterIssue": object(map[string]*Schema{
+			"issue_id":        integer,
+			"issue":           ref("Issue"),
+			"appearance_type": {Type: "string", Enum: []string{"main", "alternate", "all"}},
+			"importance":      importance,
+		}),
+		"CharacterIssuePatch": object(map[string]*Schema{
+			"importance": importance,
+		}),
+		"AdminCharacter": object(with(character, map[string]*Schema{
+			"is_disabled": boolean,
+			"sources":     arrayOf(ref("CharacterSource")),
+		})),
+		"CharacterSource": object(map[string]*Schema{
+			"id":                integer,
+			"character_id":      integer,
+			"type":              integer,
+			"vendor_url":        str,
+			"vendor_name":       str,
+			"vendor_other_name": str,
+			"is_disabled":       boolean,
+			"is_main":           boolean,
+		}),
+		"CharacterPatch": object(map[string]*Schema{
+			"is_disabled": boolean,
+			"name":        str,
+			"other_name":  str,
+			"description": str,
+		}),
+		"CharacterSourcePatch": object(map[string]*Schema{
+			"is_disabled": boolean,
+			"is_main":     boolean,
+		}),
+		"GraphQLRequest": object(map[string]*Schema{
+			"query":         str,
+			"operationName": str,
+			"variables":     {Type: "object"},
+		}),
+		"SyncResult": object(map[string]*Schema{
+			"slug":   str,
+			"issues": integer,
+		}),
+		"Readiness": object(map[string]*Schema{
+			"ready":    boolean,
+			"postgres": ref("DependencyStatus"),
+			"redis":    ref("DependencyStatus"),
+			"views": object(map[string]*Schema{
+				"refreshed_at": {Type: "string", Format: "date-time", Nullable: true},
+				"age_seconds":  {Type: "integer", Nullable: true},
+			}),
+			"last_successful_sync": {
+				Type:     "object",
+				Nullable: true,
+				Properties: map[string]*Schema{
+					"character_id": integer,
+					"synced_at":    dateTime,
+					"age_seconds":  integer,
+				},
+			},
+		}),
+		"DependencyStatus": object(map[string]*Schema{
+			"ok":         boolean,
+			"latency_ms": integer,
+		}),
+		"RankedRow": object(map[string]*Schema{
+			"slug":                         str,
+			"name":                         str,
+			"other_name":                   str,
+			"publisher":                    str,
+			"category":                     str,
+			"issue_count_rank":             integer,
+			"issue_count":                  integer,
+			"average_issues_per_year_rank": integer,
+			"average_issues_per_year":      number,
+			"image":                        str,
+			"vendor_image":                 str,
+			"vendor_url":                   str,
+		}),
+	}
+}
+
+// The query parameters parsed by `decodeCriteria`.
+func rankingParams() []*Parameter {
+	return []*Parameter{
+		pageParam(),
+		enumParam("sort", "Rank by the number of issues, the average issues per year, or the issues weighted by how recent they are.", rankingSorts),
+		enumParam("category", "The type of appearances. Weighted counts all appearances by the character's importance in the issues.", rankingCategories),
+		halfLifeParam(),
+		cursorParam("after", "Get the characters ranked after the cursor from a `next_page` link."),
+		cursorParam("before", "Get the characters ranked before the cursor from a `previous_page` link, or `last` for the last page."),
+	}
+}
+
+// The parameter for how fast the issues count less when ranking by relevance.
+func halfLifeParam() *Parameter {
+	min, max := 1, maxHalfLife
+	return &Parameter{
+		Name:        "half_life",
+		In:          "query",
+		Description: "The number of years it takes an issue to count half as much when sorted by relevance.",
+		Schema:      &Schema{Type: "integer", Minimum: &min, Maximum: &max, Default: comic.DefaultHalfLife},
+	}
+}
+
+// The parameters for ranking the characters by their appearances in an era or a range of years.
+func yearsParams() []*Parameter {
+	min := 1
+	return []*Parameter{
+		{Name: "era", In: "query", Description: "Rank by the appearances in the era. Can't be used with `from` and `to`.", Schema: &Schema{Type: "string", Enum: eras}},
+		{Name: "from", In: "query", Description: "Rank by the appearances from the year.", Schema: &Schema{Type: "integer", Minimum: &min}},
+		{Name: "to", In: "query", Description: "Rank by the appearances up to and including the year. Requires `from`.", Schema: &Schema{Type: "integer", Minimum: &min}},
+	}
+}
+
+// The parameters for the co-appearance graph.
+func graphParams() []*Parameter {
+	min, maxLimit := 1, graphMaxLimit
+	return []*Parameter{
+		enumParam("format", "Export the graph as JSON or GraphML.", graphFormats),
+		{Name: "min", In: "query", Description: "Leave out the pairs of characters with fewer shared issues.", Schema: &Schema{Type: "integer", Minimum: &min, Default: graphMinIssues}},
+		{Name: "limit", In: "query", Description: "The max number of pairs of characters, the most shared issues first.", Schema: &Schema{Type: "integer", Minimum: &min, Maximum: &maxLimit, Default: graphLimit}},
+	}
+}
+
+func cursorParam(name, description string) *Parameter {
+	return &Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: "string"}}
+}
+
+func pageParam() *Parameter {
+	min := 1
+	return &Parameter{Name: "page", In: "query", Description: "The page number.", Schema: &Schema{Type: "integer", Minimum: &min, Default: 1}}
+}
+
+func formatParam() *Parameter {
+	return enumParam("format", "Export the whole list instead of a page. The `Accept` header works too.", exportFormats)
+}
+
+func slugParam(description string) *Parameter {
+	return &Parameter{Name: "slug", In: "path", Description: description, Required: true, Schema: &Schema{Type: "string"}}
+}
+
+// Creates a parameter that only allows the values. The first value is the default.
+func enumParam(name, description string, allowed []string) *Parameter {
+	return &Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: "string", Enum: allowed, Default: allowed[0]}}
+}
+
+func get(op *Operation) *PathItem {
+	return &PathItem{Get: op}
+}
+
+// Creates the operation for executing a GraphQL query. POST requests send the query in the body.
+func graphQLOperation(id string, params ...*Parameter) *Operation {
+	op := &Operation{
+		OperationID: id,
+		Summary:     "Queries characters, their stats, thumbnails, appearances, and the rankings with GraphQL.",
+		Tags:        []string{"graphql"},
+		Parameters:  params,
+		Responses: map[string]*Response{
+			"200": {Description: "The GraphQL result with `data` and `errors`.", Content: jsonContent(&Schema{Type: "object"})},
+			"400": errorResponse(http.StatusBadRequest),
+		},
+	}
+	if len(params) == 0 {
+		op.RequestBody = &RequestBody{Required: true, Content: jsonContent(ref("GraphQLRequest"))}
+	}
+	return op
+}
+
+// Marks the operation as an admin operation that requires a JWT bearer token.
+func admin(op *Operation) *Operation {
+	op.Tags = append(op.Tags, "admin")
+	op.Security = []map[string][]string{{"bearer": {}}}
+	op.Responses[strconv.Itoa(http.StatusUnauthorized)] = errorResponse(http.StatusUnauthorized)
+	return op
+}
+
+// Creates the 200 response and a 400 error response along with any other error status codes.
+func responses(ok *Schema, statusCodes ...int) map[string]*Response {
+	r := map[string]*Response{
+		"200": {Description: "OK", Content: jsonContent(ok)},
+		"400": errorResponse(http.StatusBadRequest),
+	}
+	for _, code := range statusCodes {
+		r[strconv.Itoa(code)] = errorResponse(code)
+	}
+	return r
+}
+
+// Creates the responses for lists of ranked characters that can be exported.
+func exportResponses(statusCodes ...int) map[string]*Response {
+	r := responses(listOf(ref("RankedCharacter")), statusCodes...)
+	r["200"].Content[MIMETextCSV] = &MediaType{Schema: &Schema{Type: "string"}}
+	r["200"].Content[MIMEApplicationNDJSON] = &MediaType{Schema: ref("RankedRow")}
+	return r
+}
+
+// Creates the responses for the co-appearance graph as JSON or GraphML.
+func graphResponses() map[string]*Response {
+	r := responses(detailOf(ref("CoAppearanceGraph")))
+	r["200"].Content[MIMEApplicationGraphML] = &MediaType{Schema: &Schema{Type: "string"}}
+	return r
+}
+
+func errorResponse(statusCode int) *Response {
+	return &Response{Description: http.StatusText(statusCode), Content: jsonContent(ref("ErrorView"))}
+}
+
+func jsonContent(s *Schema) map[string]*MediaType {
+	return map[string]*MediaType{echo.MIMEApplicationJSON: {Schema: s}}
+}
+
+// The `DetailView` envelope for the data.
+func detailOf(data *Schema) *Schema {
+	return object(map[string]*Schema{"meta": ref("Meta"), "data": data})
+}
+
+// The `ListView` envelope for the items.
+func listOf(item *Schema) *Schema {
+	return object(map[string]*Schema{"meta": ref("Meta"), "data": arrayOf(item)})
+}
+
+func ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func object(properties map[string]*Schema) *Schema {
+	return &Schema{Type: "object", Properties: properties}
+}
+
+func arrayOf(item *Schema) *Schema {
+	return &Schema{Type: "array", Items: item}
+}
+
+// Merges the properties into a new map.
+func with(properties ...map[string]*Schema) map[string]*Schema {
+	m := make(map[string]*Schema)
+	for _, p := range properties {
+		for k, v := range p {
+			m[k] = v
+		}
+	}
+	return m
+}
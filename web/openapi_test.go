@@ -0,0 +1,88 @@
+package web_test
+
+import (
+	"encoding/json"
+	"github.com/comiccruncher/comiccruncher/web"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewOpenAPI(t *testing.T) {
+	doc := web.NewOpenAPI()
+	assert.Equal(t, "3.0.2", doc.OpenAPI)
+	for _, path := range []string{
+		"/openapi.json",
+		"/healthz",
+		"/readyz",
+		"/stats",
+		"/compare",
+		"/search/characters",
+		"/characters",
+		"/characters/{slug}",
+		"/characters/{slug}/issues",
+		"/characters/{slug}/series",
+		"/characters/{slug}/appearances/monthly",
+		"/characters/{slug}/rank-history",
+		"/characters/{slug}/co-appearances",
+		"/co-appearances",
+		"/publishers/{slug}",
+		"/trending/{slug}",
+	} {
+		assert.NotNil(t, doc.Paths[path], path)
+		assert.NotNil(t, doc.Paths[path].Get.Responses["200"], path)
+	}
+	var sort *web.Parameter
+	for _, p := range doc.Paths["/characters"].Get.Parameters {
+		if p.Name == "sort" {
+			sort = p
+		}
+	}
+	assert.NotNil(t, sort)
+	assert.Equal(t, []string{"issues", "average", "relevance"}, sort.Schema.Enum)
+}
+
+func TestNewOpenAPIRefsExist(t *testing.T) {
+	doc := web.NewOpenAPI()
+	b, err := json.Marshal(doc)
+	assert.Nil(t, err)
+	var refs []string
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch val := v.(type) {
+		case map[string]interface{}:
+			for k, vv := range val {
+				if k == "$ref" {
+					refs = append(refs, vv.(string))
+				}
+				walk(vv)
+			}
+		case []interface{}:
+			for _, vv := range val {
+				walk(vv)
+			}
+		}
+	}
+	var m map[string]interface{}
+	assert.Nil(t, json.Unmarshal(b, &m))
+	walk(m)
+	assert.NotEmpty(t, refs)
+	for _, r := range refs {
+		name := r[len("#/components/schemas/"):]
+		assert.NotNil(t, doc.Components.Schemas[name], r)
+	}
+}
+
+func TestOpenAPIControllerOpenAPI(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	err := web.NewOpenAPIController().OpenAPI(c)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"openapi": "3.0.2"`)
+}
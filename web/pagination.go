@@ -2,13 +2,27 @@ package web
 
 import (
 	"bytes"
+	"fmt"
+	"github.com/comiccruncher/comiccruncher/comic"
 	"github.com/labstack/echo/v4"
+	"math"
+	"net/url"
 	"strconv"
+	"strings"
 )
 
 // InvalidPageErr is for an invalid page / bad request.
 var InvalidPageErr = NewBadRequestError("Invalid page parameter")
 
+// InvalidCursorErr is for an invalid `after` or `before` cursor.
+var InvalidCursorErr = NewBadRequestError("Invalid cursor parameter")
+
+// The `before` cursor for the last page. It's past every rank so seeking before it gets the last characters.
+const lastCursor = "last"
+
+// The position past every ranked character.
+var endCursor = comic.RankCursor{Rank: math.MaxInt32, ID: math.MaxInt32}
+
 // Page represents a page number and link.
 type Page struct {
 	Number int    `json:"number"`
@@ -18,9 +32,13 @@ type Page struct {
 // Pagination is a view that displays pagination info.
 type Pagination struct {
 	PerPage      int    `json:"per_page"`
+	TotalItems   *int   `json:"total_items,omitempty"`
+	TotalPages   *int   `json:"total_pages,omitempty"`
+	FirstPage    string `json:"first_page,omitempty"`
 	PreviousPage string `json:"previous_page"`
 	CurrentPage  string `json:"current_page"`
 	NextPage     string `json:"next_page"`
+	LastPage     string `json:"last_page,omitempty"`
 }
 
 // CreatePagination creates a new pagination. TODO: clean this crap up.
@@ -50,6 +68,116 @@ func CreatePagination(ctx echo.Context, data []interface{}, itemsPerPage int) (*
 	return pagination, nil
 }
 
+// CreateRankedPagination creates the pagination for a page of ranked characters with the total number of characters.
+// The previous and next links seek from the first and last characters on the page with a cursor instead of an offset.
+// The `page` in the links is only for numbering the pages.
+func CreateRankedPagination(ctx echo.Context, results []*comic.RankedCharacter, cr comic.PopularCriteria, total, itemsPerPage int) (*Pagination, error) {
+	page, err := parsePageNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	totalPages := (total + itemsPerPage - 1) / itemsPerPage
+	if cr.Before != nil && *cr.Before == endCursor {
+		page = totalPages
+	}
+	items := results
+	if len(items) > itemsPerPage {
+		items = items[:itemsPerPage]
+	}
+	pagination := &Pagination{
+		PerPage:     itemsPerPage,
+		TotalItems:  &total,
+		TotalPages:  &totalPages,
+		CurrentPage: fullPath(ctx.Request().URL.EscapedPath(), ctx.QueryString()),
+		FirstPage:   pageLink(ctx, 1, "", ""),
+		LastPage:    pageLink(ctx, 1, "", ""),
+	}
+	if totalPages > 1 {
+		pagination.LastPage = pageLink(ctx, totalPages, "before", lastCursor)
+	}
+	if page > 1 {
+		switch {
+		case page == 2:
+			pagination.PreviousPage = pagination.FirstPage
+		case len(items) > 0:
+			pagination.PreviousPage = pageLink(ctx, page-1, "before", encodeCursor(items[0].Cursor(cr.SortBy)))
+		default:
+			pagination.PreviousPage = pageLink(ctx, page-1, "", "")
+		}
+	}
+	// seeking before a cursor doesn't get an extra character to tell if there's a next page.
+	hasNext := len(results) > itemsPerPage
+	if cr.Before != nil {
+		hasNext = page < totalPages
+	}
+	if hasNext && len(items) > 0 {
+		pagination.NextPage = pageLink(ctx, page+1, "after", encodeCursor(items[len(items)-1].Cursor(cr.SortBy)))
+	}
+	return pagination, nil
+}
+
+// Creates the link to the page number with the cursor param. Any other cursor in the query string is removed.
+func pageLink(ctx echo.Context, page int, cursorParam, cursor string) string {
+	params := url.Values{}
+	for k, v := range ctx.QueryParams() {
+		params[k] = v
+	}
+	params.Del("after")
+	params.Del("before")
+	params.Set("page", strconv.Itoa(page))
+	if cursorParam != "" {
+		params.Set(cursorParam, cursor)
+	}
+	return fullPath(ctx.Request().URL.EscapedPath(), params.Encode())
+}
+
+// Decodes the `after` or `before` cursor in the context onto the criteria.
+func decodeCursor(ctx echo.Context, cr *comic.PopularCriteria) error {
+	after, before := ctx.QueryParam("after"), ctx.QueryParam("before")
+	if after != "" && before != "" {
+		return NewBadRequestError("Only one of the after or before parameters can be used.")
+	}
+	if after != "" {
+		cursor, err := decodeRankCursor(after)
+		if err != nil {
+			return err
+		}
+		cr.After = &cursor
+	}
+	if before == lastCursor {
+		cr.Before = &endCursor
+	} else if before != "" {
+		cursor, err := decodeRankCursor(before)
+		if err != nil {
+			return err
+		}
+		cr.Before = &cursor
+	}
+	return nil
+}
+
+// Encodes the cursor as `rank-id`.
+func encodeCursor(c comic.RankCursor) string {
+	return fmt.Sprintf("%d-%d", c.Rank, c.ID)
+}
+
+// Decodes a cursor encoded as `rank-id`.
+func decodeRankCursor(s string) (comic.RankCursor, error) {
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		return comic.RankCursor{}, InvalidCursorErr
+	}
+	rank, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return comic.RankCursor{}, InvalidCursorErr
+	}
+	id, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return comic.RankCursor{}, InvalidCursorErr
+	}
+	return comic.RankCursor{Rank: uint(rank), ID: comic.CharacterID(id)}, nil
+}
+
 // Gets the previous page from the current page and context.
 func previousPage(ctx echo.Context) (string, error) {
 	pageNum, err := parsePageNumber(ctx)
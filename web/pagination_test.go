@@ -1,6 +1,7 @@
 package web_test
 
 import (
+	"github.com/comiccruncher/comiccruncher/comic"
 	"github.com/comiccruncher/comiccruncher/web"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
@@ -94,3 +95,72 @@ func TestCreatePaginationBadParameter(t *testing.T) {
 	_, err := web.CreatePagination(c, data, 20)
 	assert.Error(t, err)
 }
+
+func rankedCharacters(n int) []*comic.RankedCharacter {
+	results := make([]*comic.RankedCharacter, n)
+	for i := range results {
+		results[i] = &comic.RankedCharacter{ID: comic.CharacterID(100 + i), Stats: comic.CharacterStats{IssueCountRank: uint(i + 1), AverageRank: uint(n - i)}}
+	}
+	return results
+}
+
+func TestCreateRankedPaginationFirstPage(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/characters?category=main", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	p, err := web.CreateRankedPagination(c, rankedCharacters(5), comic.PopularCriteria{SortBy: comic.MostIssues}, 10, 4)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 10, *p.TotalItems)
+	assert.Equal(t, 3, *p.TotalPages)
+	assert.Equal(t, "/characters?category=main&page=1", p.FirstPage)
+	assert.Equal(t, "", p.PreviousPage)
+	assert.Equal(t, "/characters?category=main", p.CurrentPage)
+	assert.Equal(t, "/characters?after=4-103&category=main&page=2", p.NextPage)
+	assert.Equal(t, "/characters?before=last&category=main&page=3", p.LastPage)
+}
+
+func TestCreateRankedPaginationAfterCursor(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/characters?page=3&after=4-103&sort=average", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	cr := comic.PopularCriteria{SortBy: comic.AverageIssuesPerYear, After: &comic.RankCursor{Rank: 4, ID: 103}}
+
+	p, err := web.CreateRankedPagination(c, rankedCharacters(4), cr, 12, 4)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "/characters?before=4-100&page=2&sort=average", p.PreviousPage)
+	assert.Equal(t, "", p.NextPage)
+	assert.Equal(t, "/characters?before=last&page=3&sort=average", p.LastPage)
+}
+
+func TestCreateRankedPaginationBeforeCursor(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/characters?page=2&before=9-108", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	cr := comic.PopularCriteria{SortBy: comic.MostIssues, Before: &comic.RankCursor{Rank: 9, ID: 108}}
+
+	p, err := web.CreateRankedPagination(c, rankedCharacters(4), cr, 10, 4)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "/characters?page=1", p.PreviousPage)
+	assert.Equal(t, "/characters?after=4-103&page=3", p.NextPage)
+}
+
+func TestCreateRankedPaginationEmpty(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/characters", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	p, err := web.CreateRankedPagination(c, nil, comic.PopularCriteria{SortBy: comic.MostIssues}, 0, 4)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 0, *p.TotalPages)
+	assert.Equal(t, "/characters?page=1", p.LastPage)
+	assert.Equal(t, "", p.NextPage)
+}
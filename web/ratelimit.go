@@ -0,0 +1,207 @@
+package web
+
+import (
+	"errors"
+	"github.com/comiccruncher/comiccruncher/comic"
+	"github.com/comiccruncher/comiccruncher/internal/log"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The headers for telling the client about its rate limit.
+const (
+	HeaderRateLimitLimit     = "X-RateLimit-Limit"
+	HeaderRateLimitRemaining = "X-RateLimit-Remaining"
+	HeaderRateLimitReset     = "X-RateLimit-Reset"
+	HeaderRetryAfter         = "Retry-After"
+	// HeaderAPIKey is the header for identifying a client by its API key.
+	HeaderAPIKey = "X-API-KEY"
+	// HeaderVisitorID is the header the frontend sends for identifying a visitor.
+	HeaderVisitorID = "X-VISITOR-ID"
+)
+
+// Takes a token from the bucket stored in a hash with the number of tokens left and when it was last updated.
+// The bucket refills continuously at `rate` tokens per millisecond up to `capacity` and expires once it would be full.
+// Returns whether the request is allowed, the tokens remaining, and the milliseconds until a token is available and
+// until the bucket is full.
+const tokenBucketScript = `
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "updated_at")
+local tokens = tonumber(bucket[1]) or capacity
+local updated = tonumber(bucket[2]) or now
+tokens = math.min(capacity, tokens + math.max(0, now - updated) * rate)
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) / rate)
+end
+local reset = math.ceil((capacity - tokens) / rate)
+redis.call("HMSET", KEYS[1], "tokens", tostring(tokens), "updated_at", now)
+redis.call("PEXPIRE", KEYS[1], math.max(reset, 1))
+return {allowed, math.floor(tokens), retry, reset}
+`
+
+// RateLimit is the token bucket configuration for a route group.
+type RateLimit struct {
+	// Name namespaces the buckets so each route group gets its own limit.
+	Name string
+	// Limit is the number of requests a client can burst before getting limited.
+	Limit int
+	// Per is how long it takes for an empty bucket to refill all the way to the limit.
+	Per time.Duration
+}
+
+// RateLimitResult is the state of a client's bucket after taking a token.
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+	Reset      time.Duration
+}
+
+// A shared IP like an office's gets this many times the limit across all of its visitors.
+const sharedIPFactor = 10
+
+// RateLimiter limits clients with token buckets stored in Redis.
+type RateLimiter struct {
+	r       comic.RedisClient
+	apiKeys map[string]bool
+	now     func() time.Time
+}
+
+// The bucket a request takes a token from.
+type rateLimitBucket struct {
+	limit  RateLimit
+	client string
+}
+
+// Take takes a token from the client's bucket for the rate limit.
+func (l *RateLimiter) Take(limit RateLimit, client string) (RateLimitResult, error) {
+	rate := float64(limit.Limit) / float64(limit.Per/time.Millisecond)
+	key := "ratelimit:" + limit.Name + ":" + client
+	now := l.now().UnixNano() / int64(time.Millisecond)
+	res, err := l.r.Eval(tokenBucketScript, []string{key}, limit.Limit, rate, now).Result()
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	vals, ok := res.([]interface{})
+	if !ok || len(vals) != 4 {
+		return RateLimitResult{}, errors.New("unexpected result from the rate limit script")
+	}
+	ints := make([]int64, len(vals))
+	for i, v := range vals {
+		if ints[i], ok = v.(int64); !ok {
+			return RateLimitResult{}, errors.New("unexpected result from the rate limit script")
+		}
+	}
+	return RateLimitResult{
+		Allowed:    ints[0] == 1,
+		Remaining:  int(ints[1]),
+		RetryAfter: time.Duration(ints[2]) * time.Millisecond,
+		Reset:      time.Duration(ints[3]) * time.Millisecond,
+	}, nil
+}
+
+// Middleware creates the middleware for limiting requests for the rate limit.
+// Requests are let through if Redis can't be reached so the API doesn't go down with it.
+func (l *RateLimiter) Middleware(limit RateLimit) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			buckets := l.buckets(ctx, limit)
+			// the headers are for the client's own bucket.
+			res, err := l.Take(buckets[0].limit, buckets[0].client)
+			if err != nil {
+				logContext(err, ctx)
+				return next(ctx)
+			}
+			h := ctx.Response().Header()
+			h.Set(HeaderRateLimitLimit, strconv.Itoa(limit.Limit))
+			h.Set(HeaderRateLimitRemaining, strconv.Itoa(res.Remaining))
+			h.Set(HeaderRateLimitReset, strconv.FormatInt(l.now().Add(res.Reset).Unix(), 10))
+			for _, b := range buckets[1:] {
+				if !res.Allowed {
+					break
+				}
+				shared, err := l.Take(b.limit, b.client)
+				if err != nil {
+					logContext(err, ctx)
+					return next(ctx)
+				}
+				if !shared.Allowed {
+					res = shared
+					h.Set(HeaderRateLimitRemaining, "0")
+				}
+			}
+			if !res.Allowed {
+				h.Set(HeaderRetryAfter, strconv.Itoa(int((res.RetryAfter+time.Second-1)/time.Second)))
+				log.WEB().Info("rate limited", zap.String("limit", limit.Name), zap.String("client", buckets[0].client))
+				return echo.NewHTTPError(http.StatusTooManyRequests, "Too many requests. Slow down.")
+			}
+			return next(ctx)
+		}
+	}
+}
+
+// Gets the buckets for the client. A configured API key gets its own bucket. Anyone can make up a visitor ID, so
+// a visitor's bucket is for their IP and they also take from a bigger bucket shared by the IP. Otherwise the client
+// is identified by its IP.
+func (l *RateLimiter) buckets(ctx echo.Context, limit RateLimit) []rateLimitBucket {
+	req := ctx.Request()
+	key := req.Header.Get(HeaderAPIKey)
+	if key == "" {
+		key = ctx.QueryParam("key")
+	}
+	if key != "" && l.apiKeys[key] {
+		return []rateLimitBucket{{limit: limit, client: "key:" + key}}
+	}
+	ip := clientIP(req)
+	if id := req.Header.Get(HeaderVisitorID); id != "" {
+		shared := RateLimit{Name: limit.Name + ":shared", Limit: limit.Limit * sharedIPFactor, Per: limit.Per}
+		return []rateLimitBucket{{limit: limit, client: "visitor:" + ip + ":" + id}, {limit: shared, client: "ip:" + ip}}
+	}
+	return []rateLimitBucket{{limit: limit, client: "ip:" + ip}}
+}
+
+// Gets the client's IP from the `X-Real-IP` header nginx sets, or else the connection's address.
+// `X-Forwarded-For` isn't used since the client controls its first entry.
+func clientIP(req *http.Request) string {
+	if ip := req.Header.Get(echo.HeaderXRealIP); ip != "" {
+		return ip
+	}
+	ip, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return ip
+}
+
+// NewRateLimiter creates a new rate limiter that stores its buckets in Redis. Only the API keys get their own buckets.
+func NewRateLimiter(r comic.RedisClient, apiKeys ...string) *RateLimiter {
+	keys := make(map[string]bool, len(apiKeys))
+	for _, k := range apiKeys {
+		if k = strings.TrimSpace(k); k != "" {
+			keys[k] = true
+		}
+	}
+	return &RateLimiter{
+		r:       r,
+		apiKeys: keys,
+		now:     time.Now,
+	}
+}
+
+// NewRateLimiterFromEnvironment creates a new rate limiter with the API keys from the comma-separated `CC_API_KEYS`.
+func NewRateLimiterFromEnvironment(r comic.RedisClient) *RateLimiter {
+	return NewRateLimiter(r, strings.Split(os.Getenv("CC_API_KEYS"), ",")...)
+}
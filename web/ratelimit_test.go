@@ -0,0 +1,172 @@
+package web_test
+
+import (
+	"errors"
+	"github.com/comiccruncher/comiccruncher/internal/mocks/comic"
+	"github.com/comiccruncher/comiccruncher/web"
+	"github.com/go-redis/redis"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var testRateLimit = web.RateLimit{Name: "test", Limit: 60, Per: time.Minute}
+
+func TestRateLimiterTake(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r := mock_comic.NewMockRedisClient(ctrl)
+	r.EXPECT().Eval(gomock.Any(), []string{"ratelimit:test:ip:192.0.2.1"}, 60, 0.001, gomock.Any()).
+		Return(redis.NewCmdResult([]interface{}{int64(1), int64(59), int64(0), int64(1000)}, nil))
+
+	res, err := web.NewRateLimiter(r).Take(testRateLimit, "ip:192.0.2.1")
+	assert.Nil(t, err)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 59, res.Remaining)
+	assert.Equal(t, time.Duration(0), res.RetryAfter)
+	assert.Equal(t, time.Second, res.Reset)
+}
+
+func TestRateLimiterTakeUnexpectedResult(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r := mock_comic.NewMockRedisClient(ctrl)
+	r.EXPECT().Eval(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(redis.NewCmdResult("OK", nil))
+
+	_, err := web.NewRateLimiter(r).Take(testRateLimit, "ip:192.0.2.1")
+	assert.NotNil(t, err)
+}
+
+func TestRateLimiterMiddlewareAllowed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r := mock_comic.NewMockRedisClient(ctrl)
+	r.EXPECT().Eval(gomock.Any(), []string{"ratelimit:test:visitor:192.0.2.1:abc"}, 60, gomock.Any(), gomock.Any()).
+		Return(redis.NewCmdResult([]interface{}{int64(1), int64(10), int64(0), int64(50000)}, nil))
+	r.EXPECT().Eval(gomock.Any(), []string{"ratelimit:test:shared:ip:192.0.2.1"}, 600, gomock.Any(), gomock.Any()).
+		Return(redis.NewCmdResult([]interface{}{int64(1), int64(500), int64(0), int64(5000)}, nil))
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/characters", nil)
+	req.Header.Set(web.HeaderVisitorID, "abc")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	called := false
+	err := web.NewRateLimiter(r).Middleware(testRateLimit)(func(ctx echo.Context) error {
+		called = true
+		return nil
+	})(c)
+	assert.Nil(t, err)
+	assert.True(t, called)
+	h := rec.Header()
+	assert.Equal(t, "60", h.Get(web.HeaderRateLimitLimit))
+	assert.Equal(t, "10", h.Get(web.HeaderRateLimitRemaining))
+	assert.NotEmpty(t, h.Get(web.HeaderRateLimitReset))
+	assert.Empty(t, h.Get(web.HeaderRetryAfter))
+}
+
+func TestRateLimiterMiddlewareLimited(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r := mock_comic.NewMockRedisClient(ctrl)
+	r.EXPECT().Eval(gomock.Any(), []string{"ratelimit:test:key:secret"}, gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(redis.NewCmdResult([]interface{}{int64(0), int64(0), int64(1500), int64(60000)}, nil))
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/characters?page=100", nil)
+	req.Header.Set(web.HeaderAPIKey, "secret")
+	req.Header.Set(web.HeaderVisitorID, "abc")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	err := web.NewRateLimiter(r, "other", "secret").Middleware(testRateLimit)(func(ctx echo.Context) error {
+		t.Fatal("the handler shouldn't be called")
+		return nil
+	})(c)
+	assert.Equal(t, http.StatusTooManyRequests, err.(*echo.HTTPError).Code)
+	assert.Equal(t, "2", rec.Header().Get(web.HeaderRetryAfter))
+	assert.Equal(t, "0", rec.Header().Get(web.HeaderRateLimitRemaining))
+}
+
+func TestRateLimiterMiddlewareRedisError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r := mock_comic.NewMockRedisClient(ctrl)
+	r.EXPECT().Eval(gomock.Any(), []string{"ratelimit:test:ip:192.0.2.1"}, gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(redis.NewCmdResult(nil, errors.New("connection refused")))
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/characters", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	called := false
+	err := web.NewRateLimiter(r).Middleware(testRateLimit)(func(ctx echo.Context) error {
+		called = true
+		return nil
+	})(c)
+	assert.Nil(t, err)
+	assert.True(t, called)
+	assert.Empty(t, rec.Header().Get(web.HeaderRateLimitLimit))
+}
+
+func TestRateLimiterMiddlewareSharedIPLimited(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r := mock_comic.NewMockRedisClient(ctrl)
+	r.EXPECT().Eval(gomock.Any(), []string{"ratelimit:test:visitor:192.0.2.1:made-up"}, gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(redis.NewCmdResult([]interface{}{int64(1), int64(59), int64(0), int64(1000)}, nil))
+	r.EXPECT().Eval(gomock.Any(), []string{"ratelimit:test:shared:ip:192.0.2.1"}, gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(redis.NewCmdResult([]interface{}{int64(0), int64(0), int64(3000), int64(60000)}, nil))
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/characters", nil)
+	req.Header.Set(web.HeaderVisitorID, "made-up")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	err := web.NewRateLimiter(r).Middleware(testRateLimit)(func(ctx echo.Context) error {
+		t.Fatal("the handler shouldn't be called")
+		return nil
+	})(c)
+	assert.Equal(t, http.StatusTooManyRequests, err.(*echo.HTTPError).Code)
+	assert.Equal(t, "3", rec.Header().Get(web.HeaderRetryAfter))
+	assert.Equal(t, "0", rec.Header().Get(web.HeaderRateLimitRemaining))
+}
+
+func TestRateLimiterMiddlewareUnknownKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r := mock_comic.NewMockRedisClient(ctrl)
+	r.EXPECT().Eval(gomock.Any(), []string{"ratelimit:test:ip:203.0.113.7"}, gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(redis.NewCmdResult([]interface{}{int64(1), int64(59), int64(0), int64(1000)}, nil))
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/characters?key=random", nil)
+	req.Header.Set(web.HeaderAPIKey, "random")
+	req.Header.Set(echo.HeaderXRealIP, "203.0.113.7")
+	req.Header.Set(echo.HeaderXForwardedFor, "198.51.100.1, 203.0.113.7")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	called := false
+	err := web.NewRateLimiter(r, "secret").Middleware(testRateLimit)(func(ctx echo.Context) error {
+		called = true
+		return nil
+	})(c)
+	assert.Nil(t, err)
+	assert.True(t, called)
+}
@@ -1,15 +1,23 @@
 package web
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"github.com/comiccruncher/comiccruncher/comic"
 	"github.com/labstack/echo/v4"
 	"net/http"
+	"strings"
+	"time"
 )
 
 // Meta is the meta struct for an HTTP JSON response.
 type Meta struct {
-	StatusCode int         `json:"status_code"`
-	Error      *string     `json:"error"`
-	Pagination *Pagination `json:"pagination"`
+	StatusCode int           `json:"status_code"`
+	Error      *string       `json:"error"`
+	Errors     []*ParamError `json:"errors,omitempty"`
+	Pagination *Pagination   `json:"pagination"`
 }
 
 // DetailView is a detail view for a single object.
@@ -31,7 +39,7 @@ func JSONDetailViewOK(ctx echo.Context, data interface{}) error {
 
 // JSONDetailView returns a detail view.
 func JSONDetailView(ctx echo.Context, data interface{}, statusCode int) error {
-	return ctx.JSONPretty(statusCode, NewDetailView(data, statusCode), "  ")
+	return jsonCached(ctx, statusCode, NewDetailView(data, statusCode))
 }
 
 // JSONListViewOK returns a list view.
@@ -41,9 +49,71 @@ func JSONListViewOK(ctx echo.Context, data []interface{}, itemsPerPage int) erro
 		return err
 	}
 	if len(data) > itemsPerPage {
-		return ctx.JSONPretty(http.StatusOK, NewListViewOK(data[:len(data)-1], pagination), "  ")
+		return jsonCached(ctx, http.StatusOK, NewListViewOK(data[:len(data)-1], pagination))
+	}
+	return jsonCached(ctx, http.StatusOK, NewListViewOK(data, pagination))
+}
+
+// JSONRankedListViewOK returns a list view of ranked characters with keyset pagination.
+func JSONRankedListViewOK(ctx echo.Context, results []*comic.RankedCharacter, cr comic.PopularCriteria, total, itemsPerPage int) error {
+	pagination, err := CreateRankedPagination(ctx, results, cr, total, itemsPerPage)
+	if err != nil {
+		return err
+	}
+	if len(results) > itemsPerPage {
+		results = results[:itemsPerPage]
+	}
+	return jsonCached(ctx, http.StatusOK, NewListViewOK(listRanked(results), pagination))
+}
+
+// Writes the pretty JSON response with a strong `ETag` computed from the body. Responds with a 304 and no body
+// if the client already has the response from either `If-None-Match` or `If-Modified-Since`.
+func jsonCached(ctx echo.Context, statusCode int, i interface{}) error {
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(i); err != nil {
+		return err
+	}
+	req := ctx.Request()
+	if statusCode == http.StatusOK && (req.Method == http.MethodGet || req.Method == http.MethodHead) {
+		etag := fmt.Sprintf(`"%x"`, sha256.Sum256(buf.Bytes()))
+		ctx.Response().Header().Set("ETag", etag)
+		if isNotModified(req, ctx.Response().Header(), etag) {
+			return ctx.NoContent(http.StatusNotModified)
+		}
+	}
+	return ctx.Blob(statusCode, echo.MIMEApplicationJSONCharsetUTF8, buf.Bytes())
+}
+
+// Checks the request's conditional headers. `If-Modified-Since` is only checked when there's no `If-None-Match`.
+// The ETags are compared weakly like `If-None-Match` needs, so an ETag a proxy marked weak with `W/` still matches.
+func isNotModified(req *http.Request, h http.Header, etag string) bool {
+	if inm := req.Header.Get("If-None-Match"); inm != "" {
+		for _, t := range strings.Split(inm, ",") {
+			t = strings.TrimSpace(t)
+			if t == "*" || strings.TrimPrefix(t, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	ims, err := http.ParseTime(req.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	lm, err := http.ParseTime(h.Get(echo.HeaderLastModified))
+	if err != nil {
+		return false
+	}
+	return !lm.After(ims)
+}
+
+// Sets the `Last-Modified` header if the time isn't zero.
+func setLastModified(ctx echo.Context, t time.Time) {
+	if !t.IsZero() {
+		ctx.Response().Header().Set(echo.HeaderLastModified, t.UTC().Format(http.TimeFormat))
 	}
-	return ctx.JSONPretty(http.StatusOK, NewListViewOK(data, pagination), "  ")
 }
 
 // NewJSONErrorView returns a new view JSON view with an error message and status code.
@@ -51,6 +121,11 @@ func NewJSONErrorView(ctx echo.Context, err string, statusCode int) error {
 	return ctx.JSONPretty(statusCode, DetailView{Meta: Meta{StatusCode: statusCode, Error: &err}}, "  ")
 }
 
+// NewJSONParamErrorView returns a new JSON error view with the details about the invalid parameters.
+func NewJSONParamErrorView(ctx echo.Context, err string, statusCode int, errs ...*ParamError) error {
+	return ctx.JSONPretty(statusCode, DetailView{Meta: Meta{StatusCode: statusCode, Error: &err, Errors: errs}}, "  ")
+}
+
 // NewDetailViewOK returns a new detail view with a 200.
 func NewDetailViewOK(data interface{}) DetailView {
 	return NewDetailView(data, http.StatusOK)
@@ -107,3 +107,57 @@ func TestNewListViewOk(t *testing.T) {
 	assert.Equal(t, view.StatusCode, http.StatusOK)
 	assert.Equal(t, view.Data, data)
 }
+
+func TestJSONDetailViewOKNotModified(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	assert.Nil(t, web.JSONDetailViewOK(c, struct{}{}))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	etag := rec.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	assert.Nil(t, web.JSONDetailViewOK(c, struct{}{}))
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Equal(t, etag, rec.Header().Get("ETag"))
+	assert.Equal(t, 0, rec.Body.Len())
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-None-Match", `"stale"`)
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	assert.Nil(t, web.JSONDetailViewOK(c, struct{}{}))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// a proxy can mark the ETag weak, which still matches.
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-None-Match", `"stale", W/`+etag)
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	assert.Nil(t, web.JSONDetailViewOK(c, struct{}{}))
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+}
+
+func TestJSONListViewOKNotModifiedSince(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-Modified-Since", "Wed, 01 May 2019 12:00:00 GMT")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Response().Header().Set("Last-Modified", "Wed, 01 May 2019 12:00:00 GMT")
+	assert.Nil(t, web.JSONListViewOK(c, make([]interface{}, 1), 10))
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-Modified-Since", "Tue, 30 Apr 2019 12:00:00 GMT")
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	c.Response().Header().Set("Last-Modified", "Wed, 01 May 2019 12:00:00 GMT")
+	assert.Nil(t, web.JSONListViewOK(c, make([]interface{}, 1), 10))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}